@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/elastic/beats/libbeat/api"
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
@@ -14,6 +15,7 @@ import (
 	cfg "github.com/elastic/beats/filebeat/config"
 	"github.com/elastic/beats/filebeat/crawler"
 	"github.com/elastic/beats/filebeat/fileset"
+	"github.com/elastic/beats/filebeat/prospector"
 	"github.com/elastic/beats/filebeat/publisher"
 	"github.com/elastic/beats/filebeat/registrar"
 	"github.com/elastic/beats/filebeat/spooler"
@@ -67,9 +69,70 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 		config:         &config,
 		moduleRegistry: moduleRegistry,
 	}
+	api.RegisterState("prospectors", fb.prospectorsState)
 	return fb, nil
 }
 
+// prospectorsState reports the statically configured prospectors for the
+// HTTP endpoint.
+func (fb *Filebeat) prospectorsState() interface{} {
+	state := []common.MapStr{}
+	for _, c := range fb.config.Prospectors {
+		if !c.Enabled() {
+			continue
+		}
+
+		var prospector struct {
+			InputType string   `config:"input_type"`
+			Paths     []string `config:"paths"`
+		}
+		prospector.InputType = cfg.DefaultInputType
+		if err := c.Unpack(&prospector); err != nil {
+			continue
+		}
+		state = append(state, common.MapStr{
+			"input_type": prospector.InputType,
+			"paths":      prospector.Paths,
+		})
+	}
+	return state
+}
+
+// CheckConfig creates the prospectors, including the ones of the modules and
+// config_dir, and validates their harvester settings without starting them.
+func (fb *Filebeat) CheckConfig() []error {
+	var errs []error
+	for i, c := range fb.config.Prospectors {
+		if !c.Enabled() {
+			continue
+		}
+
+		p, err := prospector.NewProspector(c, nil, fb.done)
+		if err == nil {
+			err = p.LoadStates(nil)
+		}
+		if err != nil {
+			path := c.Path()
+			if path == "" {
+				path = fmt.Sprintf("filebeat.prospectors.%d", i)
+			}
+			errs = append(errs, fmt.Errorf("%v: %v", path, err))
+		}
+	}
+	return errs
+}
+
+// ExpandConfig sets the prospectors to the complete list of prospectors,
+// including the ones of the modules and config_dir.
+func (fb *Filebeat) ExpandConfig(section *common.Config) error {
+	for i, c := range fb.config.Prospectors {
+		if err := section.SetChild("prospectors", i, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // modulesSetup is called when modules are configured to do the initial
 // setup.
 func (fb *Filebeat) modulesSetup(b *beat.Beat) error {
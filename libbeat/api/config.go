@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+	"time"
+)
+
+// Config is the configuration of the HTTP endpoint.
+type Config struct {
+	Enabled bool         `config:"enabled"`
+	Host    string       `config:"host"`
+	Port    int          `config:"port"`
+	Health  HealthConfig `config:"health"`
+}
+
+// HealthConfig configures the /health resource.
+type HealthConfig struct {
+	// FailureThreshold is the duration the outputs have to be failing
+	// continuously before the Beat is reported as degraded.
+	FailureThreshold time.Duration `config:"failure_threshold"`
+}
+
+// DefaultConfig is the default configuration of the HTTP endpoint.
+var DefaultConfig = Config{
+	Enabled: false,
+	Host:    "localhost",
+	Port:    5066,
+	Health: HealthConfig{
+		FailureThreshold: 60 * time.Second,
+	},
+}
+
+func (c *Config) Validate() error {
+	if c.Health.FailureThreshold <= 0 {
+		return errors.New("health.failure_threshold must be greater than 0")
+	}
+	return nil
+}
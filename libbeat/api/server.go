@@ -0,0 +1,197 @@
+// Package api implements an optional HTTP endpoint reporting the Beat its
+// metrics, state and health as JSON.
+//
+// The endpoint serves the resources:
+//
+//	/stats   all metrics of the monitoring registry and expvar
+//	/state   Beat info, uptime, configured outputs and registered states
+//	/health  green, or degraded if the outputs fail for too long
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+)
+
+// Info holds the Beat details reported by the /state resource.
+type Info struct {
+	Name    string
+	Version string
+	UUID    string
+	Outputs []string
+}
+
+// Server serves the HTTP endpoint.
+type Server struct {
+	config   Config
+	info     Info
+	start    time.Time
+	listener net.Listener
+	mux      *http.ServeMux
+}
+
+var debugf = logp.MakeDebug("api")
+
+// New creates a new server from the `http` configuration section. If cfg is
+// nil or the endpoint is not enabled, no server is created and nil is
+// returned.
+func New(cfg *common.Config, info Info) (*Server, error) {
+	config := DefaultConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	network, address := listenAddress(config.Host, config.Port)
+	if network == "unix" {
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %v: %v", address, err)
+	}
+
+	s := &Server{
+		config:   config,
+		info:     info,
+		start:    time.Now(),
+		listener: l,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/state", s.handleState)
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	return s, nil
+}
+
+// listenAddress returns the network and address to listen on. Hosts of the
+// form `unix:///path/to/socket` listen on a unix socket.
+func listenAddress(host string, port int) (string, string) {
+	if strings.HasPrefix(host, "unix://") {
+		return "unix", strings.TrimPrefix(host, "unix://")
+	}
+	return "tcp", net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// removeStaleSocket removes the unix socket left at path by a previous run
+// that did not shut down cleanly. Files that are not sockets are never
+// removed.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("failed to listen on %v: file exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Start serves requests in the background until Stop is called.
+func (s *Server) Start() {
+	logp.Info("Starting HTTP endpoint on %v", s.listener.Addr())
+	go func() {
+		err := http.Serve(s.listener, s.mux)
+		debugf("HTTP endpoint stopped: %v", err)
+	}()
+}
+
+// Stop closes the listener and removes the unix socket, if any.
+func (s *Server) Stop() error {
+	err := s.listener.Close()
+	if network, address := listenAddress(s.config.Host, s.config.Port); network == "unix" {
+		if rmErr := os.Remove(address); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK,
+		monitoring.CollectStructSnapshot(monitoring.Default, monitoring.Full, true))
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	outputs := append([]string{}, s.info.Outputs...)
+	sort.Strings(outputs)
+
+	state := collectStates()
+	state["beat"] = map[string]interface{}{
+		"name":    s.info.Name,
+		"version": s.info.Version,
+		"uuid":    s.info.UUID,
+	}
+	state["uptime"] = map[string]interface{}{
+		"ms": int64(time.Since(s.start) / time.Millisecond),
+	}
+	state["outputs"] = outputs
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := mode.Health()
+
+	status := "green"
+	code := http.StatusOK
+	if !health.FailingSince.IsZero() &&
+		time.Since(health.FailingSince) > s.config.Health.FailureThreshold {
+		status = "degraded"
+		code = http.StatusServiceUnavailable
+	}
+
+	body := map[string]interface{}{
+		"status":   status,
+		"failures": health.Failures,
+	}
+	if !health.LastSuccess.IsZero() {
+		body["last_success"] = common.Time(health.LastSuccess)
+	}
+	if !health.FailingSince.IsZero() {
+		body["failing_since"] = common.Time(health.FailingSince)
+	}
+	writeJSON(w, code, body)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	err := monitoring.WritePrometheus(w, monitoring.Default, monitoring.Full, true)
+	if err != nil {
+		logp.Err("Failed to write Prometheus metrics: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logp.Err("Failed to encode HTTP endpoint response: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// +build !integration
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+)
+
+func newTestServer(t *testing.T, settings map[string]interface{}) *Server {
+	cfg, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(cfg, Info{
+		Name:    "testbeat",
+		Version: "1.0.0",
+		UUID:    "uuid",
+		Outputs: []string{"logstash", "elasticsearch"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	return s
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, map[string]interface{}) {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestDisabled(t *testing.T) {
+	s, err := New(nil, Info{})
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestStatsAndState(t *testing.T) {
+	s := newTestServer(t, map[string]interface{}{
+		"enabled": true,
+		"port":    0,
+	})
+	defer s.Stop()
+
+	monitoring.NewInt(nil, "test.api.value").Set(3)
+	RegisterState("modules", func() interface{} { return []string{"system"} })
+
+	url := "http://" + s.Addr().String()
+	code, stats := get(t, http.DefaultClient, url+"/stats")
+	assert.Equal(t, http.StatusOK, code)
+	value, _ := common.MapStr(stats).GetValue("test.api.value")
+	assert.Equal(t, float64(3), value)
+
+	code, state := get(t, http.DefaultClient, url+"/state")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{
+		"name":    "testbeat",
+		"version": "1.0.0",
+		"uuid":    "uuid",
+	}, state["beat"])
+	assert.Equal(t, []interface{}{"elasticsearch", "logstash"}, state["outputs"])
+	assert.Equal(t, []interface{}{"system"}, state["modules"])
+	assert.Contains(t, state, "uptime")
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer(t, map[string]interface{}{
+		"enabled":                  true,
+		"port":                     0,
+		"health.failure_threshold": "10ms",
+	})
+	defer s.Stop()
+	url := "http://" + s.Addr().String() + "/health"
+
+	tracker := mode.NewHealthTracker()
+	defer tracker.Close()
+
+	tracker.PublishSucceeded()
+	code, health := get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "green", health["status"])
+
+	tracker.PublishFailed()
+	code, health = get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), health["failures"])
+
+	time.Sleep(20 * time.Millisecond)
+	code, health = get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "degraded", health["status"])
+	assert.Contains(t, health, "failing_since")
+
+	tracker.PublishSucceeded()
+	code, _ = get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthReportsWorstOutput(t *testing.T) {
+	s := newTestServer(t, map[string]interface{}{
+		"enabled":                  true,
+		"port":                     0,
+		"health.failure_threshold": "10ms",
+	})
+	defer s.Stop()
+	url := "http://" + s.Addr().String() + "/health"
+
+	healthy := mode.NewHealthTracker()
+	defer healthy.Close()
+	failing := mode.NewHealthTracker()
+
+	failing.PublishFailed()
+	time.Sleep(20 * time.Millisecond)
+	healthy.PublishSucceeded()
+
+	code, health := get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "degraded", health["status"])
+	assert.Equal(t, float64(1), health["failures"])
+
+	// closed outputs no longer contribute to the health
+	failing.Close()
+	code, health = get(t, http.DefaultClient, url)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "green", health["status"])
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t, map[string]interface{}{
+		"enabled": true,
+		"port":    0,
+	})
+	defer s.Stop()
+
+	monitoring.NewInt(nil, "libbeat.redis.publish.test_bytes").Set(5)
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(body), `libbeat_output_publish_test_bytes{output="redis"} 5`)
+}
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "beat.sock")
+
+	s := newTestServer(t, map[string]interface{}{
+		"enabled": true,
+		"host":    "unix://" + path,
+	})
+	defer s.Stop()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) {
+				return net.Dial("unix", path)
+			},
+		},
+	}
+	code, state := get(t, client, "http://unix/state")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, state, "beat")
+
+	s.Stop()
+	_, err = os.Lstat(path)
+	assert.True(t, os.IsNotExist(err), "socket not removed on stop")
+}
+
+func TestUnixSocketStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "beat.sock")
+
+	// leave a socket behind like a crashed Beat would, moving it away from
+	// the path the listener removes on close
+	l, err := net.Listen("unix", path+".tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	s := newTestServer(t, map[string]interface{}{
+		"enabled": true,
+		"host":    "unix://" + path,
+	})
+	s.Stop()
+}
+
+func TestUnixSocketNoSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "beat.sock")
+	if err := ioutil.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"enabled": true,
+		"host":    "unix://" + path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(cfg, Info{})
+	assert.Error(t, err)
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "regular file removed")
+}
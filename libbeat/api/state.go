@@ -0,0 +1,36 @@
+package api
+
+import "sync"
+
+// StateFunc returns a JSON encodable state reported by the /state resource.
+type StateFunc func() interface{}
+
+var states = struct {
+	sync.Mutex
+	funcs map[string]StateFunc
+}{funcs: map[string]StateFunc{}}
+
+// RegisterState registers a function reporting part of the Beat state, e.g.
+// the loaded modules or prospectors. The state is reported under the given
+// name. Registering a name again replaces the former function.
+func RegisterState(name string, fn StateFunc) {
+	states.Lock()
+	defer states.Unlock()
+	states.funcs[name] = fn
+}
+
+// collectStates calls all registered state functions.
+func collectStates() map[string]interface{} {
+	states.Lock()
+	funcs := make(map[string]StateFunc, len(states.funcs))
+	for name, fn := range states.funcs {
+		funcs[name] = fn
+	}
+	states.Unlock()
+
+	result := make(map[string]interface{}, len(funcs))
+	for name, fn := range funcs {
+		result[name] = fn()
+	}
+	return result
+}
@@ -42,6 +42,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/elastic/beats/libbeat/api"
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/dashboards/dashboards"
@@ -57,6 +58,7 @@ import (
 	// Register default processors.
 	_ "github.com/elastic/beats/libbeat/processors/actions"
 	_ "github.com/elastic/beats/libbeat/processors/add_cloud_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/add_host_metadata"
 )
 
 // Beater is the interface that must be implemented by every Beat. A Beater
@@ -93,6 +95,13 @@ type Beat struct {
 	RawConfig *common.Config      // Raw config that can be unpacked to get Beat specific config data.
 	Config    BeatConfig          // Common Beat configuration data.
 	Publisher publisher.Publisher // Publisher
+
+	// ConfigCheck is set if the Beater is only created to run the
+	// `config check` command. Beaters implementing ConfigChecker must not
+	// fail on the settings validated by CheckConfig.
+	ConfigCheck bool
+
+	publisher *publisher.BeatPublisher
 }
 
 // BeatConfig struct contains the basic configuration of every beat
@@ -103,6 +112,9 @@ type BeatConfig struct {
 	Processors processors.PluginConfig   `config:"processors"`
 	Path       paths.Path                `config:"path"`
 	Dashboards *common.Config            `config:"dashboards"`
+	HTTP       *common.Config            `config:"http"`
+	Publisher  *common.Config            `config:"publisher"`
+	Keystore   *common.Config            `config:"keystore"`
 }
 
 var (
@@ -171,36 +183,19 @@ func (b *Beat) launch(bt Creator) error {
 		return err
 	}
 
-	// load the beats config section
-	var sub *common.Config
-	configName := strings.ToLower(b.Name)
-	if b.RawConfig.HasField(configName) {
-		sub, err = b.RawConfig.Child(configName, -1)
-		if err != nil {
-			return err
-		}
-	} else {
-		sub = common.NewConfig()
+	if err := b.handleKeystoreCommand(); err != nil {
+		return err
 	}
 
-	logp.Info("Setup Beat: %s; Version: %s", b.Name, b.Version)
-	processors, err := processors.New(b.Config.Processors)
-	if err != nil {
-		return fmt.Errorf("error initializing processors: %v", err)
+	if err := b.handleConfigCommand(bt); err != nil {
+		return err
 	}
 
-	debugf("Initializing output plugins")
-	publisher, err := publisher.New(b.Name, b.Version, b.Config.Output, b.Config.Shipper, processors)
-	if err != nil {
-		return fmt.Errorf("error initializing publisher: %v", err)
+	if err := b.handleTestCommand(); err != nil {
+		return err
 	}
 
-	// TODO: some beats race on shutdown with publisher.Stop -> do not call Stop yet,
-	//       but refine publisher to disconnect clients on stop automatically
-	// defer publisher.Stop()
-
-	b.Publisher = publisher
-	beater, err := bt(b, sub)
+	beater, err := b.createBeater(bt)
 	if err != nil {
 		return err
 	}
@@ -218,6 +213,24 @@ func (b *Beat) launch(bt Creator) error {
 		return err
 	}
 
+	reloader, err := newPublisherReloader(b.publisher, b.Config.Publisher, b.RawConfig)
+	if err != nil {
+		return fmt.Errorf("error initializing publisher reloading: %v", err)
+	}
+	if reloader != nil {
+		reloader.Start()
+		defer reloader.Stop()
+	}
+
+	apiServer, err := api.New(b.Config.HTTP, b.apiInfo())
+	if err != nil {
+		return fmt.Errorf("error initializing HTTP endpoint: %v", err)
+	}
+	if apiServer != nil {
+		apiServer.Start()
+		defer apiServer.Stop()
+	}
+
 	logp.Info("%s start running.", b.Name)
 	defer logp.Info("%s stopped.", b.Name)
 	defer logp.LogTotalExpvars(&b.Config.Logging)
@@ -225,6 +238,62 @@ func (b *Beat) launch(bt Creator) error {
 	return beater.Run(b)
 }
 
+// createBeater initializes the processors and the publisher and creates the
+// Beater from the Beat specific configuration section.
+func (b *Beat) createBeater(bt Creator) (Beater, error) {
+	sub, err := b.beatConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	logp.Info("Setup Beat: %s; Version: %s", b.Name, b.Version)
+	processors, err := processors.New(b.Config.Processors)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing processors: %v", err)
+	}
+
+	debugf("Initializing output plugins")
+	publisher, err := publisher.New(b.Name, b.Version, b.Config.Output, b.Config.Shipper, processors)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing publisher: %v", err)
+	}
+
+	// TODO: some beats race on shutdown with publisher.Stop -> do not call Stop yet,
+	//       but refine publisher to disconnect clients on stop automatically
+	// defer publisher.Stop()
+
+	b.publisher = publisher
+	b.Publisher = publisher
+	return bt(b, sub)
+}
+
+// beatConfig returns the Beat specific configuration section, named after
+// the Beat.
+func (b *Beat) beatConfig() (*common.Config, error) {
+	configName := strings.ToLower(b.Name)
+	if !b.RawConfig.HasField(configName) {
+		return common.NewConfig(), nil
+	}
+	return b.RawConfig.Child(configName, -1)
+}
+
+// apiInfo returns the Beat details reported by the HTTP endpoint.
+func (b *Beat) apiInfo() api.Info {
+	var outputs []string
+	for name, cfg := range b.Config.Output {
+		if cfg.Enabled() {
+			outputs = append(outputs, name)
+		}
+	}
+
+	return api.Info{
+		Name:    b.Name,
+		Version: b.Version,
+		UUID:    b.UUID.String(),
+		Outputs: outputs,
+	}
+}
+
 // handleFlags parses the command line flags. It handles the '-version' flag
 // and invokes the HandleFlags callback if implemented by the Beat.
 func (b *Beat) handleFlags() error {
@@ -274,6 +343,11 @@ func (b *Beat) configure() error {
 		return fmt.Errorf("error setting default paths: %v", err)
 	}
 
+	err = b.initKeystore()
+	if err != nil {
+		return err
+	}
+
 	err = logp.Init(b.Name, &b.Config.Logging)
 	if err != nil {
 		return fmt.Errorf("error initializing logging: %v", err)
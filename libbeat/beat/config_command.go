@@ -0,0 +1,182 @@
+package beat
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+// ConfigChecker is an optional interface for Beaters validating the settings
+// of components, that are only created when running, e.g. prospectors. It is
+// used by the `config check` command.
+type ConfigChecker interface {
+	// CheckConfig returns all errors found, each naming the config path of
+	// the invalid setting.
+	CheckConfig() []error
+}
+
+// ConfigExpander is an optional interface for Beaters adding settings, not
+// present in the configuration files, to their section. E.g. settings
+// included from other files or generated by modules. It is used by the
+// `config export` command.
+type ConfigExpander interface {
+	ExpandConfig(section *common.Config) error
+}
+
+const configUsage = "usage: config check | export"
+
+// handleConfigCommand runs the `config` subcommand, if given on the command
+// line. It returns GracefulExit if the command succeeded.
+func (b *Beat) handleConfigCommand(bt Creator) error {
+	if flag.NArg() == 0 || flag.Arg(0) != "config" {
+		return nil
+	}
+
+	if flag.NArg() != 2 {
+		return errors.New(configUsage)
+	}
+
+	// the Beater is only created, but not run, so there is nothing to publish
+	publisher.DisablePublishing()
+
+	var err error
+	switch flag.Arg(1) {
+	case "check":
+		err = b.checkConfig(bt, os.Stdout)
+	case "export":
+		err = b.exportConfig(bt, os.Stdout)
+	default:
+		err = fmt.Errorf("unknown config command '%v', %v", flag.Arg(1), configUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+	return GracefulExit
+}
+
+// checkConfig validates the processors, the outputs and the Beat specific
+// settings, without connecting the outputs or starting the Beater. All errors
+// found are printed.
+func (b *Beat) checkConfig(bt Creator, out io.Writer) error {
+	b.ConfigCheck = true
+	errs := checkProcessors(b.Config.Processors)
+	if len(errs) > 0 {
+		// the errors are reported already, check the Beater without processors
+		b.Config.Processors = nil
+	}
+	errs = append(errs, b.checkOutputs()...)
+
+	beater, err := b.createBeater(bt)
+	if err != nil {
+		errs = append(errs, err)
+	} else if checker, ok := beater.(ConfigChecker); ok {
+		errs = append(errs, checker.CheckConfig()...)
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+		return fmt.Errorf("config check found %d error(s)", len(errs))
+	}
+
+	fmt.Fprintln(out, "Config OK")
+	return nil
+}
+
+// checkProcessors compiles each processor, including its conditions.
+func checkProcessors(config processors.PluginConfig) []error {
+	var errs []error
+	for i, processor := range config {
+		if _, err := processors.New(processors.PluginConfig{processor}); err != nil {
+			errs = append(errs, fmt.Errorf("processors.%d: %v", i, err))
+		}
+	}
+	return errs
+}
+
+// checkOutputs validates the settings of the enabled outputs, without creating
+// the outputs or connecting to any host. Outputs not registering a checker are
+// only checked to be known.
+func (b *Beat) checkOutputs() []error {
+	names := enabledOutputs(b.Config.Output)
+	if len(names) == 0 {
+		return []error{errors.New("output: no outputs are defined")}
+	}
+
+	var errs []error
+	for _, name := range names {
+		if outputs.FindOutputPlugin(name) == nil {
+			errs = append(errs, fmt.Errorf("output.%v: unknown output type", name))
+			continue
+		}
+
+		checker := outputs.FindOutputChecker(name)
+		if checker == nil {
+			continue
+		}
+		if err := checker(b.Name, b.Config.Output[name]); err != nil {
+			errs = append(errs, fmt.Errorf("output.%v: %v", name, err))
+		}
+	}
+	return errs
+}
+
+// enabledOutputs returns the sorted names of the enabled outputs.
+func enabledOutputs(configs map[string]*common.Config) []string {
+	names := make([]string, 0, len(configs))
+	for name, config := range configs {
+		if config.Enabled() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exportConfig prints the complete configuration, including the command line
+// overwrites and the settings added by the Beater, as YAML. Secrets are not
+// exported.
+func (b *Beat) exportConfig(bt Creator, out io.Writer) error {
+	beater, err := b.createBeater(bt)
+	if err != nil {
+		return err
+	}
+
+	if expander, ok := beater.(ConfigExpander); ok {
+		section, err := b.beatConfig()
+		if err != nil {
+			return err
+		}
+		if err := expander.ExpandConfig(section); err != nil {
+			return fmt.Errorf("error expanding %v configuration: %v", b.Name, err)
+		}
+		if err := b.RawConfig.SetChild(strings.ToLower(b.Name), -1, section); err != nil {
+			return err
+		}
+	}
+
+	content, err := b.RawConfig.Export()
+	if err != nil {
+		return fmt.Errorf("error exporting configuration: %v", err)
+	}
+
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("error exporting configuration: %v", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,149 @@
+// +build !integration
+
+package beat
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+)
+
+type testBeater struct {
+	checkErrs []error
+}
+
+func (t *testBeater) Run(b *Beat) error { return nil }
+func (t *testBeater) Stop()             {}
+
+func (t *testBeater) CheckConfig() []error { return t.checkErrs }
+
+func (t *testBeater) ExpandConfig(section *common.Config) error {
+	return section.SetString("expanded", -1, "yes")
+}
+
+func newTestBeat(t *testing.T, settings map[string]interface{}) *Beat {
+	cfg, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+
+	b := newBeat("testbeat", "")
+	b.RawConfig = cfg
+	require.NoError(t, cfg.Unpack(&b.Config))
+	return b
+}
+
+// disablePublishing runs the publisher in dry run mode, like the config
+// command does, until the returned function is called.
+func disablePublishing(t *testing.T) func() {
+	require.NoError(t, flag.Set("N", "true"))
+	return func() { flag.Set("N", "false") }
+}
+
+func TestCheckConfig(t *testing.T) {
+	defer disablePublishing(t)()
+
+	b := newTestBeat(t, map[string]interface{}{
+		"processors": []map[string]interface{}{
+			{"drop_fields.fields": []string{"a"}},
+			{"drop_event.when.regexp.message": "["},
+		},
+		"output.elasticsearch.hosts": []string{},
+		"output.unknown.enabled":     true,
+	})
+
+	beater := &testBeater{checkErrs: []error{errors.New("testbeat.setting: invalid")}}
+	creator := func(*Beat, *common.Config) (Beater, error) { return beater, nil }
+
+	var out bytes.Buffer
+	err := b.checkConfig(creator, &out)
+	assert.Error(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 4, out.String())
+	assert.Contains(t, string(lines[0]), "Error: processors.1: error parsing regexp")
+	assert.Contains(t, string(lines[1]), "Error: output.elasticsearch:")
+	assert.Equal(t, "Error: output.unknown: unknown output type", string(lines[2]))
+	assert.Equal(t, "Error: testbeat.setting: invalid", string(lines[3]))
+}
+
+func TestCheckConfigOK(t *testing.T) {
+	defer disablePublishing(t)()
+
+	b := newTestBeat(t, map[string]interface{}{
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":            []string{"localhost:9200"},
+			"template.enabled": false,
+		},
+	})
+	creator := func(*Beat, *common.Config) (Beater, error) { return &testBeater{}, nil }
+
+	var out bytes.Buffer
+	require.NoError(t, b.checkConfig(creator, &out))
+	assert.Equal(t, "Config OK\n", out.String())
+}
+
+func TestCheckConfigDoesNotCreateOutputs(t *testing.T) {
+	defer disablePublishing(t)()
+
+	created := false
+	outputs.RegisterOutputPlugin("checktest", func(string, *common.Config, int) (outputs.Outputer, error) {
+		created = true
+		return nil, errors.New("not supported")
+	})
+	outputs.RegisterOutputChecker("checktest", func(_ string, cfg *common.Config) error {
+		if !cfg.HasField("valid") {
+			return errors.New("valid required")
+		}
+		return nil
+	})
+
+	b := newTestBeat(t, map[string]interface{}{"output.checktest.enabled": true})
+	creator := func(*Beat, *common.Config) (Beater, error) { return &testBeater{}, nil }
+
+	var out bytes.Buffer
+	assert.Error(t, b.checkConfig(creator, &out))
+	assert.Equal(t, "Error: output.checktest: valid required\n", out.String())
+	assert.False(t, created)
+}
+
+func TestCheckConfigNoOutputs(t *testing.T) {
+	defer disablePublishing(t)()
+
+	b := newTestBeat(t, map[string]interface{}{})
+	creator := func(*Beat, *common.Config) (Beater, error) { return &testBeater{}, nil }
+
+	var out bytes.Buffer
+	assert.Error(t, b.checkConfig(creator, &out))
+	assert.Equal(t, "Error: output: no outputs are defined\n", out.String())
+}
+
+func TestExportConfig(t *testing.T) {
+	defer disablePublishing(t)()
+
+	b := newTestBeat(t, map[string]interface{}{
+		"testbeat.period": "10s",
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":    []string{"localhost:9200"},
+			"password": "secret",
+		},
+	})
+	creator := func(*Beat, *common.Config) (Beater, error) { return &testBeater{}, nil }
+
+	var out bytes.Buffer
+	require.NoError(t, b.exportConfig(creator, &out))
+	assert.Equal(t, `output:
+  elasticsearch:
+    hosts:
+    - localhost:9200
+    password: xxxxx
+testbeat:
+  expanded: "yes"
+  period: 10s
+`, out.String())
+}
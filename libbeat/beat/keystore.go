@@ -0,0 +1,190 @@
+package beat
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/keystore"
+	"github.com/elastic/beats/libbeat/paths"
+)
+
+type keystoreConfig struct {
+	Path string `config:"path"`
+}
+
+// keystorePasswordEnv is the environment variable holding the password the
+// keystore is encrypted with. Without a password the keystore is only
+// obfuscated, and must be protected by its file permissions.
+const keystorePasswordEnv = "BEAT_KEYSTORE_PASSWORD"
+
+// openKeystore opens the keystore configured by `keystore.path`, defaulting
+// to `<beat>.keystore` in the data path. The password is read from the
+// BEAT_KEYSTORE_PASSWORD environment variable.
+func (b *Beat) openKeystore() (*keystore.FileKeystore, error) {
+	config := keystoreConfig{
+		Path: paths.Resolve(paths.Data, b.Name+".keystore"),
+	}
+	if b.Config.Keystore != nil {
+		if err := b.Config.Keystore.Unpack(&config); err != nil {
+			return nil, fmt.Errorf("error unpacking keystore config: %v", err)
+		}
+	}
+
+	return keystore.NewFileKeystore(config.Path, os.Getenv(keystorePasswordEnv))
+}
+
+// initKeystore registers the keystore for resolving configuration variables.
+func (b *Beat) initKeystore() error {
+	store, err := b.openKeystore()
+	if err != nil {
+		return fmt.Errorf("error opening keystore: %v", err)
+	}
+
+	common.SetSecretsResolver(keystore.ConfigResolver(store))
+	return nil
+}
+
+// handleKeystoreCommand runs the `keystore` subcommand, if given on the
+// command line. It returns GracefulExit if the command succeeded.
+func (b *Beat) handleKeystoreCommand() error {
+	if flag.NArg() == 0 || flag.Arg(0) != "keystore" {
+		return nil
+	}
+
+	store, err := b.openKeystore()
+	if err != nil {
+		return fmt.Errorf("error opening keystore: %v", err)
+	}
+
+	if err := runKeystoreCommand(store, flag.Args()[1:], os.Stdin, os.Stdout); err != nil {
+		return err
+	}
+	if os.Getenv(keystorePasswordEnv) == "" && flag.Arg(1) == "create" {
+		fmt.Fprintf(os.Stderr, "Warning: %v is not set, the keystore is not "+
+			"protected by a password and must only be readable by its owner\n",
+			keystorePasswordEnv)
+	}
+	return GracefulExit
+}
+
+const keystoreUsage = "usage: keystore create [--force] | add KEY [--force] | list | remove KEY..."
+
+func runKeystoreCommand(store *keystore.FileKeystore, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(keystoreUsage)
+	}
+
+	fs := flag.NewFlagSet("keystore "+args[0], flag.ContinueOnError)
+	force := fs.Bool("force", false, "Overwrite existing keystore or key")
+
+	// allow flags after positional arguments, e.g. `add KEY --force`
+	var params []string
+	rest := args[1:]
+	for len(rest) > 0 {
+		if err := fs.Parse(rest); err != nil {
+			return err
+		}
+		rest = fs.Args()
+		if len(rest) > 0 {
+			params = append(params, rest[0])
+			rest = rest[1:]
+		}
+	}
+
+	switch args[0] {
+	case "create":
+		if store.IsPersisted() && !*force {
+			return fmt.Errorf("keystore %v already exists, use --force to overwrite it", store.Path())
+		}
+		for _, key := range store.List() {
+			store.Delete(key)
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created keystore %v\n", store.Path())
+
+	case "add":
+		if len(params) != 1 {
+			return errors.New("keystore add requires exactly one key")
+		}
+		key := params[0]
+		if _, err := store.Retrieve(key); err == nil && !*force {
+			return fmt.Errorf("key %v already exists, use --force to overwrite it", key)
+		}
+
+		value, err := readSecret(in)
+		if err != nil {
+			return fmt.Errorf("error reading value of key %v: %v", key, err)
+		}
+		if err := store.Store(key, value); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added key %v\n", key)
+
+	case "list":
+		for _, key := range store.List() {
+			fmt.Fprintln(out, key)
+		}
+
+	case "remove":
+		if len(params) == 0 {
+			return errors.New("keystore remove requires at least one key")
+		}
+		for _, key := range params {
+			if err := store.Delete(key); err != nil {
+				return fmt.Errorf("error removing key %v: %v", key, err)
+			}
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed %v\n", strings.Join(params, ", "))
+
+	default:
+		return fmt.Errorf("unknown keystore command '%v', %v", args[0], keystoreUsage)
+	}
+
+	return nil
+}
+
+// readSecret reads the value to store from the input. A single line is read
+// if the input is a terminal, otherwise all of the input is used.
+func readSecret(in io.Reader) ([]byte, error) {
+	var value string
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		fmt.Fprint(os.Stderr, "Enter value: ")
+		line, err := bufio.NewReader(f).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		value = line
+	} else {
+		content, err := ioutil.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		value = string(content)
+	}
+
+	value = strings.TrimRight(value, "\r\n")
+	if value == "" {
+		return nil, errors.New("value must not be empty")
+	}
+	return []byte(value), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,108 @@
+// +build !integration
+
+package beat
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/keystore"
+)
+
+func TestKeystoreCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.keystore")
+	store, err := keystore.NewFileKeystore(path, "")
+	require.NoError(t, err)
+
+	run := func(input string, args ...string) (string, error) {
+		var out bytes.Buffer
+		err := runKeystoreCommand(store, args, strings.NewReader(input), &out)
+		return out.String(), err
+	}
+
+	_, err = run("", "create")
+	require.NoError(t, err)
+	_, err = run("", "create")
+	assert.Error(t, err, "create must not overwrite without --force")
+
+	_, err = run("changeme\n", "add", "es.password")
+	require.NoError(t, err)
+	_, err = run("other\n", "add", "es.password")
+	assert.Error(t, err, "add must not overwrite without --force")
+	_, err = run("secret\n", "add", "es.password", "--force")
+	require.NoError(t, err)
+	_, err = run("value", "add", "kafka.password")
+	require.NoError(t, err)
+	_, err = run("", "add", "empty")
+	assert.Error(t, err)
+
+	out, err := run("", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "es.password\nkafka.password\n", out)
+
+	store, err = keystore.NewFileKeystore(path, "")
+	require.NoError(t, err)
+	secret, err := store.Retrieve("es.password")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(secret))
+
+	_, err = run("", "remove", "kafka.password")
+	require.NoError(t, err)
+	_, err = run("", "remove", "kafka.password")
+	assert.Error(t, err)
+
+	out, err = run("", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "es.password\n", out)
+
+	_, err = run("", "create", "--force")
+	require.NoError(t, err)
+	out, err = run("", "list")
+	require.NoError(t, err)
+	assert.Equal(t, "", out)
+
+	_, err = run("", "unknown")
+	assert.Error(t, err)
+}
+
+func TestOpenKeystorePassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv(keystorePasswordEnv)
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"path": filepath.Join(dir, "test.keystore"),
+	})
+	require.NoError(t, err)
+	b := &Beat{Name: "test", Config: BeatConfig{Keystore: cfg}}
+
+	os.Setenv(keystorePasswordEnv, "secret")
+	store, err := b.openKeystore()
+	require.NoError(t, err)
+	require.NoError(t, store.Store("es.password", []byte("changeme")))
+	require.NoError(t, store.Save())
+
+	os.Unsetenv(keystorePasswordEnv)
+	_, err = b.openKeystore()
+	assert.Error(t, err, "keystore must not open without the password")
+
+	os.Setenv(keystorePasswordEnv, "secret")
+	store, err = b.openKeystore()
+	require.NoError(t, err)
+	secret, err := store.Retrieve("es.password")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", string(secret))
+}
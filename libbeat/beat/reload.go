@@ -0,0 +1,203 @@
+package beat
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/publisher"
+	svc "github.com/elastic/beats/libbeat/service"
+)
+
+var (
+	publisherReloads        = expvar.NewInt("libbeat.config.publisher.reloads")
+	publisherReloadFailures = expvar.NewInt("libbeat.config.publisher.failures")
+)
+
+type publisherReloadConfig struct {
+	Reload publisherReload `config:"reload"`
+}
+
+type publisherReload struct {
+	cfgfile.Reload `config:",inline"`
+
+	// DrainTimeout bounds waiting for the former outputs to publish their
+	// pending events, before they are closed.
+	DrainTimeout time.Duration `config:"drain_timeout" validate:"nonzero,positive"`
+}
+
+var defaultPublisherReloadConfig = publisherReloadConfig{
+	Reload: publisherReload{
+		Reload:       cfgfile.DefaultReloadConfig.Reload,
+		DrainTimeout: 30 * time.Second,
+	},
+}
+
+// reloadSections holds the configuration sections rebuilt on reload.
+type reloadSections struct {
+	Output     map[string]*common.Config `config:"output"`
+	Processors processors.PluginConfig   `config:"processors"`
+}
+
+// publisherReloader rebuilds the processors and outputs of the publisher if
+// the configuration files change or SIGHUP is received.
+type publisherReloader struct {
+	publisher *publisher.BeatPublisher
+	period    time.Duration
+	timeout   time.Duration
+	watchers  []*cfgfile.GlobWatcher
+	hash      uint64
+
+	trigger chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newPublisherReloader creates a reloader from the `publisher` configuration
+// section. It returns nil if reloading is not enabled.
+func newPublisherReloader(
+	pub *publisher.BeatPublisher,
+	cfg *common.Config,
+	rawConfig *common.Config,
+) (*publisherReloader, error) {
+	config := defaultPublisherReloadConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+	if !config.Reload.Enabled {
+		return nil, nil
+	}
+
+	hash, err := hashReloadSections(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &publisherReloader{
+		publisher: pub,
+		period:    config.Reload.Period,
+		timeout:   config.Reload.DrainTimeout,
+		hash:      hash,
+		trigger:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	for _, file := range cfgfile.GetConfigFiles() {
+		gw := cfgfile.NewGlobWatcher(file)
+		// initial scan, such that only later changes trigger a reload
+		gw.Scan()
+		r.watchers = append(r.watchers, gw)
+	}
+	return r, nil
+}
+
+// hashReloadSections hashes the output and processors sections of the
+// configuration, to skip reloading if these did not change.
+func hashReloadSections(cfg *common.Config) (uint64, error) {
+	var sections struct {
+		Output     map[string]interface{}   `config:"output"`
+		Processors []map[string]interface{} `config:"processors"`
+	}
+	if err := cfg.Unpack(&sections); err != nil {
+		return 0, err
+	}
+	return hashstructure.Hash(sections, nil)
+}
+
+// Start watches the configuration files and SIGHUP in the background.
+func (r *publisherReloader) Start() {
+	logp.Info("Publisher reloading enabled")
+	svc.HandleReload(r.Trigger)
+
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop stops watching for configuration changes.
+func (r *publisherReloader) Stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// Trigger requests a reload of the configuration files.
+func (r *publisherReloader) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default: // reload already pending
+	}
+}
+
+func (r *publisherReloader) run() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-r.trigger:
+			r.reload()
+		case <-time.After(r.period):
+			if r.changed() {
+				r.reload()
+			}
+		}
+	}
+}
+
+func (r *publisherReloader) changed() bool {
+	changed := false
+	for _, gw := range r.watchers {
+		_, updated, err := gw.Scan()
+		if err != nil {
+			logp.Err("Error scanning config file: %v", err)
+		}
+		changed = changed || updated
+	}
+	return changed
+}
+
+func (r *publisherReloader) reload() {
+	debugf("Reload publisher configuration")
+
+	rawConfig, err := cfgfile.Load("")
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	hash, err := hashReloadSections(rawConfig)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	if hash == r.hash {
+		debugf("Processors and outputs unchanged, skip reload")
+		return
+	}
+
+	var sections reloadSections
+	if err := rawConfig.Unpack(&sections); err != nil {
+		r.fail(err)
+		return
+	}
+
+	if err := r.publisher.Reload(sections.Output, sections.Processors, r.timeout); err != nil {
+		r.fail(err)
+		return
+	}
+
+	r.hash = hash
+	publisherReloads.Add(1)
+}
+
+func (r *publisherReloader) fail(err error) {
+	publisherReloadFailures.Add(1)
+	logp.Err("Failed to reload processors and outputs, keeping the current ones: %v", err)
+}
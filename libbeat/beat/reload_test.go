@@ -0,0 +1,80 @@
+// +build !integration
+
+package beat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestNewPublisherReloaderDisabled(t *testing.T) {
+	r, err := newPublisherReloader(nil, nil, common.NewConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestNewPublisherReloaderConfig(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"reload.enabled":       true,
+		"reload.period":        "5s",
+		"reload.drain_timeout": "1s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newPublisherReloader(nil, cfg, common.NewConfig())
+	if assert.NoError(t, err) {
+		assert.Equal(t, 5*time.Second, r.period)
+		assert.Equal(t, time.Second, r.timeout)
+	}
+
+	cfg, err = common.NewConfigFrom(map[string]interface{}{
+		"reload.enabled":       true,
+		"reload.drain_timeout": 0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newPublisherReloader(nil, cfg, common.NewConfig())
+	assert.Error(t, err)
+}
+
+func TestHashReloadSections(t *testing.T) {
+	hash := func(settings map[string]interface{}) uint64 {
+		cfg, err := common.NewConfigFrom(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := hashReloadSections(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	base := hash(map[string]interface{}{
+		"output.kafka.topic": "a",
+		"processors":         []map[string]interface{}{{"drop_event": nil}},
+		"logging.level":      "info",
+	})
+
+	// changes to other sections are ignored
+	assert.Equal(t, base, hash(map[string]interface{}{
+		"output.kafka.topic": "a",
+		"processors":         []map[string]interface{}{{"drop_event": nil}},
+		"logging.level":      "debug",
+	}))
+
+	assert.NotEqual(t, base, hash(map[string]interface{}{
+		"output.kafka.topic": "b",
+		"processors":         []map[string]interface{}{{"drop_event": nil}},
+	}))
+	assert.NotEqual(t, base, hash(map[string]interface{}{
+		"output.kafka.topic": "a",
+	}))
+}
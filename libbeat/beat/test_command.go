@@ -0,0 +1,70 @@
+package beat
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+const testUsage = "usage: test output"
+
+// handleTestCommand runs the `test` subcommand, if given on the command line.
+// It returns GracefulExit if all tests succeeded.
+func (b *Beat) handleTestCommand() error {
+	if flag.NArg() == 0 || flag.Arg(0) != "test" {
+		return nil
+	}
+
+	if flag.NArg() != 2 {
+		return errors.New(testUsage)
+	}
+
+	var err error
+	switch flag.Arg(1) {
+	case "output":
+		err = b.testOutputs(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown test command '%v', %v", flag.Arg(1), testUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+	return GracefulExit
+}
+
+// testOutputs tests the connection to the hosts of each enabled output,
+// printing the steps taken. The outputs are not created, so no events are
+// published and no templates are loaded.
+func (b *Beat) testOutputs(out io.Writer) error {
+	names := enabledOutputs(b.Config.Output)
+	if len(names) == 0 {
+		return errors.New("no outputs are defined")
+	}
+
+	d := testing.NewConsoleDriver(out)
+	for _, name := range names {
+		tester := outputs.FindOutputTester(name)
+		if tester == nil {
+			d.Warn(name, "testing this output is not supported")
+			continue
+		}
+
+		testable, err := tester(b.Name, b.Config.Output[name])
+		if err != nil {
+			d.Error(name, err)
+			continue
+		}
+		d.Run(name, testable.Test)
+	}
+
+	if d.Failed() {
+		return errors.New("output test failed")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// +build !integration
+
+package beat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	bt "github.com/elastic/beats/libbeat/testing"
+)
+
+func TestTestOutputs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"version": {"number": "5.4.0"}}`)
+	}))
+	defer server.Close()
+
+	b := newTestBeat(t, map[string]interface{}{
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":            []string{server.URL},
+			"template.enabled": false,
+		},
+	})
+
+	var out bytes.Buffer
+	require.NoError(t, b.testOutputs(&out), out.String())
+	assert.Contains(t, out.String(), "elasticsearch: "+server.URL+"...")
+	assert.Contains(t, out.String(), "version: 5.4.0")
+}
+
+func TestTestOutputsFailure(t *testing.T) {
+	b := newTestBeat(t, map[string]interface{}{
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":            []string{"http://127.0.0.1:1"},
+			"template.enabled": false,
+		},
+	})
+
+	var out bytes.Buffer
+	assert.Error(t, b.testOutputs(&out))
+	assert.Contains(t, out.String(), "dial up... ERROR")
+}
+
+type testTestable struct{}
+
+func (testTestable) Test(d bt.Driver) { d.Info("tested", "yes") }
+
+func TestTestOutputsDoesNotCreateOutputs(t *testing.T) {
+	created := false
+	outputs.RegisterOutputPlugin("testtest", func(string, *common.Config, int) (outputs.Outputer, error) {
+		created = true
+		return nil, errors.New("not supported")
+	})
+	outputs.RegisterOutputTester("testtest", func(_ string, cfg *common.Config) (bt.Testable, error) {
+		if !cfg.HasField("valid") {
+			return nil, errors.New("valid required")
+		}
+		return testTestable{}, nil
+	})
+
+	b := newTestBeat(t, map[string]interface{}{"output.testtest.valid": true})
+	var out bytes.Buffer
+	require.NoError(t, b.testOutputs(&out), out.String())
+	assert.Contains(t, out.String(), "tested: yes")
+
+	b = newTestBeat(t, map[string]interface{}{"output.testtest.enabled": true})
+	out.Reset()
+	assert.Error(t, b.testOutputs(&out))
+	assert.Contains(t, out.String(), "testtest... ERROR valid required")
+	assert.False(t, created)
+}
+
+func TestTestOutputsNoOutputs(t *testing.T) {
+	b := newTestBeat(t, map[string]interface{}{})
+
+	var out bytes.Buffer
+	assert.Error(t, b.testOutputs(&out))
+}
@@ -107,15 +107,7 @@ func Load(path string) (*common.Config, error) {
 	cfgpath := GetPathConfig()
 
 	if path == "" {
-		list := []string{}
-		for _, cfg := range configfiles.list {
-			if !filepath.IsAbs(cfg) {
-				list = append(list, filepath.Join(cfgpath, cfg))
-			} else {
-				list = append(list, cfg)
-			}
-		}
-		config, err = common.LoadFiles(list...)
+		config, err = common.LoadFiles(GetConfigFiles()...)
 	} else {
 		if !filepath.IsAbs(path) {
 			path = filepath.Join(cfgpath, path)
@@ -139,6 +131,22 @@ func Load(path string) (*common.Config, error) {
 	return config, nil
 }
 
+// GetConfigFiles returns the paths of the configuration files specified by the
+// '-c' command line flag. Relative paths are resolved against ${path.config}.
+func GetConfigFiles() []string {
+	cfgpath := GetPathConfig()
+
+	list := []string{}
+	for _, cfg := range configfiles.list {
+		if !filepath.IsAbs(cfg) {
+			list = append(list, filepath.Join(cfgpath, cfg))
+		} else {
+			list = append(list, cfg)
+		}
+	}
+	return list
+}
+
 // LoadList loads a list of configs data from the given file.
 func LoadList(file string) ([]*common.Config, error) {
 	logp.Debug("cfgfile", "Load config from file: %s", file)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -44,12 +45,66 @@ type flagOverwrite struct {
 	value  string
 }
 
-var configOpts = []ucfg.Option{
+var configOpts = makeConfigOpts(nil)
+
+// debugConfigOpts do not resolve variables from the keystore, environment or
+// files, but report the variable references instead, so secrets are not
+// printed by PrintDebugf.
+var debugConfigOpts = []ucfg.Option{
 	ucfg.PathSep("."),
-	ucfg.ResolveEnv,
+	ucfg.Resolve(func(name string) (string, error) {
+		return "${" + name + "}", nil
+	}),
 	ucfg.VarExp,
 }
 
+// fileRefPrefix is the prefix of variables referencing the contents of a file,
+// e.g. `${file:/run/secrets/password}`.
+const fileRefPrefix = "file:"
+
+// makeConfigOpts returns the options for resolving variables. Resolvers are
+// tried in reverse order, such that variables are looked up in the secrets
+// resolver first (if set), then in the environment and last in files.
+func makeConfigOpts(secrets func(string) (string, error)) []ucfg.Option {
+	opts := []ucfg.Option{
+		ucfg.PathSep("."),
+		ucfg.Resolve(resolveFile),
+		ucfg.ResolveEnv,
+	}
+	if secrets != nil {
+		opts = append(opts, ucfg.Resolve(secrets))
+	}
+	return append(opts, ucfg.VarExp)
+}
+
+// SetSecretsResolver registers a resolver looking up variables before the
+// environment and files, e.g. in the keystore. It must be called before the
+// configuration sections using the secrets are unpacked.
+func SetSecretsResolver(secrets func(name string) (string, error)) {
+	configOpts = makeConfigOpts(secrets)
+}
+
+// resolveFile resolves variables of the form `${file:/path/to/file}` to the
+// contents of the file, with trailing newlines removed.
+func resolveFile(name string) (string, error) {
+	if !strings.HasPrefix(name, fileRefPrefix) {
+		return "", ucfg.ErrMissing
+	}
+
+	content, err := ioutil.ReadFile(strings.TrimPrefix(name, fileRefPrefix))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// escapeFileRefs escapes the separator in `${file:...}` variables. Otherwise
+// the variable would be parsed as variable `file` with the path as default
+// value.
+func escapeFileRefs(s string) string {
+	return strings.Replace(s, "${"+fileRefPrefix, "${file$:", -1)
+}
+
 const (
 	selectorConfig             = "config"
 	selectorConfigWithPassword = "config-with-passwords"
@@ -67,6 +122,14 @@ var debugBlacklist = MakeStringSet(
 	"hosts",
 )
 
+// secretSettings are the settings redacted by Export.
+var secretSettings = MakeStringSet(
+	"password",
+	"passphrase",
+	"key_passphrase",
+	"pass",
+)
+
 // make hasSelector and configDebugf available for unit testing
 var hasSelector = logp.HasSelector
 var configDebugf = logp.Debug
@@ -164,7 +227,13 @@ func LoadFile(path string) (*Config, error) {
 		}
 	}
 
-	c, err := yaml.NewConfigWithFile(path, configOpts...)
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append([]ucfg.Option{ucfg.MetaData(ucfg.Meta{Source: path})}, configOpts...)
+	c, err := yaml.NewConfig([]byte(escapeFileRefs(string(input))), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +384,7 @@ func (f *flagOverwrite) Set(v string) error {
 		configOpts...,
 	)
 
-	err := f.config.SetString(f.path, -1, v, opts...)
+	err := f.config.SetString(f.path, -1, escapeFileRefs(v), opts...)
 	if err != nil {
 		return err
 	}
@@ -361,22 +430,22 @@ func configDebugString(c *Config, filterPrivate bool) string {
 
 	if c.IsDict() {
 		var content map[string]interface{}
-		if err := c.Unpack(&content); err != nil {
+		if err := c.access().Unpack(&content, debugConfigOpts...); err != nil {
 			return fmt.Sprintf("<config error> %v", err)
 		}
 		if filterPrivate {
-			filterDebugObject(content)
+			filterDebugObject(content, debugBlacklist)
 		}
 		j, _ := json.MarshalIndent(content, "", "  ")
 		bufs = append(bufs, string(j))
 	}
 	if c.IsArray() {
 		var content []interface{}
-		if err := c.Unpack(&content); err != nil {
+		if err := c.access().Unpack(&content, debugConfigOpts...); err != nil {
 			return fmt.Sprintf("<config error> %v", err)
 		}
 		if filterPrivate {
-			filterDebugObject(content)
+			filterDebugObject(content, debugBlacklist)
 		}
 		j, _ := json.MarshalIndent(content, "", "  ")
 		bufs = append(bufs, string(j))
@@ -388,11 +457,23 @@ func configDebugString(c *Config, filterPrivate bool) string {
 	return strings.Join(bufs, "\n")
 }
 
-func filterDebugObject(c interface{}) {
+// Export returns the contents of the configuration for printing it. Variables
+// referencing the keystore, the environment or files are not resolved and the
+// values of password settings are redacted, such that no secrets are exported.
+func (c *Config) Export() (map[string]interface{}, error) {
+	content := map[string]interface{}{}
+	if err := c.access().Unpack(&content, debugConfigOpts...); err != nil {
+		return nil, err
+	}
+	filterDebugObject(content, secretSettings)
+	return content, nil
+}
+
+func filterDebugObject(c interface{}, blacklist StringSet) {
 	switch cfg := c.(type) {
 	case map[string]interface{}:
 		for k, v := range cfg {
-			if debugBlacklist.Has(k) {
+			if blacklist.Has(k) {
 				if arr, ok := v.([]interface{}); ok {
 					for i := range arr {
 						arr[i] = "xxxxx"
@@ -401,13 +482,13 @@ func filterDebugObject(c interface{}) {
 					cfg[k] = "xxxxx"
 				}
 			} else {
-				filterDebugObject(v)
+				filterDebugObject(v, blacklist)
 			}
 		}
 
 	case []interface{}:
 		for _, elem := range cfg {
-			filterDebugObject(elem)
+			filterDebugObject(elem, blacklist)
 		}
 	}
 }
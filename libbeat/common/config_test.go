@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/elastic/go-ucfg"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -74,10 +75,29 @@ func TestConfigPrintDebug(t *testing.T) {
     "password": "secret"
   }
 }
+`,
+		},
+		{
+			"resolved variables are not printed",
+			"config-with-passwords",
+			map[string]interface{}{
+				"name":     "test",
+				"ref":      "${name}",
+				"password": "${TEST_CONFIG_DEBUG_PASSWORD}",
+			},
+			`test:
+{
+  "name": "test",
+  "password": "${TEST_CONFIG_DEBUG_PASSWORD}",
+  "ref": "test"
+}
 `,
 		},
 	}
 
+	os.Setenv("TEST_CONFIG_DEBUG_PASSWORD", "secret")
+	defer os.Unsetenv("TEST_CONFIG_DEBUG_PASSWORD")
+
 	origSelector := hasSelector
 	origDebugf := configDebugf
 	defer func() {
@@ -114,6 +134,93 @@ func TestConfigPrintDebug(t *testing.T) {
 	}
 }
 
+func TestConfigResolveSecrets(t *testing.T) {
+	f, err := ioutil.TempFile("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("from-file\n")
+	f.Close()
+
+	cfgFile, err := ioutil.TempFile("", "config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cfgFile.Name())
+	fmt.Fprintf(cfgFile, "file: ${file:%v}\nenv: ${TEST_SECRET}\nstore: ${TEST_SECRET_STORE}\n", f.Name())
+	cfgFile.Close()
+	os.Chmod(cfgFile.Name(), 0600)
+
+	os.Setenv("TEST_SECRET", "from-env")
+	os.Setenv("TEST_SECRET_STORE", "from-env")
+	defer os.Unsetenv("TEST_SECRET")
+	defer os.Unsetenv("TEST_SECRET_STORE")
+
+	SetSecretsResolver(func(name string) (string, error) {
+		if name == "TEST_SECRET_STORE" {
+			return "from-store", nil
+		}
+		return "", ucfg.ErrMissing
+	})
+	defer SetSecretsResolver(nil)
+
+	cfg, err := LoadFile(cfgFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values map[string]string
+	if err := cfg.Unpack(&values); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{
+		"file":  "from-file",
+		"env":   "from-env",
+		"store": "from-store",
+	}, values)
+}
+
+func TestConfigExport(t *testing.T) {
+	os.Setenv("TEST_EXPORT_PASSWORD", "secret")
+	defer os.Unsetenv("TEST_EXPORT_PASSWORD")
+
+	cfg, err := NewConfigFrom(map[string]interface{}{
+		"output.elasticsearch": map[string]interface{}{
+			"hosts":    []string{"localhost:9200"},
+			"username": "${TEST_EXPORT_USER}",
+			"password": "changeme",
+		},
+		"output.kafka.sasl.password": "${TEST_EXPORT_PASSWORD}",
+		"path.home":                  "/usr/share/beat",
+		"path.data":                  "${path.home}/data",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := cfg.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"output": map[string]interface{}{
+			"elasticsearch": map[string]interface{}{
+				"hosts":    []interface{}{"localhost:9200"},
+				"username": "${TEST_EXPORT_USER}",
+				"password": "xxxxx",
+			},
+			"kafka": map[string]interface{}{
+				"sasl": map[string]interface{}{"password": "xxxxx"},
+			},
+		},
+		"path": map[string]interface{}{
+			"home": "/usr/share/beat",
+			"data": "/usr/share/beat/data",
+		},
+	}, content)
+}
+
 func TestConfigFilePermissions(t *testing.T) {
 	if !IsStrictPerms() {
 		t.Skip("Skipping test because strict.perms is disabled")
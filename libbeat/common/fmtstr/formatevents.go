@@ -168,6 +168,16 @@ func (fs *EventFormatString) Fields() []string {
 	return fields
 }
 
+// AllFields returns list of unique event fields used by the format string,
+// including the optional fields having a default value.
+func (fs *EventFormatString) AllFields() []string {
+	fields := make([]string, len(fs.fields))
+	for i, fi := range fs.fields {
+		fields[i] = fi.path
+	}
+	return fields
+}
+
 // Run executes the format string returning a new expanded string or an error
 // if execution or event field expansion fails.
 func (fs *EventFormatString) Run(event common.MapStr) (string, error) {
@@ -0,0 +1,155 @@
+// Package lainlet watches the LAIN containers of this node with lainlet.
+package lainlet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/laincloud/lainlet/api/v2"
+	"github.com/laincloud/lainlet/client"
+	"github.com/laincloud/lainlet/watcher/container"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	// shortIDLength is the length of the container IDs shortened by Docker.
+	shortIDLength = 12
+
+	// retryPeriod is the time waited before retrying a failed watch.
+	retryPeriod = 3 * time.Second
+)
+
+// Watcher keeps the LAIN containers of this node up to date by watching
+// lainlet. If the watch fails, it is retried after 3 seconds.
+type Watcher struct {
+	address  string
+	url      string
+	onUpdate func(containers map[string]container.Info)
+
+	mutex      sync.RWMutex
+	containers map[string]container.Info // by container ID
+	shortIDs   map[string]string         // container IDs by short ID
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWatcher creates a watcher of the lainlet at address, e.g.
+// localhost:9001. If onUpdate is not nil, it is called with the containers by
+// container ID each time lainlet reports them. The map must not be modified.
+func NewWatcher(address string, onUpdate func(containers map[string]container.Info)) (*Watcher, error) {
+	nodeName, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		address:  address,
+		url:      fmt.Sprintf("/v2/containers?nodename=%s", nodeName),
+		onUpdate: onUpdate,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start starts watching lainlet in the background.
+func (w *Watcher) Start() {
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop stops watching lainlet and waits until the watch has shut down.
+func (w *Watcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Lookup returns the LAIN container with the given full or short container
+// ID.
+func (w *Watcher) Lookup(id string) (container.Info, bool) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if len(id) == shortIDLength {
+		if fullID, found := w.shortIDs[id]; found {
+			id = fullID
+		}
+	}
+	info, found := w.containers[id]
+	return info, found
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	for {
+		if err := w.watch(); err != nil {
+			logp.Err("Error watching lainlet: %v", err)
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+func (w *Watcher) watch() error {
+	ch, err := client.New(w.address).Watch(w.url, w.ctx)
+	if err != nil {
+		return err
+	}
+
+	for resp := range ch {
+		if resp.Event != client.INIT && resp.Event != client.UPDATE && resp.Event != client.DELETE {
+			continue
+		}
+
+		data := new(api.GeneralContainers)
+		if err := data.Decode(resp.Data); err != nil {
+			logp.Err("Error decoding lainlet data: %v", err)
+			continue
+		}
+		w.update(data.Data)
+	}
+
+	if w.ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("lainlet watch closed")
+}
+
+// update replaces the containers. The keys of the lainlet data are of the
+// form <node name>/<container ID>.
+func (w *Watcher) update(data map[string]container.Info) {
+	containers := make(map[string]container.Info, len(data))
+	shortIDs := make(map[string]string, len(data))
+	for key, info := range data {
+		id := key[strings.LastIndex(key, "/")+1:]
+		if id == "" {
+			continue
+		}
+		containers[id] = info
+		if len(id) > shortIDLength {
+			shortIDs[id[:shortIDLength]] = id
+		}
+	}
+	logp.Debug("lainlet", "LAIN containers: %v", containers)
+
+	w.mutex.Lock()
+	w.containers = containers
+	w.shortIDs = shortIDs
+	w.mutex.Unlock()
+
+	if w.onUpdate != nil {
+		w.onUpdate(containers)
+	}
+}
@@ -0,0 +1,81 @@
+// +build !integration
+
+package lainlet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/laincloud/lainlet/watcher/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	const id = "0123456789abcdef"
+	data := fmt.Sprintf(`{"node1/%s": {"app": "hello", "proc": "hello.web.web", "instanceNo": 1, "app_version": "1.0"}}`, id)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/containers", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("watch"))
+		fmt.Fprintf(w, "id: 1\nevent: init\ndata: %s\n\n", data)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	updates := make(chan map[string]container.Info, 1)
+	w, err := NewWatcher(strings.TrimPrefix(server.URL, "http://"), func(containers map[string]container.Info) {
+		updates <- containers
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	var containers map[string]container.Info
+	select {
+	case containers = <-updates:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the containers")
+	}
+
+	web := container.Info{AppName: "hello", ProcName: "hello.web.web", InstanceNo: 1, AppVersion: "1.0"}
+	assert.Equal(t, map[string]container.Info{id: web}, containers)
+
+	info, found := w.Lookup(id)
+	assert.True(t, found)
+	assert.Equal(t, web, info)
+
+	info, found = w.Lookup(id[:shortIDLength])
+	assert.True(t, found)
+	assert.Equal(t, web, info)
+
+	_, found = w.Lookup("unknown")
+	assert.False(t, found)
+}
+
+func TestWatcherStop(t *testing.T) {
+	// Nothing listens on the address, so the watch is retried until stopped.
+	w, err := NewWatcher("127.0.0.1:1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher didn't stop")
+	}
+}
@@ -0,0 +1,106 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	saltLength     = 64
+	keyLength      = 32
+	keyIterations  = 10000
+	nonceLength    = 12
+	encryptVersion = "v1"
+)
+
+var errInvalidData = errors.New("invalid keystore data")
+
+// encrypt encrypts the data with AES-256-GCM, using a key derived from the
+// password and a random salt. The result holds the salt, the nonce and the
+// sealed data.
+func encrypt(password, data []byte) ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(salt, nonce...)
+	return aead.Seal(out, nonce, data, []byte(encryptVersion)), nil
+}
+
+// decrypt reverses encrypt. It fails if the password is wrong or the data has
+// been tampered with.
+func decrypt(password, data []byte) ([]byte, error) {
+	if len(data) < saltLength+nonceLength {
+		return nil, errInvalidData
+	}
+
+	salt := data[:saltLength]
+	nonce := data[saltLength : saltLength+nonceLength]
+	aead, err := newAEAD(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := aead.Open(nil, nonce, data[saltLength+nonceLength:], []byte(encryptVersion))
+	if err != nil {
+		return nil, errors.New("could not decrypt the keystore, the password is wrong or the data is corrupted")
+	}
+	return plain, nil
+}
+
+func newAEAD(password, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2(password, salt, keyIterations, keyLength))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 derives a key from the password as defined in RFC 8018, using
+// HMAC-SHA512 as pseudorandom function.
+func pbkdf2(password, salt []byte, iterations, length int) []byte {
+	prf := hmac.New(sha512.New, password)
+	hashLen := prf.Size()
+	blocks := (length + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	key := make([]byte, 0, blocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, hashLen)
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:length]
+}
@@ -0,0 +1,193 @@
+// Package keystore implements an encrypted file based store for secrets, like
+// the passwords of the outputs. The secrets are referenced from the
+// configuration files via `${key}`.
+package keystore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/elastic/go-ucfg"
+)
+
+// ErrKeyDoesntExists is returned if a key is not present in the keystore.
+var ErrKeyDoesntExists = errors.New("key does not exist in the keystore")
+
+// FileKeystore stores secrets in a file encrypted with AES-256-GCM. The file
+// holds a version header followed by the base64 encoded encrypted secrets.
+type FileKeystore struct {
+	sync.Mutex
+	path     string
+	password []byte
+	secrets  map[string][]byte
+	dirty    bool
+}
+
+// NewFileKeystore opens the keystore at path. If the file does not exist, an
+// empty keystore is returned, which is only written to disk on Save.
+func NewFileKeystore(path string, password string) (*FileKeystore, error) {
+	ks := &FileKeystore{
+		path:     path,
+		password: []byte(password),
+		secrets:  map[string][]byte{},
+	}
+
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Path returns the path of the keystore file.
+func (k *FileKeystore) Path() string {
+	return k.path
+}
+
+// IsPersisted returns true if the keystore file exists.
+func (k *FileKeystore) IsPersisted() bool {
+	_, err := os.Stat(k.path)
+	return err == nil
+}
+
+// Retrieve returns the secret stored for key.
+func (k *FileKeystore) Retrieve(key string) ([]byte, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	secret, exists := k.secrets[key]
+	if !exists {
+		return nil, ErrKeyDoesntExists
+	}
+	return secret, nil
+}
+
+// Store adds or replaces the secret of a key. Call Save to persist the change.
+func (k *FileKeystore) Store(key string, secret []byte) error {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	k.secrets[key] = secret
+	k.dirty = true
+	return nil
+}
+
+// Delete removes a key. Call Save to persist the change.
+func (k *FileKeystore) Delete(key string) error {
+	k.Lock()
+	defer k.Unlock()
+
+	if _, exists := k.secrets[key]; !exists {
+		return ErrKeyDoesntExists
+	}
+	delete(k.secrets, key)
+	k.dirty = true
+	return nil
+}
+
+// List returns the sorted list of keys.
+func (k *FileKeystore) List() []string {
+	k.Lock()
+	defer k.Unlock()
+
+	keys := make([]string, 0, len(k.secrets))
+	for key := range k.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes the keystore to disk, if it has been changed or not been
+// persisted yet. The file is only readable by the owner.
+func (k *FileKeystore) Save() error {
+	k.Lock()
+	defer k.Unlock()
+
+	if !k.dirty && k.IsPersisted() {
+		return nil
+	}
+
+	plain, err := json.Marshal(k.secrets)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encrypt(k.password, plain)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptVersion)
+	buf.WriteByte('\n')
+	buf.WriteString(base64.StdEncoding.EncodeToString(encrypted))
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0750); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, such that the keystore is not
+	// corrupted if writing fails
+	tmp := k.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	k.dirty = false
+	return nil
+}
+
+func (k *FileKeystore) load() error {
+	content, err := ioutil.ReadFile(k.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	parts := bytes.SplitN(content, []byte{'\n'}, 2)
+	if len(parts) != 2 || string(parts[0]) != encryptVersion {
+		return fmt.Errorf("keystore %v has an unsupported format", k.path)
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(parts[1])))
+	if err != nil {
+		return fmt.Errorf("keystore %v is corrupted: %v", k.path, err)
+	}
+
+	plain, err := decrypt(k.password, encrypted)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plain, &k.secrets)
+}
+
+// ConfigResolver returns a function resolving configuration variables from
+// the keystore, for use with common.SetSecretsResolver.
+func ConfigResolver(k *FileKeystore) func(string) (string, error) {
+	return func(key string) (string, error) {
+		secret, err := k.Retrieve(key)
+		if err != nil {
+			return "", ucfg.ErrMissing
+		}
+		return string(secret), nil
+	}
+}
@@ -0,0 +1,117 @@
+// +build !integration
+
+package keystore
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastic/go-ucfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempKeystorePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	return filepath.Join(dir, "test.keystore"), func() { os.RemoveAll(dir) }
+}
+
+func TestFileKeystoreRoundtrip(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path, "")
+	require.NoError(t, err)
+	assert.False(t, ks.IsPersisted())
+
+	require.NoError(t, ks.Store("es.password", []byte("changeme")))
+	require.NoError(t, ks.Store("kafka.password", []byte("secret")))
+	require.NoError(t, ks.Save())
+	assert.True(t, ks.IsPersisted())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "changeme")
+
+	ks, err = NewFileKeystore(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"es.password", "kafka.password"}, ks.List())
+
+	secret, err := ks.Retrieve("es.password")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", string(secret))
+
+	require.NoError(t, ks.Delete("kafka.password"))
+	assert.Equal(t, ErrKeyDoesntExists, ks.Delete("kafka.password"))
+	require.NoError(t, ks.Save())
+
+	ks, err = NewFileKeystore(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"es.password"}, ks.List())
+}
+
+func TestFileKeystoreWrongPassword(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path, "pass")
+	require.NoError(t, err)
+	require.NoError(t, ks.Store("key", []byte("value")))
+	require.NoError(t, ks.Save())
+
+	_, err = NewFileKeystore(path, "other")
+	assert.Error(t, err)
+}
+
+func TestFileKeystoreCorrupted(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("v2\nabc"), 0600))
+	_, err := NewFileKeystore(path, "")
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("v1\nYWJj"), 0600))
+	_, err = NewFileKeystore(path, "")
+	assert.Error(t, err)
+}
+
+func TestConfigResolver(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path, "")
+	require.NoError(t, err)
+	require.NoError(t, ks.Store("password", []byte("secret")))
+
+	resolve := ConfigResolver(ks)
+	value, err := resolve("password")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = resolve("missing")
+	assert.Equal(t, ucfg.ErrMissing, err)
+}
+
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		iterations int
+		expected   string
+	}{
+		{1, "867f70cf1ade02cff3752599a3a53dc4af34c7a669815ae5d513554e1c8cf252c02d470a285a0501bad999bfe943c08f050235d7d68b1da55e63f73b60a57fce"},
+		{2, "e1d9c16aa681708a45f5c7c4e215ceb66e011a2e9f0040713f18aefdb866d53cf76cab2868a39b9f7840edce4fef5a82be67335c77a6068e04112754f27ccf4e"},
+	}
+
+	for _, test := range tests {
+		key := pbkdf2([]byte("password"), []byte("salt"), test.iterations, 64)
+		assert.Equal(t, test.expected, hex.EncodeToString(key))
+	}
+}
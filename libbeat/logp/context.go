@@ -0,0 +1,52 @@
+package logp
+
+// ContextLogger logs messages with a selector and key-value context. The
+// context is appended as key=value pairs to text log lines and added as keys
+// to JSON log lines. Messages are logged if their level is enabled for the
+// selector, see `logging.levels`.
+type ContextLogger struct {
+	selector string
+	fields   []interface{}
+}
+
+// NewContextLogger creates a logger for the selector, attaching the
+// alternating keys and values to all messages.
+func NewContextLogger(selector string, keysAndValues ...interface{}) *ContextLogger {
+	return &ContextLogger{selector: selector, fields: keysAndValues}
+}
+
+// With returns a new logger with the keys and values added to the context.
+func (l *ContextLogger) With(keysAndValues ...interface{}) *ContextLogger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keysAndValues...)
+	return &ContextLogger{selector: l.selector, fields: fields}
+}
+
+func (l *ContextLogger) Debug(format string, v ...interface{}) {
+	if IsDebug(l.selector) {
+		l.log(LOG_DEBUG, "DBG  ", format, v...)
+	}
+}
+
+func (l *ContextLogger) Info(format string, v ...interface{}) {
+	l.log(LOG_INFO, "INFO ", format, v...)
+}
+
+func (l *ContextLogger) Warn(format string, v ...interface{}) {
+	l.log(LOG_WARNING, "WARN ", format, v...)
+}
+
+func (l *ContextLogger) Err(format string, v ...interface{}) {
+	l.log(LOG_ERR, "ERR ", format, v...)
+}
+
+func (l *ContextLogger) Critical(format string, v ...interface{}) {
+	l.log(LOG_CRIT, "CRIT ", format, v...)
+}
+
+func (l *ContextLogger) log(level Priority, prefix string, format string, v ...interface{}) {
+	if levelEnabled(level, l.selector) {
+		send(4, level, prefix, l.selector, l.fields, format, v...)
+	}
+}
@@ -1,16 +1,20 @@
 package logp
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const RotatorMaxFiles = 1024
 const DefaultKeepFiles = 7
 const DefaultRotateEveryBytes = 10 * 1024 * 1024
+const DefaultFilePermissions = 0600
 
 type FileRotator struct {
 	Path             string
@@ -18,8 +22,34 @@ type FileRotator struct {
 	RotateEveryBytes *uint64
 	KeepFiles        *int
 
-	current     *os.File
-	currentSize uint64
+	// RotateInterval enables time based rotation if set. Intervals are
+	// aligned to multiples of the duration since the zero time (UTC), so
+	// 1h rotates on every full hour and 24h at midnight UTC.
+	RotateInterval time.Duration
+
+	// Compress gzips rotated files. The active file is never compressed.
+	// Rotated files are compressed in the background, so writing continues
+	// while compressing.
+	Compress bool
+
+	// Permissions of the files being created. Defaults to 0600.
+	Permissions os.FileMode
+
+	// Append makes the first WriteLine append to an existing file, like
+	// after Close, instead of rotating it.
+	Append bool
+
+	current      *os.File
+	currentSize  uint64
+	currentStart time.Time
+	suspended    bool
+
+	// compressing is closed when the background compression of the last
+	// rotated file finishes. It is nil if no compression is running.
+	compressing chan struct{}
+
+	// now is used to read the current time, for testing
+	now func() time.Time
 }
 
 func (rotator *FileRotator) CreateDirectory() error {
@@ -53,13 +83,26 @@ func (rotator *FileRotator) CheckIfConfigSane() error {
 		*rotator.RotateEveryBytes = DefaultRotateEveryBytes
 	}
 
+	if rotator.Permissions == 0 {
+		rotator.Permissions = DefaultFilePermissions
+	}
+
 	if *rotator.KeepFiles < 2 || *rotator.KeepFiles >= RotatorMaxFiles {
 		return fmt.Errorf("The number of files to keep should be between 2 and %d", RotatorMaxFiles-1)
 	}
+	if rotator.RotateInterval < 0 {
+		return fmt.Errorf("The rotate interval must not be negative")
+	}
 	return nil
 }
 
 func (rotator *FileRotator) WriteLine(line []byte) error {
+	if rotator.current == nil && (rotator.suspended || rotator.Append) {
+		if err := rotator.reopen(); err != nil {
+			return err
+		}
+	}
+
 	if rotator.shouldRotate() {
 		err := rotator.Rotate()
 		if err != nil {
@@ -77,6 +120,21 @@ func (rotator *FileRotator) WriteLine(line []byte) error {
 	return nil
 }
 
+// Close closes the active file and waits for the compression of the last
+// rotated file. In contrast to a restart, the next WriteLine appends to the
+// existing file instead of rotating it, unless a rotation is due.
+func (rotator *FileRotator) Close() error {
+	rotator.waitCompress()
+	if rotator.current == nil {
+		return nil
+	}
+
+	err := rotator.current.Close()
+	rotator.current = nil
+	rotator.suspended = true
+	return err
+}
+
 func (rotator *FileRotator) shouldRotate() bool {
 	if rotator.current == nil {
 		return true
@@ -86,14 +144,59 @@ func (rotator *FileRotator) shouldRotate() bool {
 		return true
 	}
 
+	if rotator.RotateInterval > 0 &&
+		!rotator.timeNow().Truncate(rotator.RotateInterval).Equal(rotator.currentStart) {
+		return true
+	}
+
 	return false
 }
 
+func (rotator *FileRotator) reopen() error {
+	path := rotator.FilePath(0)
+	current, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, rotator.perm())
+	if err != nil {
+		return err
+	}
+
+	info, err := current.Stat()
+	if err != nil {
+		current.Close()
+		return err
+	}
+
+	rotator.current = current
+	rotator.currentSize = uint64(info.Size())
+	rotator.suspended = false
+	if rotator.RotateInterval > 0 && rotator.currentStart.IsZero() {
+		// continue the interval the file was last written in
+		rotator.currentStart = info.ModTime().Truncate(rotator.RotateInterval)
+	}
+	return nil
+}
+
+func (rotator *FileRotator) timeNow() time.Time {
+	if rotator.now != nil {
+		return rotator.now()
+	}
+	return time.Now()
+}
+
+func (rotator *FileRotator) perm() os.FileMode {
+	if rotator.Permissions == 0 {
+		return DefaultFilePermissions
+	}
+	return rotator.Permissions
+}
+
 func (rotator *FileRotator) FilePath(fileNo int) string {
 	if fileNo == 0 {
 		return filepath.Join(rotator.Path, rotator.Name)
 	}
 	filename := strings.Join([]string{rotator.Name, strconv.Itoa(fileNo)}, ".")
+	if rotator.Compress {
+		filename += ".gz"
+	}
 	return filepath.Join(rotator.Path, filename)
 }
 
@@ -114,6 +217,14 @@ func (rotator *FileRotator) Rotate() error {
 		}
 	}
 
+	// the files can only be shifted once the last rotated file is compressed
+	rotator.waitCompress()
+	if rotator.Compress {
+		if err := rotator.compressLeftover(); err != nil {
+			return err
+		}
+	}
+
 	// delete any extra files, normally we shouldn't have any
 	for fileNo := *rotator.KeepFiles; fileNo < RotatorMaxFiles; fileNo++ {
 		if rotator.FileExists(fileNo) {
@@ -137,7 +248,12 @@ func (rotator *FileRotator) Rotate() error {
 			return fmt.Errorf("File %s exists, when rotating would overwrite it", rotator.FilePath(fileNo+1))
 		}
 
-		err := os.Rename(path, rotator.FilePath(fileNo+1))
+		var err error
+		if fileNo == 0 && rotator.Compress {
+			err = rotator.compressAsync(path)
+		} else {
+			err = os.Rename(path, rotator.FilePath(fileNo+1))
+		}
 		if err != nil {
 			return err
 		}
@@ -145,12 +261,16 @@ func (rotator *FileRotator) Rotate() error {
 
 	// create the new file
 	path := rotator.FilePath(0)
-	current, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	current, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, rotator.perm())
 	if err != nil {
 		return err
 	}
 	rotator.current = current
 	rotator.currentSize = 0
+	rotator.suspended = false
+	if rotator.RotateInterval > 0 {
+		rotator.currentStart = rotator.timeNow().Truncate(rotator.RotateInterval)
+	}
 
 	// delete the extra file, ignore errors here
 	path = rotator.FilePath(*rotator.KeepFiles)
@@ -158,3 +278,78 @@ func (rotator *FileRotator) Rotate() error {
 
 	return nil
 }
+
+// uncompressedPath returns the path the active file is moved to on rotation,
+// until it is compressed.
+func (rotator *FileRotator) uncompressedPath() string {
+	return filepath.Join(rotator.Path, rotator.Name+".1")
+}
+
+// compressAsync moves the active file out of the way and compresses it in the
+// background to the first rotated file.
+func (rotator *FileRotator) compressAsync(path string) error {
+	src, dst := rotator.uncompressedPath(), rotator.FilePath(1)
+	if err := os.Rename(path, src); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	rotator.compressing = done
+	go func() {
+		defer close(done)
+		if err := rotator.compress(src, dst); err != nil {
+			Err("Failed to compress rotated file %v: %v", src, err)
+		}
+	}()
+	return nil
+}
+
+// compressLeftover compresses a rotated file that was not compressed, like
+// when the Beat stopped while compressing.
+func (rotator *FileRotator) compressLeftover() error {
+	src, dst := rotator.uncompressedPath(), rotator.FilePath(1)
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+
+	// the compressed file might be incomplete
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return rotator.compress(src, dst)
+}
+
+func (rotator *FileRotator) waitCompress() {
+	if rotator.compressing != nil {
+		<-rotator.compressing
+		rotator.compressing = nil
+	}
+}
+
+// compress writes the gzip compressed contents of src to dst and removes src.
+func (rotator *FileRotator) compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, rotator.perm())
+	if err != nil {
+		return err
+	}
+
+	w := gzip.NewWriter(out)
+	if _, err = io.Copy(w, in); err == nil {
+		err = w.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -4,10 +4,12 @@ package logp
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -161,3 +163,172 @@ func TestConfigSane(t *testing.T) {
 	assert.NotNil(t, rotator.CheckIfConfigSane())
 
 }
+
+func TestRotatorInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2017, 3, 1, 10, 59, 0, 0, time.UTC)
+	rotator := FileRotator{
+		Path:           dir,
+		Name:           "packetbeat",
+		RotateInterval: time.Hour,
+		now:            func() time.Time { return now },
+	}
+	assert.NoError(t, rotator.CheckIfConfigSane())
+
+	assert.NoError(t, rotator.WriteLine([]byte("1")))
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	now = now.Add(time.Minute)
+	assert.NoError(t, rotator.WriteLine([]byte("3")))
+
+	file0, err := ioutil.ReadFile(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", string(file0))
+
+	file1, err := ioutil.ReadFile(rotator.FilePath(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(file1))
+}
+
+func TestRotatorCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rotateeverybytes := uint64(2)
+	rotator := FileRotator{
+		Path:             dir,
+		Name:             "packetbeat",
+		RotateEveryBytes: &rotateeverybytes,
+		Compress:         true,
+		Permissions:      0640,
+	}
+	assert.NoError(t, rotator.CheckIfConfigSane())
+
+	assert.NoError(t, rotator.WriteLine([]byte("1")))
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	assert.NoError(t, rotator.WriteLine([]byte("3")))
+	// wait for the background compression
+	assert.NoError(t, rotator.Close())
+
+	assert.Equal(t, filepath.Join(dir, "packetbeat.1.gz"), rotator.FilePath(1))
+	for i, expected := range []string{"2\n", "1\n"} {
+		f, err := os.Open(rotator.FilePath(i + 1))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		r, err := gzip.NewReader(f)
+		if assert.NoError(t, err) {
+			content, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(content))
+		}
+		f.Close()
+	}
+
+	info, err := os.Stat(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestRotatorCompressLeftover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a rotated file not compressed before the Beat stopped
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "packetbeat.1"), []byte("1\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "packetbeat.1.gz"), []byte("incomplete"), 0600))
+
+	rotator := FileRotator{
+		Path:     dir,
+		Name:     "packetbeat",
+		Compress: true,
+	}
+	assert.NoError(t, rotator.CheckIfConfigSane())
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	assert.NoError(t, rotator.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "packetbeat.1"))
+	assert.True(t, os.IsNotExist(err))
+
+	f, err := os.Open(rotator.FilePath(2))
+	if assert.NoError(t, err) {
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if assert.NoError(t, err) {
+			content, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, "1\n", string(content))
+		}
+	}
+}
+
+func TestRotatorCloseReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rotator := FileRotator{
+		Path: dir,
+		Name: "packetbeat",
+	}
+	assert.NoError(t, rotator.CheckIfConfigSane())
+
+	assert.NoError(t, rotator.WriteLine([]byte("1")))
+	assert.NoError(t, rotator.Close())
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	assert.NoError(t, rotator.Close())
+
+	file0, err := ioutil.ReadFile(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(file0))
+	assert.False(t, rotator.FileExists(1))
+}
+
+func TestRotatorAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2017, 3, 1, 10, 30, 0, 0, time.UTC)
+	path := filepath.Join(dir, "packetbeat")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("1\n"), 0600))
+	assert.NoError(t, os.Chtimes(path, now, now))
+
+	rotator := FileRotator{
+		Path:           dir,
+		Name:           "packetbeat",
+		RotateInterval: time.Hour,
+		Append:         true,
+		now:            func() time.Time { return now },
+	}
+	assert.NoError(t, rotator.CheckIfConfigSane())
+
+	// the file was last written in the current interval
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	now = now.Add(time.Hour)
+	assert.NoError(t, rotator.WriteLine([]byte("3")))
+	assert.NoError(t, rotator.Close())
+
+	file0, err := ioutil.ReadFile(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", string(file0))
+
+	file1, err := ioutil.ReadFile(rotator.FilePath(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(file1))
+}
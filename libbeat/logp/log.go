@@ -1,9 +1,12 @@
 package logp
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 	"runtime/debug"
 	"time"
 )
@@ -30,6 +33,8 @@ type Logger struct {
 	level             Priority
 	selectors         map[string]struct{}
 	debugAllSelectors bool
+	selectorLevels    map[string]Priority
+	json              bool
 
 	logger  *log.Logger
 	syslog  [LOG_DEBUG + 1]*log.Logger
@@ -40,6 +45,9 @@ type Logger struct {
 
 const stderrLogFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC | log.Lshortfile
 
+// jsonTimeFormat is the format of the timestamp of JSON log lines.
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z"
+
 var _log = Logger{}
 
 // TODO: remove toSyslog and toStderr from the init function
@@ -71,37 +79,148 @@ func parseSelectors(selectors []string) (map[string]struct{}, bool) {
 	return set, all
 }
 
-func debugMessage(calldepth int, selector, format string, v ...interface{}) {
-	if _log.level >= LOG_DEBUG && IsDebug(selector) {
-		send(calldepth+1, LOG_DEBUG, "DBG  ", format, v...)
+func debugMessage(calldepth int, selector string, fields []interface{}, format string, v ...interface{}) {
+	if levelEnabled(LOG_DEBUG, selector) && IsDebug(selector) {
+		send(calldepth+1, LOG_DEBUG, "DBG  ", selector, fields, format, v...)
+	}
+}
+
+// levelEnabled checks the level against the level configured for the
+// selector, or the global level if there is none.
+func levelEnabled(level Priority, selector string) bool {
+	if selectorLevel, exists := _log.selectorLevels[selector]; exists {
+		return selectorLevel >= level
 	}
+	return _log.level >= level
 }
 
-func send(calldepth int, level Priority, prefix string, format string, v ...interface{}) {
+func send(calldepth int, level Priority, prefix, selector string, fields []interface{}, format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	text := message + formatFields(fields)
+
+	var line string
+	if _log.json && (_log.toStderr || _log.toFile) {
+		line = formatJSON(calldepth, level, selector, message, fields)
+	}
+
 	if _log.toSyslog {
-		_log.syslog[level].Output(calldepth, fmt.Sprintf(format, v...))
+		_log.syslog[level].Output(calldepth, text)
 	}
 	if _log.toStderr {
-		_log.logger.Output(calldepth, fmt.Sprintf(prefix+format, v...))
+		if _log.json {
+			_log.logger.Output(calldepth, line)
+		} else {
+			_log.logger.Output(calldepth, prefix+text)
+		}
 	}
 	if _log.toFile {
-		// Creates a timestamp for the file log message and formats it
-		prefix = time.Now().Format(time.RFC3339) + " " + prefix
-		_log.rotator.WriteLine([]byte(fmt.Sprintf(prefix+format, v...)))
+		if _log.json {
+			_log.rotator.WriteLine([]byte(line))
+		} else {
+			// Creates a timestamp for the file log message and formats it
+			prefix = time.Now().Format(time.RFC3339) + " " + prefix
+			_log.rotator.WriteLine([]byte(prefix + text))
+		}
+	}
+}
+
+// formatFields formats the key-value pairs for text log lines.
+func formatFields(fields []interface{}) string {
+	text := ""
+	forEachField(fields, func(key string, value interface{}) {
+		text += fmt.Sprintf(" %s=%v", key, value)
+	})
+	return text
+}
+
+// formatJSON formats a log line as JSON object. The key-value pairs are added
+// to the object, unless they clash with the standard keys. The calldepth
+// counts the frames to skip from the caller of formatJSON.
+func formatJSON(calldepth int, level Priority, selector, message string, fields []interface{}) string {
+	event := map[string]interface{}{}
+	forEachField(fields, func(key string, value interface{}) {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		event[key] = value
+	})
+
+	event["timestamp"] = time.Now().UTC().Format(jsonTimeFormat)
+	event["level"] = levelName(level)
+	event["message"] = message
+	if selector != "" {
+		event["selector"] = selector
+	}
+	if _, file, line, ok := runtime.Caller(calldepth); ok {
+		event["caller"] = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		// the context holds values not encodable as JSON, use their
+		// string representation instead
+		for key, value := range event {
+			event[key] = fmt.Sprint(value)
+		}
+		b, _ = json.Marshal(event)
+	}
+	return string(b)
+}
+
+// forEachField calls fn for every key-value pair. Keys not being strings are
+// formatted, a missing value of the last key is reported as nil.
+func forEachField(fields []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		key, ok := fields[i].(string)
+		if !ok {
+			key = fmt.Sprint(fields[i])
+		}
+
+		var value interface{}
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		fn(key, value)
+	}
+}
+
+func levelName(level Priority) string {
+	switch level {
+	case LOG_EMERG:
+		return "emergency"
+	case LOG_ALERT:
+		return "alert"
+	case LOG_CRIT:
+		return "critical"
+	case LOG_ERR:
+		return "error"
+	case LOG_WARNING:
+		return "warning"
+	case LOG_NOTICE:
+		return "notice"
+	case LOG_INFO:
+		return "info"
+	default:
+		return "debug"
 	}
 }
 
 func Debug(selector string, format string, v ...interface{}) {
-	debugMessage(3, selector, format, v...)
+	debugMessage(3, selector, nil, format, v...)
 }
 
 func MakeDebug(selector string) func(string, ...interface{}) {
 	return func(msg string, v ...interface{}) {
-		debugMessage(3, selector, msg, v...)
+		debugMessage(3, selector, nil, msg, v...)
 	}
 }
 
+// IsDebug returns true if debug messages of the selector are logged, either
+// because the selector is enabled or its level is set to debug.
 func IsDebug(selector string) bool {
+	if level, exists := _log.selectorLevels[selector]; exists {
+		return level >= LOG_DEBUG
+	}
 	return _log.debugAllSelectors || HasSelector(selector)
 }
 
@@ -112,7 +231,7 @@ func HasSelector(selector string) bool {
 
 func msg(level Priority, prefix string, format string, v ...interface{}) {
 	if _log.level >= level {
-		send(4, level, prefix, format, v...)
+		send(4, level, prefix, "", nil, format, v...)
 	}
 }
 
@@ -135,7 +254,7 @@ func Critical(format string, v ...interface{}) {
 // WTF prints the message at CRIT level and panics immediately with the same
 // message
 func WTF(format string, v ...interface{}) {
-	msg(LOG_CRIT, "CRIT ", format, v)
+	msg(LOG_CRIT, "CRIT ", format, v...)
 	panic(fmt.Sprintf(format, v...))
 }
 
@@ -149,8 +268,13 @@ func Recover(msg string) {
 func SetToStderr(toStderr bool, prefix string) {
 	_log.toStderr = toStderr
 	if _log.toStderr {
-		// Add timestamp
-		_log.logger = log.New(os.Stderr, prefix, stderrLogFlags)
+		if _log.json {
+			// JSON lines hold the timestamp and caller
+			_log.logger = log.New(os.Stderr, "", 0)
+		} else {
+			// Add timestamp
+			_log.logger = log.New(os.Stderr, prefix, stderrLogFlags)
+		}
 	}
 }
 
@@ -0,0 +1,122 @@
+// +build !integration
+
+package logp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLog sets up logging to a buffer and restores the former settings
+// when the returned function is called.
+func captureLog(level Priority, selectorLevels map[string]Priority, jsonFormat bool) (*bytes.Buffer, func()) {
+	old := _log
+
+	buf := &bytes.Buffer{}
+	_log = Logger{
+		toStderr:       true,
+		level:          level,
+		selectorLevels: selectorLevels,
+		json:           jsonFormat,
+		logger:         log.New(buf, "", 0),
+	}
+	return buf, func() { _log = old }
+}
+
+func readJSONLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		event := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestJSONLogging(t *testing.T) {
+	buf, restore := captureLog(LOG_DEBUG, nil, true)
+	defer restore()
+	_log.debugAllSelectors = true
+
+	Info("hello %v", "world")
+	NewContextLogger("publish", "output", "kafka").With("error", errors.New("oops")).Warn("failed")
+	Debug("test", "debug message")
+
+	events := readJSONLines(t, buf)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "info", events[0]["level"])
+	assert.Equal(t, "hello world", events[0]["message"])
+	assert.NotContains(t, events[0], "selector")
+	assert.Regexp(t, `^log_test.go:\d+$`, events[0]["caller"])
+	assert.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{3}Z$`, events[0]["timestamp"])
+
+	assert.Equal(t, "warning", events[1]["level"])
+	assert.Equal(t, "failed", events[1]["message"])
+	assert.Equal(t, "publish", events[1]["selector"])
+	assert.Equal(t, "kafka", events[1]["output"])
+	assert.Equal(t, "oops", events[1]["error"])
+	assert.Regexp(t, `^log_test.go:\d+$`, events[1]["caller"])
+
+	assert.Equal(t, "debug", events[2]["level"])
+	assert.Equal(t, "test", events[2]["selector"])
+	assert.Regexp(t, `^log_test.go:\d+$`, events[2]["caller"])
+}
+
+func TestJSONLoggingUnsupportedValues(t *testing.T) {
+	buf, restore := captureLog(LOG_INFO, nil, true)
+	defer restore()
+
+	NewContextLogger("test", "ch", make(chan int)).Info("message")
+
+	events := readJSONLines(t, buf)
+	require.Len(t, events, 1)
+	assert.Equal(t, "message", events[0]["message"])
+	assert.Contains(t, events[0], "ch")
+}
+
+func TestTextLoggingContext(t *testing.T) {
+	buf, restore := captureLog(LOG_INFO, nil, false)
+	defer restore()
+
+	NewContextLogger("test", "a", 1, "b").Info("message %d", 42)
+	assert.Equal(t, "INFO message 42 a=1 b=<nil>\n", buf.String())
+}
+
+func TestSelectorLevels(t *testing.T) {
+	buf, restore := captureLog(LOG_INFO, map[string]Priority{
+		"publish": LOG_DEBUG,
+		"output":  LOG_ERR,
+	}, false)
+	defer restore()
+
+	assert.True(t, IsDebug("publish"))
+	assert.False(t, IsDebug("other"))
+
+	Debug("publish", "publish debug")
+	Debug("other", "other debug")
+	NewContextLogger("output").Warn("output warning")
+	NewContextLogger("output").Err("output error")
+	NewContextLogger("other").Info("other info")
+
+	assert.Equal(t, "DBG  publish debug\nERR output error\nINFO other info\n", buf.String())
+}
+
+func TestGetSelectorLevels(t *testing.T) {
+	levels, err := getSelectorLevels(&Logging{Levels: map[string]string{"publish": "Debug"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Priority{"publish": LOG_DEBUG}, levels)
+
+	_, err = getSelectorLevels(&Logging{Levels: map[string]string{"publish": "verbose"}})
+	assert.Error(t, err)
+}
@@ -28,6 +28,8 @@ type Logging struct {
 	ToSyslog  *bool `config:"to_syslog"`
 	ToFiles   *bool `config:"to_files"`
 	Level     string
+	Levels    map[string]string    `config:"levels"`
+	JSON      bool                 `config:"json"`
 	Metrics   LoggingMetricsConfig `config:"metrics"`
 }
 
@@ -87,6 +89,11 @@ func Init(name string, config *Logging) error {
 		return err
 	}
 
+	selectorLevels, err := getSelectorLevels(config)
+	if err != nil {
+		return err
+	}
+
 	if *verbose {
 		if LOG_INFO > logLevel {
 			logLevel = LOG_INFO
@@ -125,6 +132,8 @@ func Init(name string, config *Logging) error {
 		toFiles = false
 	}
 
+	_log.json = config.JSON
+	_log.selectorLevels = selectorLevels
 	LogInit(Priority(logLevel), "", toSyslog, true, debugSelectors)
 	if len(debugSelectors) > 0 {
 		config.Selectors = debugSelectors
@@ -174,7 +183,28 @@ func getLogLevel(config *Logging) (Priority, error) {
 	if config == nil || config.Level == "" {
 		return LOG_INFO, nil
 	}
+	return parseLevel(config.Level)
+}
+
+// getSelectorLevels parses the levels configured per selector, which take
+// precedence over the global level.
+func getSelectorLevels(config *Logging) (map[string]Priority, error) {
+	if config == nil || len(config.Levels) == 0 {
+		return nil, nil
+	}
+
+	levels := make(map[string]Priority, len(config.Levels))
+	for selector, name := range config.Levels {
+		level, err := parseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level of selector %v: %v", selector, err)
+		}
+		levels[selector] = level
+	}
+	return levels, nil
+}
 
+func parseLevel(name string) (Priority, error) {
 	levels := map[string]Priority{
 		"critical": LOG_CRIT,
 		"error":    LOG_ERR,
@@ -183,9 +213,9 @@ func getLogLevel(config *Logging) (Priority, error) {
 		"debug":    LOG_DEBUG,
 	}
 
-	level, ok := levels[strings.ToLower(config.Level)]
+	level, ok := levels[strings.ToLower(name)]
 	if !ok {
-		return 0, fmt.Errorf("unknown log level: %v", config.Level)
+		return 0, fmt.Errorf("unknown log level: %v", name)
 	}
 	return level, nil
 }
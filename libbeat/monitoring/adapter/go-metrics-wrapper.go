@@ -80,8 +80,24 @@ func (w goMetricsFuncGaugeFloat) Visit(_ monitoring.Mode, vs monitoring.Visitor)
 
 func (w goMetricsHistogram) wrapped() interface{} { return w.h }
 func (w goMetricsHistogram) Get() int64           { return w.h.Sum() }
+
+// Visit reports the histograms summary statistics as sub-registry.
 func (w goMetricsHistogram) Visit(_ monitoring.Mode, vs monitoring.Visitor) {
-	vs.OnInt(w.Get())
+	vs.OnRegistryStart()
+	defer vs.OnRegistryFinished()
+
+	h := w.h.Snapshot()
+	ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+	monitoring.ReportInt(vs, "count", h.Count())
+	monitoring.ReportInt(vs, "min", h.Min())
+	monitoring.ReportInt(vs, "max", h.Max())
+	monitoring.ReportFloat(vs, "mean", h.Mean())
+	monitoring.ReportFloat(vs, "stddev", h.StdDev())
+	monitoring.ReportFloat(vs, "median", ps[0])
+	monitoring.ReportFloat(vs, "p75", ps[1])
+	monitoring.ReportFloat(vs, "p95", ps[2])
+	monitoring.ReportFloat(vs, "p99", ps[3])
+	monitoring.ReportFloat(vs, "p999", ps[4])
 }
 
 func (w goMetricsMeter) wrapped() interface{} { return w.m }
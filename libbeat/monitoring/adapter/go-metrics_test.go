@@ -86,3 +86,22 @@ func TestGoMetricsAdapter(t *testing.T) {
 		}
 	})
 }
+
+func TestGoMetricsHistogram(t *testing.T) {
+	monReg := monitoring.NewRegistry()
+	reg := GetGoMetrics(monReg, "test", Accept)
+
+	h := reg.GetOrRegister("latency", func() interface{} {
+		return metrics.NewHistogram(metrics.NewUniformSample(100))
+	}).(metrics.Histogram)
+	for i := int64(1); i <= 4; i++ {
+		h.Update(i)
+	}
+
+	snapshot := monitoring.CollectFlatSnapshot(monReg, monitoring.Full, false)
+	assert.Equal(t, int64(4), snapshot.Ints["test.latency.count"])
+	assert.Equal(t, int64(1), snapshot.Ints["test.latency.min"])
+	assert.Equal(t, int64(4), snapshot.Ints["test.latency.max"])
+	assert.Equal(t, 2.5, snapshot.Floats["test.latency.mean"])
+	assert.Equal(t, 2.5, snapshot.Floats["test.latency.median"])
+}
@@ -79,3 +79,79 @@ func (vs *snapshotVisitor) OnInt(i int64) {
 func (vs *snapshotVisitor) OnFloat(f float64) {
 	vs.snapshot.Floats[vs.getName()] = f
 }
+
+type structSnapshotVisitor struct {
+	root    map[string]interface{}
+	current map[string]interface{}
+	stack   []map[string]interface{}
+	key     string
+}
+
+// CollectStructSnapshot collects a structured snapshot of a metrics tree
+// starting with the given registry. Registries are reported as nested maps.
+// Names containing dots, like the names of expvar metrics, are split into
+// nested maps as well.
+func CollectStructSnapshot(r *Registry, mode Mode, expvar bool) map[string]interface{} {
+	vs := &structSnapshotVisitor{root: map[string]interface{}{}}
+	r.Visit(mode, vs)
+	if expvar {
+		VisitExpvars(vs)
+	}
+	return vs.root
+}
+
+func (vs *structSnapshotVisitor) OnRegistryStart() {
+	if len(vs.stack) == 0 && vs.current == nil {
+		// top-level registry, merged into the root map
+		vs.stack = append(vs.stack, nil)
+		vs.current = vs.root
+		return
+	}
+
+	vs.stack = append(vs.stack, vs.current)
+	vs.current = vs.sub(vs.key)
+}
+
+func (vs *structSnapshotVisitor) OnRegistryFinished() {
+	last := len(vs.stack) - 1
+	vs.current = vs.stack[last]
+	vs.stack = vs.stack[:last]
+}
+
+func (vs *structSnapshotVisitor) OnKey(name string) {
+	vs.key = name
+}
+
+func (vs *structSnapshotVisitor) OnString(s string) { vs.set(s) }
+func (vs *structSnapshotVisitor) OnBool(b bool)     { vs.set(b) }
+func (vs *structSnapshotVisitor) OnInt(i int64)     { vs.set(i) }
+func (vs *structSnapshotVisitor) OnFloat(f float64) { vs.set(f) }
+
+// sub returns the nested map for the current key, creating all intermediate
+// maps.
+func (vs *structSnapshotVisitor) sub(key string) map[string]interface{} {
+	m := vs.current
+	for _, name := range strings.Split(key, ".") {
+		next, ok := m[name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[name] = next
+		}
+		m = next
+	}
+	return m
+}
+
+func (vs *structSnapshotVisitor) set(v interface{}) {
+	m := vs.current
+	names := strings.Split(vs.key, ".")
+	for _, name := range names[:len(names)-1] {
+		next, ok := m[name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[name] = next
+		}
+		m = next
+	}
+	m[names[len(names)-1]] = v
+}
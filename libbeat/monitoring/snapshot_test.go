@@ -0,0 +1,37 @@
+// +build !integration
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStructSnapshot(t *testing.T) {
+	reg := NewRegistry()
+	NewInt(reg, "a.b").Set(1)
+	NewString(reg, "a.name").Set("test")
+	NewFloat(reg, "f", Report).Set(1.5)
+
+	assert.Equal(t, map[string]interface{}{"f": 1.5},
+		CollectStructSnapshot(reg, Reported, false))
+
+	expected := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b":    int64(1),
+			"name": "test",
+		},
+		"f": 1.5,
+	}
+	assert.Equal(t, expected, CollectStructSnapshot(reg, Full, false))
+}
+
+func TestCollectStructSnapshotExpvars(t *testing.T) {
+	i := getOrCreateInt("test.struct.snapshot")
+	i.Set(42)
+
+	snapshot := CollectStructSnapshot(NewRegistry(), Full, true)
+	test := snapshot["test"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"snapshot": int64(42)}, test["struct"])
+}
@@ -0,0 +1,266 @@
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusVisitor collects metrics for rendering them in the Prometheus
+// text exposition format. Metric names are the dotted names with all
+// characters not allowed in Prometheus names replaced by underscores. Path
+// segments identifying an output, topic, partition, module or metricset are
+// turned into labels (see promRules). String values are not reported.
+type PrometheusVisitor struct {
+	level   []string
+	samples map[string][]promSample
+}
+
+type promSample struct {
+	labels string
+	value  string
+}
+
+// promRule extracts labels from the names matching pattern. The segments
+// matched by pattern are replaced by the segments of name. Pattern segments
+// are either literals or templates made of labels and literal separators,
+// e.g. `{output}` or `{module}-{metricset}`. A label can restrict the values
+// it matches, e.g. `{output:es|logstash}`.
+type promRule struct {
+	pattern []string
+	name    []string
+}
+
+var promRules = []promRule{
+	makePromRule("libbeat.output.{output}.topics.{topic}.partitions.{partition}", "libbeat.output.partition"),
+	makePromRule("libbeat.output.{output}.topics.{topic}", "libbeat.output.topic"),
+	makePromRule("libbeat.{output:es|logstash|kafka|redis|file|console}", "libbeat.output"),
+	makePromRule("fetches.{module}-{metricset}", "metricbeat.fetches"),
+}
+
+func makePromRule(pattern, name string) promRule {
+	return promRule{
+		pattern: strings.Split(pattern, "."),
+		name:    strings.Split(name, "."),
+	}
+}
+
+// NewPrometheusVisitor creates a new visitor collecting Prometheus samples.
+func NewPrometheusVisitor() *PrometheusVisitor {
+	return &PrometheusVisitor{samples: map[string][]promSample{}}
+}
+
+// WritePrometheus writes all metrics of the registry, and optionally all
+// expvar metrics, in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, r *Registry, mode Mode, expvar bool) error {
+	vs := NewPrometheusVisitor()
+	r.Visit(mode, vs)
+	if expvar {
+		VisitExpvars(vs)
+	}
+	_, err := vs.WriteTo(w)
+	return err
+}
+
+func (vs *PrometheusVisitor) OnRegistryStart() {}
+
+func (vs *PrometheusVisitor) OnRegistryFinished() {
+	if len(vs.level) > 0 {
+		vs.dropName()
+	}
+}
+
+func (vs *PrometheusVisitor) OnKey(name string) {
+	vs.level = append(vs.level, name)
+}
+
+func (vs *PrometheusVisitor) dropName() {
+	vs.level = vs.level[:len(vs.level)-1]
+}
+
+func (vs *PrometheusVisitor) OnString(s string) { vs.dropName() }
+
+func (vs *PrometheusVisitor) OnBool(b bool) {
+	if b {
+		vs.add("1")
+	} else {
+		vs.add("0")
+	}
+}
+
+func (vs *PrometheusVisitor) OnInt(i int64) {
+	vs.add(strconv.FormatInt(i, 10))
+}
+
+func (vs *PrometheusVisitor) OnFloat(f float64) {
+	vs.add(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func (vs *PrometheusVisitor) add(value string) {
+	defer vs.dropName()
+
+	var path []string
+	for _, name := range vs.level {
+		path = append(path, strings.Split(name, ".")...)
+	}
+
+	name, labels := promNameAndLabels(path)
+	vs.samples[name] = append(vs.samples[name], promSample{labels, value})
+}
+
+// WriteTo writes the collected samples, sorted by metric name and labels.
+func (vs *PrometheusVisitor) WriteTo(w io.Writer) (int64, error) {
+	names := make([]string, 0, len(vs.samples))
+	for name := range vs.samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		samples := vs.samples[name]
+		sort.Sort(promSamples(samples))
+
+		fmt.Fprintf(&buf, "# TYPE %s untyped\n", name)
+		for _, s := range samples {
+			fmt.Fprintf(&buf, "%s%s %s\n", name, s.labels, s.value)
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+type promSamples []promSample
+
+func (s promSamples) Len() int           { return len(s) }
+func (s promSamples) Less(i, j int) bool { return s[i].labels < s[j].labels }
+func (s promSamples) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// promNameAndLabels applies the first matching rule to the path, returning
+// the Prometheus metric name and the formatted labels.
+func promNameAndLabels(path []string) (string, string) {
+	for _, rule := range promRules {
+		labels, ok := rule.match(path)
+		if !ok {
+			continue
+		}
+
+		name := append(append([]string{}, rule.name...), path[len(rule.pattern):]...)
+		return promName(name), formatPromLabels(labels)
+	}
+	return promName(path), ""
+}
+
+type promLabel struct {
+	name, value string
+}
+
+func (r promRule) match(path []string) ([]promLabel, bool) {
+	if len(path) <= len(r.pattern) {
+		return nil, false
+	}
+
+	var labels []promLabel
+	for i, pattern := range r.pattern {
+		segmentLabels, ok := matchPromSegment(pattern, path[i])
+		if !ok {
+			return nil, false
+		}
+		labels = append(labels, segmentLabels...)
+	}
+	return labels, true
+}
+
+// matchPromSegment matches a single path segment against a segment pattern.
+// Labels match all characters up to the first occurrence of the following
+// literal separator, or the rest of the segment.
+func matchPromSegment(pattern, segment string) ([]promLabel, bool) {
+	var labels []promLabel
+	for pattern != "" {
+		start := strings.IndexByte(pattern, '{')
+		if start != 0 {
+			literal := pattern
+			if start > 0 {
+				literal = pattern[:start]
+			}
+			if !strings.HasPrefix(segment, literal) {
+				return nil, false
+			}
+			pattern = pattern[len(literal):]
+			segment = segment[len(literal):]
+			continue
+		}
+
+		end := strings.IndexByte(pattern, '}')
+		label := pattern[1:end]
+		pattern = pattern[end+1:]
+
+		value := segment
+		if pattern != "" {
+			next := strings.IndexByte(pattern, '{')
+			if next < 0 {
+				next = len(pattern)
+			}
+			idx := strings.Index(segment, pattern[:next])
+			if idx < 0 {
+				return nil, false
+			}
+			value = segment[:idx]
+		}
+		segment = segment[len(value):]
+
+		if value == "" {
+			return nil, false
+		}
+		if i := strings.IndexByte(label, ':'); i >= 0 {
+			allowed := strings.Split(label[i+1:], "|")
+			label = label[:i]
+			if !containsString(allowed, value) {
+				return nil, false
+			}
+		}
+		labels = append(labels, promLabel{label, value})
+	}
+	return labels, segment == ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// promName joins the path and replaces all characters not valid in
+// Prometheus metric names with underscores.
+func promName(path []string) string {
+	b := []byte(strings.Join(path, "_"))
+	for i, c := range b {
+		valid := c == '_' || c == ':' ||
+			('a' <= c && c <= 'z') ||
+			('A' <= c && c <= 'Z') ||
+			(i > 0 && '0' <= c && c <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatPromLabels(labels []promLabel) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l.name, promLabelEscaper.Replace(l.value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,62 @@
+// +build !integration
+
+package monitoring
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusNameAndLabels(t *testing.T) {
+	tests := []struct {
+		path   string
+		name   string
+		labels string
+	}{
+		{"libbeat.es.publish.read_bytes", "libbeat_output_publish_read_bytes", `{output="es"}`},
+		{"libbeat.outputs.messages_dropped", "libbeat_outputs_messages_dropped", ""},
+		{"libbeat.output.kafka.topics.test.acked", "libbeat_output_topic_acked", `{output="kafka",topic="test"}`},
+		{
+			"libbeat.output.kafka.topics.test.partitions.1.failed",
+			"libbeat_output_partition_failed",
+			`{output="kafka",topic="test",partition="1"}`,
+		},
+		{"fetches.system-cpu.events", "metricbeat_fetches_events", `{module="system",metricset="cpu"}`},
+		{"fetches.system.events", "fetches_system_events", ""},
+		{"filebeat.harvester.open-files", "filebeat_harvester_open_files", ""},
+	}
+
+	for _, test := range tests {
+		name, labels := promNameAndLabels(strings.Split(test.path, "."))
+		assert.Equal(t, test.name, name, test.path)
+		assert.Equal(t, test.labels, labels, test.path)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	reg := NewRegistry()
+	NewInt(reg, "libbeat.logstash.publish.write_bytes").Set(10)
+	NewInt(reg, "libbeat.es.publish.write_bytes").Set(20)
+	NewFloat(reg, "filebeat.ratio").Set(0.5)
+	NewString(reg, "beat.name").Set("testbeat")
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, reg, Full, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# TYPE filebeat_ratio untyped\n" +
+		"filebeat_ratio 0.5\n" +
+		"# TYPE libbeat_output_publish_write_bytes untyped\n" +
+		"libbeat_output_publish_write_bytes{output=\"es\"} 20\n" +
+		"libbeat_output_publish_write_bytes{output=\"logstash\"} 10\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestFormatPromLabelsEscaping(t *testing.T) {
+	labels := formatPromLabels([]promLabel{{"topic", "a\"b\\c\n"}})
+	assert.Equal(t, `{topic="a\"b\\c\n"}`, labels)
+}
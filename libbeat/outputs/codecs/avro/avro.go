@@ -0,0 +1,200 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+// Encoder encodes events using Avro. The schema is either configured or
+// derived from each event. Schemas are registered with a schema registry and
+// the messages are prefixed with the schema ID, as required by the Confluent
+// serializers:
+//
+//	<magic byte 0> <4 bytes schema ID, big endian> <avro binary encoding>
+type Encoder struct {
+	registry *registry
+
+	// configured schema, nil if schemas are derived from the events
+	schema     *schema
+	schemaText string
+
+	recordName    string
+	fallbackField string
+}
+
+const magicByte = 0
+
+var debugf = logp.MakeDebug("avro")
+
+func init() {
+	outputs.RegisterOutputCodec("avro", func(cfg *common.Config) (outputs.Codec, error) {
+		config := defaultConfig
+		if cfg == nil {
+			return nil, errors.New("empty avro codec configuration")
+		}
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+
+		return newFromConfig(config)
+	})
+}
+
+func newFromConfig(config config) (*Encoder, error) {
+	text := config.Schema
+	if config.SchemaFile != "" {
+		content, err := ioutil.ReadFile(config.SchemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read avro schema: %v", err)
+		}
+		text = string(content)
+	}
+
+	tls, err := outputs.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := transport.NetDialer(config.Timeout)
+	tlsDialer, err := transport.TLSDialer(dialer, tls, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial:    dialer.Dial,
+			DialTLS: tlsDialer.Dial,
+		},
+		Timeout: config.Timeout,
+	}
+
+	reg := newRegistry(config.SchemaRegistry, config.Subject,
+		config.Username, config.Password, client)
+	return newEncoder(reg, text, config.RecordName, config.FallbackField)
+}
+
+// newEncoder creates an avro encoder. If schemaText is empty, the schema is
+// derived from the events.
+func newEncoder(
+	reg *registry,
+	schemaText string,
+	recordName string,
+	fallbackField string,
+) (*Encoder, error) {
+	e := &Encoder{
+		registry:      reg,
+		recordName:    avroName(recordName),
+		fallbackField: fallbackField,
+	}
+	if schemaText == "" {
+		return e, nil
+	}
+
+	s, err := parseSchema(schemaText)
+	if err != nil {
+		return nil, err
+	}
+	if s.typ != "record" {
+		return nil, fmt.Errorf("avro schema must be a record, but is %v", s.typ)
+	}
+	if fallbackField != "" {
+		f, exists := s.field(fallbackField)
+		if !exists {
+			return nil, fmt.Errorf("fallback field '%v' missing in avro schema", fallbackField)
+		}
+		if !f.schema.acceptsString() {
+			return nil, fmt.Errorf("fallback field '%v' must be of type string", fallbackField)
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(schemaText)); err != nil {
+		return nil, err
+	}
+
+	e.schema = s
+	e.schemaText = compact.String()
+	return e, nil
+}
+
+func (e *Encoder) Encode(event common.MapStr) ([]byte, error) {
+	serializedEvent, err := e.encode(event)
+	if err != nil {
+		logp.Err("Fail to convert the event to avro (%v): %#v", err, event)
+	}
+	return serializedEvent, err
+}
+
+func (e *Encoder) encode(event common.MapStr) ([]byte, error) {
+	s, text := e.schema, e.schemaText
+	if s == nil {
+		s = deriveSchema(e.recordName, event, e.fallbackField)
+		text = s.String()
+	}
+
+	id, err := e.registry.schemaID(text)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := encoder{buf: make([]byte, 5, 512)}
+	enc.buf[0] = magicByte
+	binary.BigEndian.PutUint32(enc.buf[1:], uint32(id))
+
+	value := map[string]interface{}(event)
+	if e.fallbackField != "" {
+		value, err = e.withFallback(s, event)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.encodeRecord(s, value); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+// withFallback returns a shallow copy of the event, with all top-level fields
+// not covered by the schema being JSON encoded into the fallback field.
+func (e *Encoder) withFallback(s *schema, event common.MapStr) (map[string]interface{}, error) {
+	covered := make(map[string]bool, len(s.fields))
+	for _, f := range s.fields {
+		if f.name != e.fallbackField {
+			covered[f.key] = true
+		}
+	}
+
+	value := make(map[string]interface{}, len(s.fields))
+	uncovered := map[string]interface{}{}
+	for k, v := range event {
+		if covered[k] {
+			value[k] = v
+		} else {
+			uncovered[k] = v
+		}
+	}
+
+	fallback, _ := s.field(e.fallbackField)
+	if len(uncovered) == 0 && fallback.schema.nullable() {
+		return value, nil
+	}
+
+	encoded, err := json.Marshal(uncovered)
+	if err != nil {
+		return nil, err
+	}
+	value[fallback.key] = string(encoded)
+	return value, nil
+}
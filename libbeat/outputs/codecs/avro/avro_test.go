@@ -0,0 +1,223 @@
+// +build !integration
+
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// testRegistry is a stand-in for the schema registry, assigning consecutive
+// IDs to new schemas.
+type testRegistry struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	schemas  []string
+	requests int
+	subject  string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.requests++
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, registryContentType, req.Header.Get("Content-Type"))
+
+		var body registerRequest
+		content, _ := ioutil.ReadAll(req.Body)
+		if err := json.Unmarshal(content, &body); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error_code":42201,"message":"Input schema is an invalid Avro schema"}`))
+			return
+		}
+		r.subject = req.URL.Path
+
+		for i, s := range r.schemas {
+			if s == body.Schema {
+				json.NewEncoder(w).Encode(registerResponse{ID: int32(i + 1)})
+				return
+			}
+		}
+		r.schemas = append(r.schemas, body.Schema)
+		json.NewEncoder(w).Encode(registerResponse{ID: int32(len(r.schemas))})
+	}))
+	return r
+}
+
+func newTestEncoder(t *testing.T, reg *testRegistry, schemaText, fallback string) *Encoder {
+	config := defaultConfig
+	config.SchemaRegistry = reg.URL
+	config.Subject = "beats-value"
+	config.Schema = schemaText
+	config.FallbackField = fallback
+	enc, err := newFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestEncodeConfiguredSchema(t *testing.T) {
+	reg := newTestRegistry(t)
+	defer reg.Close()
+
+	enc := newTestEncoder(t, reg, `{
+		"type": "record",
+		"name": "test",
+		"fields": [
+			{"name": "name", "type": "string"},
+			{"name": "count", "type": "long"}
+		]
+	}`, "")
+
+	output, err := enc.Encode(common.MapStr{"name": "ab", "count": 1, "other": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1, 0x04, 'a', 'b', 0x02}, output)
+
+	// the schema ID is cached
+	_, err = enc.Encode(common.MapStr{"name": "cd", "count": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reg.requests)
+	assert.Equal(t, "/subjects/beats-value/versions", reg.subject)
+	assert.Equal(t,
+		`{"type":"record","name":"test","fields":[{"name":"name","type":"string"},{"name":"count","type":"long"}]}`,
+		reg.schemas[0])
+
+	// missing required fields fail
+	_, err = enc.Encode(common.MapStr{"name": "ab"})
+	assert.Error(t, err)
+}
+
+func TestEncodeFallbackField(t *testing.T) {
+	reg := newTestRegistry(t)
+	defer reg.Close()
+
+	enc := newTestEncoder(t, reg, `{
+		"type": "record",
+		"name": "test",
+		"fields": [
+			{"name": "name", "type": "string"},
+			{"name": "rest", "type": ["null", "string"], "default": null}
+		]
+	}`, "rest")
+
+	output, err := enc.Encode(common.MapStr{"name": "ab"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1, 0x04, 'a', 'b', 0x00}, output)
+
+	output, err = enc.Encode(common.MapStr{"name": "ab", "x": 1})
+	assert.NoError(t, err)
+	rest := `{"x":1}`
+	expected := append([]byte{0, 0, 0, 0, 1, 0x04, 'a', 'b', 0x02, byte(2 * len(rest))}, rest...)
+	assert.Equal(t, expected, output)
+
+	// the fallback field must be part of the schema
+	config := defaultConfig
+	config.SchemaRegistry = reg.URL
+	config.Subject = "beats-value"
+	config.Schema = `{"type":"record","name":"test","fields":[{"name":"name","type":"string"}]}`
+	config.FallbackField = "rest"
+	_, err = newFromConfig(config)
+	assert.Error(t, err)
+}
+
+func TestEncodeDerivedSchema(t *testing.T) {
+	reg := newTestRegistry(t)
+	defer reg.Close()
+
+	enc := newTestEncoder(t, reg, "", "rest")
+
+	ts := time.Date(2017, 5, 1, 10, 30, 0, 0, time.UTC)
+	event := common.MapStr{
+		"@timestamp": common.Time(ts),
+		"message":    "hello",
+		"beat":       common.MapStr{"name": "test"},
+		"empty":      nil,
+	}
+	output, err := enc.Encode(event)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, output[:5])
+	assert.Len(t, reg.schemas, 1)
+	assert.Equal(t,
+		`{"fields":[`+
+			`{"default":null,"name":"_timestamp","type":["null",{"logicalType":"timestamp-millis","type":"long"}]},`+
+			`{"default":null,"name":"beat","type":["null",{"fields":[{"default":null,"name":"name","type":["null","string"]}],"name":"event_beat","type":"record"}]},`+
+			`{"default":null,"name":"message","type":["null","string"]},`+
+			`{"default":null,"name":"rest","type":["null","string"]}],`+
+			`"name":"event","type":"record"}`,
+		reg.schemas[0])
+
+	// events of the same shape reuse the schema
+	event["message"] = "world"
+	_, err = enc.Encode(event)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reg.requests)
+
+	// a new field registers a new schema
+	event["count"] = 3
+	output, err = enc.Encode(event)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 2}, output[:5])
+	assert.Equal(t, 2, reg.requests)
+}
+
+func TestRegistryCacheBounded(t *testing.T) {
+	reg := newTestRegistry(t)
+	defer reg.Close()
+
+	r := newRegistry(reg.URL, "test", "", "", http.DefaultClient)
+	for i := 0; i <= maxCachedSchemas; i++ {
+		_, err := r.schemaID(fmt.Sprintf(`{"type":"enum","name":"e","symbols":["s%d"]}`, i))
+		assert.NoError(t, err)
+	}
+	assert.Len(t, r.ids, maxCachedSchemas)
+	assert.Equal(t, maxCachedSchemas, r.lru.Len())
+
+	// the least recently used schema was evicted and is registered again
+	_, err := r.schemaID(`{"type":"enum","name":"e","symbols":["s0"]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, maxCachedSchemas+2, reg.requests)
+}
+
+func TestRegistryUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	server.Close()
+
+	// events can be retried while the registry is unreachable
+	reg := newRegistry(server.URL, "test", "", "", http.DefaultClient)
+	_, err := reg.schemaID(`"string"`)
+	if assert.Error(t, err) {
+		assert.IsType(t, &unavailableError{}, err)
+	}
+}
+
+func TestRegistryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error_code":409,"message":"Schema being registered is incompatible"}`))
+	}))
+	defer server.Close()
+
+	reg := newRegistry(server.URL, "test", "", "", http.DefaultClient)
+	_, err := reg.schemaID(`"string"`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "incompatible")
+	}
+}
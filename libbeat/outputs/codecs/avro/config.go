@@ -0,0 +1,39 @@
+package avro
+
+import (
+	"errors"
+	"time"
+
+	"github.com/elastic/beats/libbeat/outputs"
+)
+
+type config struct {
+	SchemaRegistry string             `config:"schema_registry" validate:"required"`
+	Subject        string             `config:"subject" validate:"required"`
+	Schema         string             `config:"schema"`
+	SchemaFile     string             `config:"schema_file"`
+	RecordName     string             `config:"record_name"`
+	FallbackField  string             `config:"fallback_field"`
+	Username       string             `config:"username"`
+	Password       string             `config:"password"`
+	TLS            *outputs.TLSConfig `config:"ssl"`
+	Timeout        time.Duration      `config:"timeout"`
+}
+
+var defaultConfig = config{
+	RecordName: "event",
+	Timeout:    30 * time.Second,
+}
+
+func (c *config) Validate() error {
+	if c.Schema != "" && c.SchemaFile != "" {
+		return errors.New("only one of schema and schema_file can be configured")
+	}
+	if c.RecordName == "" {
+		return errors.New("record_name must not be empty")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package avro
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// deriveSchema derives a record schema from the event. All fields are
+// optional (a union with null, defaulting to null), such that the schemas
+// derived from events of different shapes stay compatible. Top-level fields
+// whose type can not be derived (e.g. null values, empty or mixed arrays) are
+// not part of the schema. If fallbackField is set, a string field of this name
+// is added to the schema for collecting those fields.
+func deriveSchema(name string, event common.MapStr, fallbackField string) *schema {
+	s := &schema{typ: "record", name: name}
+	names := map[string]bool{}
+	if fallbackField != "" {
+		names[fallbackField] = true
+	}
+
+	for _, k := range sortedKeys(event) {
+		if k == fallbackField {
+			continue
+		}
+
+		fieldName := avroName(k)
+		if names[fieldName] {
+			continue
+		}
+
+		fs, ok := deriveType(name+"_"+fieldName, event[k])
+		if !ok {
+			continue
+		}
+
+		names[fieldName] = true
+		s.fields = append(s.fields, optionalField(fieldName, k, fs))
+	}
+
+	if fallbackField != "" {
+		s.fields = append(s.fields,
+			optionalField(fallbackField, fallbackField, &schema{typ: "string"}))
+	}
+	return s
+}
+
+func optionalField(name, key string, s *schema) field {
+	return field{
+		name:       name,
+		key:        key,
+		schema:     &schema{typ: "union", branches: []*schema{{typ: "null"}, s}},
+		hasDefault: true,
+	}
+}
+
+// deriveType returns the schema for a value. It fails if the type of the
+// value or any nested value can not be derived.
+func deriveType(name string, v interface{}) (*schema, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case bool:
+		return &schema{typ: "boolean"}, true
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return &schema{typ: "long"}, true
+	case uint:
+		return &schema{typ: "long"}, uint64(v) <= 1<<63-1
+	case uint64:
+		return &schema{typ: "long"}, v <= 1<<63-1
+	case float32, float64:
+		return &schema{typ: "double"}, true
+	case string:
+		return &schema{typ: "string"}, true
+	case []byte:
+		return &schema{typ: "bytes"}, true
+	case common.Time, time.Time:
+		return &schema{typ: "long", logicalType: "timestamp-millis"}, true
+	case common.MapStr:
+		return deriveRecord(name, v)
+	case map[string]interface{}:
+		return deriveRecord(name, v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	return deriveArray(name, rv)
+}
+
+func deriveRecord(name string, m map[string]interface{}) (*schema, bool) {
+	s := &schema{typ: "record", name: name}
+	names := map[string]bool{}
+	for _, k := range sortedKeys(m) {
+		fieldName := avroName(k)
+		if names[fieldName] {
+			return nil, false
+		}
+		names[fieldName] = true
+
+		fs, ok := deriveType(name+"_"+fieldName, m[k])
+		if !ok {
+			return nil, false
+		}
+		s.fields = append(s.fields, optionalField(fieldName, k, fs))
+	}
+	return s, true
+}
+
+// deriveArray requires all elements to be of the same type. Arrays of records
+// are not supported, as the element records might differ in fields.
+func deriveArray(name string, rv reflect.Value) (*schema, bool) {
+	if rv.Len() == 0 {
+		return nil, false
+	}
+
+	var items *schema
+	var itemsJSON string
+	for i := 0; i < rv.Len(); i++ {
+		s, ok := deriveType(name+"_item", rv.Index(i).Interface())
+		if !ok || s.typ == "record" {
+			return nil, false
+		}
+
+		j := s.String()
+		if items == nil {
+			items, itemsJSON = s, j
+		} else if j != itemsJSON {
+			return nil, false
+		}
+	}
+	return &schema{typ: "array", items: items}, true
+}
+
+// avroName converts a field name into a valid Avro name, by replacing
+// characters not allowed with underscores.
+func avroName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' ||
+			('a' <= c && c <= 'z') ||
+			('A' <= c && c <= 'Z') ||
+			(i > 0 && '0' <= c && c <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// String returns the JSON representation of the schema.
+func (s *schema) String() string {
+	b, _ := json.Marshal(s.jsonValue(map[string]bool{}))
+	return string(b)
+}
+
+func (s *schema) jsonValue(defined map[string]bool) interface{} {
+	if s.name != "" {
+		if defined[s.name] {
+			return s.name
+		}
+		defined[s.name] = true
+	}
+
+	switch s.typ {
+	case "union":
+		branches := make([]interface{}, len(s.branches))
+		for i, b := range s.branches {
+			branches[i] = b.jsonValue(defined)
+		}
+		return branches
+
+	case "record":
+		fields := make([]interface{}, len(s.fields))
+		for i, f := range s.fields {
+			def := map[string]interface{}{
+				"name": f.name,
+				"type": f.schema.jsonValue(defined),
+			}
+			if f.hasDefault {
+				def["default"] = f.def
+			}
+			fields[i] = def
+		}
+		return map[string]interface{}{"type": "record", "name": s.name, "fields": fields}
+
+	case "enum":
+		return map[string]interface{}{"type": "enum", "name": s.name, "symbols": s.symbols}
+
+	case "fixed":
+		return map[string]interface{}{"type": "fixed", "name": s.name, "size": s.size}
+
+	case "array":
+		return map[string]interface{}{"type": "array", "items": s.items.jsonValue(defined)}
+
+	case "map":
+		return map[string]interface{}{"type": "map", "values": s.items.jsonValue(defined)}
+	}
+
+	if s.logicalType != "" {
+		return map[string]interface{}{"type": s.typ, "logicalType": s.logicalType}
+	}
+	return s.typ
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
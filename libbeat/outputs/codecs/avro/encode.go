@@ -0,0 +1,344 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// encoder implements the Avro binary encoding.
+//
+// See: https://avro.apache.org/docs/1.8.1/spec.html#binary_encoding
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) writeLong(v int64) {
+	// zig-zag encoding followed by variable length encoding
+	u := uint64((v << 1) ^ (v >> 63))
+	for u >= 0x80 {
+		e.buf = append(e.buf, byte(u)|0x80)
+		u >>= 7
+	}
+	e.buf = append(e.buf, byte(u))
+}
+
+func (e *encoder) writeFloat(v float32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) writeDouble(v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *encoder) writeBytes(b []byte) {
+	e.writeLong(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) writeString(s string) {
+	e.writeLong(int64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) encode(s *schema, v interface{}) error {
+	switch s.typ {
+	case "null":
+		if v != nil {
+			return fmt.Errorf("expected null, got %T", v)
+		}
+		return nil
+
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expected boolean, got %T", v)
+		}
+		if b {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+		return nil
+
+	case "int", "long":
+		i, ok := toLong(v, s.logicalType)
+		if !ok {
+			return fmt.Errorf("expected %v, got %T", s.typ, v)
+		}
+		if s.typ == "int" && (i < math.MinInt32 || i > math.MaxInt32) {
+			return fmt.Errorf("value %v overflows avro int", i)
+		}
+		e.writeLong(i)
+		return nil
+
+	case "float", "double":
+		f, ok := toDouble(v)
+		if !ok {
+			return fmt.Errorf("expected %v, got %T", s.typ, v)
+		}
+		if s.typ == "float" {
+			e.writeFloat(float32(f))
+		} else {
+			e.writeDouble(f)
+		}
+		return nil
+
+	case "string":
+		str, ok := toString(v)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", v)
+		}
+		e.writeString(str)
+		return nil
+
+	case "bytes":
+		switch b := v.(type) {
+		case []byte:
+			e.writeBytes(b)
+		case string:
+			e.writeString(b)
+		default:
+			return fmt.Errorf("expected bytes, got %T", v)
+		}
+		return nil
+
+	case "fixed":
+		b, ok := v.([]byte)
+		if !ok || len(b) != s.size {
+			return fmt.Errorf("expected fixed of size %v, got %T", s.size, v)
+		}
+		e.buf = append(e.buf, b...)
+		return nil
+
+	case "enum":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected enum symbol, got %T", v)
+		}
+		for i, sym := range s.symbols {
+			if sym == str {
+				e.writeLong(int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("'%v' is no symbol of enum %v", str, s.name)
+
+	case "union":
+		return e.encodeUnion(s, v)
+
+	case "record":
+		m, ok := toMap(v)
+		if !ok {
+			return fmt.Errorf("expected record %v, got %T", s.name, v)
+		}
+		return e.encodeRecord(s, m)
+
+	case "array":
+		return e.encodeArray(s, v)
+
+	case "map":
+		return e.encodeMap(s, v)
+	}
+
+	return fmt.Errorf("unsupported avro type '%v'", s.typ)
+}
+
+func (e *encoder) encodeRecord(s *schema, m map[string]interface{}) error {
+	for i := range s.fields {
+		f := &s.fields[i]
+		v, exists := m[f.key]
+		if !exists && f.hasDefault {
+			v = f.def
+		}
+		if err := e.encode(f.schema, v); err != nil {
+			return fmt.Errorf("field '%v': %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+// encodeUnion writes the index of the first branch accepting the value,
+// followed by the value.
+func (e *encoder) encodeUnion(s *schema, v interface{}) error {
+	for i, b := range s.branches {
+		if !matches(b, v) {
+			continue
+		}
+
+		e.writeLong(int64(i))
+		return e.encode(b, v)
+	}
+	return fmt.Errorf("no matching union branch for %T", v)
+}
+
+func (e *encoder) encodeArray(s *schema, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if v == nil || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+		return fmt.Errorf("expected array, got %T", v)
+	}
+
+	if n := rv.Len(); n > 0 {
+		e.writeLong(int64(n))
+		for i := 0; i < n; i++ {
+			if err := e.encode(s.items, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	e.writeLong(0)
+	return nil
+}
+
+func (e *encoder) encodeMap(s *schema, v interface{}) error {
+	m, ok := toMap(v)
+	if !ok {
+		return fmt.Errorf("expected map, got %T", v)
+	}
+
+	if len(m) > 0 {
+		e.writeLong(int64(len(m)))
+		for _, k := range sortedKeys(m) {
+			e.writeString(k)
+			if err := e.encode(s.items, m[k]); err != nil {
+				return fmt.Errorf("key '%v': %v", k, err)
+			}
+		}
+	}
+	e.writeLong(0)
+	return nil
+}
+
+// matches reports if a value can be encoded using the given schema. It is
+// used to select the union branch.
+func matches(s *schema, v interface{}) bool {
+	switch s.typ {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "int", "long":
+		_, ok := toLong(v, s.logicalType)
+		return ok
+	case "float", "double":
+		_, ok := toDouble(v)
+		return ok
+	case "string":
+		_, ok := toString(v)
+		return ok
+	case "bytes":
+		switch v.(type) {
+		case []byte, string:
+			return true
+		}
+		return false
+	case "fixed":
+		b, ok := v.([]byte)
+		return ok && len(b) == s.size
+	case "enum":
+		str, ok := v.(string)
+		if !ok {
+			return false
+		}
+		for _, sym := range s.symbols {
+			if sym == str {
+				return true
+			}
+		}
+		return false
+	case "record", "map":
+		_, ok := toMap(v)
+		return ok
+	case "array":
+		if v == nil {
+			return false
+		}
+		k := reflect.TypeOf(v).Kind()
+		return k == reflect.Slice || k == reflect.Array
+	}
+	return false
+}
+
+func toLong(v interface{}, logicalType string) (int64, bool) {
+	switch i := v.(type) {
+	case int:
+		return int64(i), true
+	case int8:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	case uint:
+		return int64(i), uint64(i) <= math.MaxInt64
+	case uint8:
+		return int64(i), true
+	case uint16:
+		return int64(i), true
+	case uint32:
+		return int64(i), true
+	case uint64:
+		return int64(i), i <= math.MaxInt64
+	case common.Time:
+		return timestamp(time.Time(i), logicalType)
+	case time.Time:
+		return timestamp(i, logicalType)
+	}
+	return 0, false
+}
+
+func timestamp(t time.Time, logicalType string) (int64, bool) {
+	switch logicalType {
+	case "timestamp-millis":
+		return t.UnixNano() / int64(time.Millisecond), true
+	case "timestamp-micros":
+		return t.UnixNano() / int64(time.Microsecond), true
+	}
+	return 0, false
+}
+
+func toDouble(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float32:
+		return float64(f), true
+	case float64:
+		return f, true
+	}
+	if i, ok := toLong(v, ""); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+func toString(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case common.Time:
+		return time.Time(s).UTC().Format(common.TsLayout), true
+	case time.Time:
+		return s.UTC().Format(common.TsLayout), true
+	}
+	return "", false
+}
+
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case common.MapStr:
+		return m, true
+	case map[string]interface{}:
+		return m, true
+	}
+	return nil, false
+}
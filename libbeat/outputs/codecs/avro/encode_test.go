@@ -0,0 +1,155 @@
+// +build !integration
+
+package avro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestWriteLong(t *testing.T) {
+	tests := []struct {
+		value    int64
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0x01}},
+		{1, []byte{0x02}},
+		{-64, []byte{0x7f}},
+		{64, []byte{0x80, 0x01}},
+		{-8193, []byte{0x81, 0x80, 0x01}},
+	}
+
+	for _, test := range tests {
+		enc := encoder{}
+		enc.writeLong(test.value)
+		assert.Equal(t, test.expected, enc.buf, "value %v", test.value)
+	}
+}
+
+func TestEncodeTypes(t *testing.T) {
+	ts := time.Unix(1, 500*int64(time.Millisecond))
+
+	tests := []struct {
+		schema   string
+		value    interface{}
+		expected []byte
+	}{
+		{`"null"`, nil, nil},
+		{`"boolean"`, true, []byte{1}},
+		{`"int"`, int32(3), []byte{6}},
+		{`"long"`, uint8(3), []byte{6}},
+		{`"float"`, 1.0, []byte{0, 0, 0x80, 0x3f}},
+		{`"double"`, 1, []byte{0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		{`"string"`, "foo", []byte{6, 'f', 'o', 'o'}},
+		{`"bytes"`, []byte{1, 2}, []byte{4, 1, 2}},
+		{`{"type":"fixed","name":"f","size":2}`, []byte{1, 2}, []byte{1, 2}},
+		{`{"type":"enum","name":"e","symbols":["A","B"]}`, "B", []byte{2}},
+		{`{"type":"long","logicalType":"timestamp-millis"}`, common.Time(ts), []byte{0xb8, 0x17}},
+		{`["null","string"]`, nil, []byte{0}},
+		{`["null","string"]`, "a", []byte{2, 2, 'a'}},
+		{`{"type":"array","items":"long"}`, []int{1, 2}, []byte{4, 2, 4, 0}},
+		{`{"type":"array","items":"long"}`, []interface{}{}, []byte{0}},
+		{`{"type":"map","values":"long"}`, common.MapStr{"b": 2, "a": 1}, []byte{4, 2, 'a', 2, 2, 'b', 4, 0}},
+		{
+			`{"type":"record","name":"r","fields":[{"name":"a","type":"long"},{"name":"b","type":"string","default":"x"}]}`,
+			map[string]interface{}{"a": 1},
+			[]byte{2, 2, 'x'},
+		},
+	}
+
+	for _, test := range tests {
+		s, err := parseSchema(test.schema)
+		if err != nil {
+			t.Fatalf("failed to parse %v: %v", test.schema, err)
+		}
+
+		enc := encoder{}
+		err = enc.encode(s, test.value)
+		if assert.NoError(t, err, "schema %v", test.schema) {
+			assert.Equal(t, test.expected, enc.buf, "schema %v", test.schema)
+		}
+	}
+}
+
+func TestEncodeTypeMismatch(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  interface{}
+	}{
+		{`"long"`, "1"},
+		{`"int"`, int64(1) << 40},
+		{`"string"`, 1},
+		{`{"type":"enum","name":"e","symbols":["A","B"]}`, "C"},
+		{`["null","long"]`, "a"},
+		{`{"type":"record","name":"r","fields":[{"name":"a","type":"long"}]}`, common.MapStr{}},
+	}
+
+	for _, test := range tests {
+		s, err := parseSchema(test.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		enc := encoder{}
+		assert.Error(t, enc.encode(s, test.value), "schema %v", test.schema)
+	}
+}
+
+func TestParseSchemaNamedTypes(t *testing.T) {
+	s, err := parseSchema(`{
+		"type": "record",
+		"name": "node",
+		"namespace": "test",
+		"fields": [
+			{"name": "value", "type": "long"},
+			{"name": "next", "type": ["null", "node"]},
+			{"name": "other", "type": ["null", "test.node"]}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "test.node", s.name)
+	next, _ := s.field("next")
+	assert.Equal(t, s, next.schema.branches[1])
+	other, _ := s.field("other")
+	assert.Equal(t, s, other.schema.branches[1])
+
+	_, err = parseSchema(`{"type":"record","name":"r","fields":[{"name":"a","type":"unknown"}]}`)
+	assert.Error(t, err)
+}
+
+func TestDeriveSchema(t *testing.T) {
+	event := common.MapStr{
+		"a.b":   "x",
+		"a_b":   "y",
+		"tags":  []string{"a", "b"},
+		"mixed": []interface{}{"a", 1},
+		"empty": []string{},
+		"nested": common.MapStr{
+			"nil": nil,
+		},
+		"flag": false,
+	}
+
+	s := deriveSchema("event", event, "")
+	var names []string
+	for _, f := range s.fields {
+		names = append(names, f.name)
+	}
+	assert.Equal(t, []string{"a_b", "flag", "tags"}, names)
+
+	f, _ := s.field("a_b")
+	assert.Equal(t, "a.b", f.key)
+	f, _ = s.field("tags")
+	assert.Equal(t, `["null",{"items":"string","type":"array"}]`, f.schema.String())
+
+	enc := encoder{}
+	assert.NoError(t, enc.encodeRecord(s, event))
+}
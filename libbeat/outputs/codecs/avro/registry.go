@@ -0,0 +1,173 @@
+package avro
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// maxCachedSchemas bounds the number of schema IDs cached. Schemas derived
+// from the events can vary a lot, so the least recently used IDs are evicted.
+const maxCachedSchemas = 1024
+
+// registry registers schemas with a Confluent compatible schema registry. The
+// schema IDs returned are cached per schema, so every schema is registered
+// only once, unless it was evicted from the cache.
+type registry struct {
+	url      string
+	subject  string
+	username string
+	password string
+	http     *http.Client
+
+	mutex sync.Mutex
+	ids   map[string]*list.Element
+
+	// cached schemas, most recently used first
+	lru *list.List
+}
+
+type cachedID struct {
+	schema string
+	id     int32
+}
+
+// unavailableError is returned if the schema registry can't be reached or
+// fails. Events failing with it can be retried later.
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string   { return e.err.Error() }
+func (e *unavailableError) Temporary() bool { return true }
+
+type registerRequest struct {
+	Schema string `json:"schema"`
+}
+
+type registerResponse struct {
+	ID int32 `json:"id"`
+}
+
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func newRegistry(
+	registryURL, subject string,
+	username, password string,
+	client *http.Client,
+) *registry {
+	return &registry{
+		url:      strings.TrimRight(registryURL, "/"),
+		subject:  subject,
+		username: username,
+		password: password,
+		http:     client,
+		ids:      map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// schemaID returns the ID of the schema, registering the schema with the
+// registry if it is not cached. Registering a schema already known to the
+// registry returns the existing ID. The registry is not locked while
+// registering, so a schema might be registered concurrently, which is
+// harmless.
+func (r *registry) schemaID(schema string) (int32, error) {
+	if id, exists := r.cachedID(schema); exists {
+		return id, nil
+	}
+
+	id, err := r.register(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	debugf("Registered avro schema with ID %v for subject '%v'", id, r.subject)
+	r.cacheID(schema, id)
+	return id, nil
+}
+
+func (r *registry) cachedID(schema string) (int32, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	elem, exists := r.ids[schema]
+	if !exists {
+		return 0, false
+	}
+	r.lru.MoveToFront(elem)
+	return elem.Value.(*cachedID).id, true
+}
+
+func (r *registry) cacheID(schema string, id int32) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.ids[schema]; exists {
+		return
+	}
+	r.ids[schema] = r.lru.PushFront(&cachedID{schema: schema, id: id})
+	for r.lru.Len() > maxCachedSchemas {
+		last := r.lru.Remove(r.lru.Back()).(*cachedID)
+		delete(r.ids, last.schema)
+	}
+}
+
+func (r *registry) register(schema string) (int32, error) {
+	body, err := json.Marshal(registerRequest{Schema: schema})
+	if err != nil {
+		return 0, err
+	}
+
+	subject := (&url.URL{Path: r.subject}).EscapedPath()
+	path := fmt.Sprintf("%v/subjects/%v/versions", r.url, subject)
+	req, err := http.NewRequest("POST", path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", registryContentType)
+	req.Header.Set("Accept", registryContentType)
+	if r.username != "" || r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, &unavailableError{fmt.Errorf("failed to register avro schema: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &unavailableError{fmt.Errorf("failed to read schema registry response: %v", err)}
+	}
+
+	if resp.StatusCode >= 500 {
+		return 0, &unavailableError{fmt.Errorf("schema registry failed: %v", resp.Status)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		var regErr registryError
+		if json.Unmarshal(content, &regErr) == nil && regErr.Message != "" {
+			return 0, fmt.Errorf("schema registry rejected avro schema (%v): %v",
+				regErr.ErrorCode, regErr.Message)
+		}
+		return 0, fmt.Errorf("schema registry rejected avro schema: %v", resp.Status)
+	}
+
+	var result registerResponse
+	if err := json.Unmarshal(content, &result); err != nil {
+		return 0, fmt.Errorf("invalid schema registry response: %v", err)
+	}
+	return result.ID, nil
+}
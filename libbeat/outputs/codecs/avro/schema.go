@@ -0,0 +1,291 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// schema is the parsed representation of an Avro schema, as required for
+// encoding events.
+type schema struct {
+	typ  string
+	name string // full name of named types (record, enum, fixed)
+
+	// logicalType annotation, e.g. `timestamp-millis` for longs
+	logicalType string
+
+	fields   []field   // record
+	symbols  []string  // enum
+	items    *schema   // array items or map values
+	size     int       // fixed
+	branches []*schema // union
+}
+
+type field struct {
+	name       string
+	key        string // event key the field is read from
+	schema     *schema
+	hasDefault bool
+	def        interface{}
+}
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// parseSchema parses an Avro schema in its JSON representation.
+func parseSchema(text string) (*schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %v", err)
+	}
+
+	p := schemaParser{names: map[string]*schema{}}
+	return p.parse(raw, "")
+}
+
+type schemaParser struct {
+	names map[string]*schema
+}
+
+func (p *schemaParser) parse(raw interface{}, namespace string) (*schema, error) {
+	switch v := raw.(type) {
+	case string:
+		return p.lookup(v, namespace)
+	case []interface{}:
+		return p.parseUnion(v, namespace)
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid avro schema definition: %v", raw)
+}
+
+func (p *schemaParser) lookup(name, namespace string) (*schema, error) {
+	if primitiveTypes[name] {
+		return &schema{typ: name}, nil
+	}
+
+	if s, exists := p.names[fullName(name, namespace)]; exists {
+		return s, nil
+	}
+	if s, exists := p.names[name]; exists {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown avro type '%v'", name)
+}
+
+func (p *schemaParser) parseUnion(raw []interface{}, namespace string) (*schema, error) {
+	s := &schema{typ: "union"}
+	for _, branch := range raw {
+		b, err := p.parse(branch, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if b.typ == "union" {
+			return nil, errors.New("avro unions must not contain unions")
+		}
+		s.branches = append(s.branches, b)
+	}
+	if len(s.branches) == 0 {
+		return nil, errors.New("empty avro union")
+	}
+	return s, nil
+}
+
+func (p *schemaParser) parseComplex(raw map[string]interface{}, namespace string) (*schema, error) {
+	typ, ok := raw["type"].(string)
+	if !ok {
+		// type can be a nested schema definition
+		if nested, exists := raw["type"]; exists {
+			return p.parse(nested, namespace)
+		}
+		return nil, fmt.Errorf("missing type in avro schema: %v", raw)
+	}
+
+	logicalType, _ := raw["logicalType"].(string)
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		return p.parseNamed(typ, raw, namespace)
+
+	case "array":
+		items, err := p.parse(raw["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: typ, items: items, logicalType: logicalType}, nil
+
+	case "map":
+		values, err := p.parse(raw["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: typ, items: values, logicalType: logicalType}, nil
+	}
+
+	s, err := p.lookup(typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if logicalType != "" && primitiveTypes[typ] {
+		s = &schema{typ: typ, logicalType: logicalType}
+	}
+	return s, nil
+}
+
+func (p *schemaParser) parseNamed(
+	typ string,
+	raw map[string]interface{},
+	namespace string,
+) (*schema, error) {
+	name, _ := raw["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("missing name in avro %v schema", typ)
+	}
+	if ns, ok := raw["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	name = fullName(name, namespace)
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		namespace = name[:idx]
+	}
+
+	if _, exists := p.names[name]; exists {
+		return nil, fmt.Errorf("avro type '%v' defined multiple times", name)
+	}
+
+	logicalType, _ := raw["logicalType"].(string)
+	s := &schema{typ: typ, name: name, logicalType: logicalType}
+	if typ == "error" {
+		s.typ = "record"
+	}
+
+	// register before parsing the fields, so records can be recursive
+	p.names[name] = s
+
+	switch typ {
+	case "record", "error":
+		rawFields, ok := raw["fields"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("missing fields in avro record '%v'", name)
+		}
+		for _, rf := range rawFields {
+			f, ok := rf.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid field in avro record '%v'", name)
+			}
+			fieldName, _ := f["name"].(string)
+			if fieldName == "" {
+				return nil, fmt.Errorf("missing field name in avro record '%v'", name)
+			}
+			fs, err := p.parse(f["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field '%v': %v", fieldName, err)
+			}
+			def, hasDefault := f["default"]
+			s.fields = append(s.fields, field{
+				name:       fieldName,
+				key:        fieldName,
+				schema:     fs,
+				hasDefault: hasDefault,
+				def:        normalizeDefault(fs, def),
+			})
+		}
+
+	case "enum":
+		symbols, ok := raw["symbols"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("missing symbols in avro enum '%v'", name)
+		}
+		for _, sym := range symbols {
+			str, ok := sym.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid symbol in avro enum '%v'", name)
+			}
+			s.symbols = append(s.symbols, str)
+		}
+
+	case "fixed":
+		size, ok := raw["size"].(float64)
+		if !ok || size < 0 {
+			return nil, fmt.Errorf("invalid size in avro fixed '%v'", name)
+		}
+		s.size = int(size)
+	}
+
+	return s, nil
+}
+
+// normalizeDefault converts numeric defaults, which are parsed as float64
+// from JSON, to integers for int and long schemas. Defaults of unions apply to
+// the first branch.
+func normalizeDefault(s *schema, def interface{}) interface{} {
+	f, ok := def.(float64)
+	if !ok {
+		return def
+	}
+	if s.typ == "union" {
+		s = s.branches[0]
+	}
+	if s.typ == "int" || s.typ == "long" {
+		return int64(f)
+	}
+	return def
+}
+
+func fullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// field returns the record field with the given name.
+func (s *schema) field(name string) (*field, bool) {
+	for i := range s.fields {
+		if s.fields[i].name == name {
+			return &s.fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// nullable reports if the schema accepts null values.
+func (s *schema) nullable() bool {
+	if s.typ == "null" {
+		return true
+	}
+	if s.typ == "union" {
+		for _, b := range s.branches {
+			if b.typ == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptsString reports if the schema accepts string values, e.g. for use as
+// fallback field.
+func (s *schema) acceptsString() bool {
+	if s.typ == "string" {
+		return true
+	}
+	if s.typ == "union" {
+		for _, b := range s.branches {
+			if b.typ == "string" {
+				return true
+			}
+		}
+	}
+	return false
+}
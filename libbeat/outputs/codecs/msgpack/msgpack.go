@@ -0,0 +1,82 @@
+package msgpack
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+)
+
+// Encoder encodes events using MessagePack. Timestamps are encoded as strings
+// in the same format used by the json codec.
+type Encoder struct {
+	handle *codec.MsgpackHandle
+}
+
+type timeExt struct{}
+
+func init() {
+	outputs.RegisterOutputCodec("msgpack", func(cfg *common.Config) (outputs.Codec, error) {
+		return New(), nil
+	})
+}
+
+func New() *Encoder {
+	h := &codec.MsgpackHandle{}
+	h.Canonical = true
+
+	// Without WriteExt the extension bytes are written as raw string, such
+	// that timestamps can be read without knowledge of the extension type.
+	h.WriteExt = false
+	h.SetBytesExt(reflect.TypeOf(common.Time{}), 1, timeExt{})
+	h.SetBytesExt(reflect.TypeOf(time.Time{}), 2, timeExt{})
+
+	return &Encoder{handle: h}
+}
+
+func (e *Encoder) Encode(event common.MapStr) ([]byte, error) {
+	var serializedEvent []byte
+
+	enc := codec.NewEncoderBytes(&serializedEvent, e.handle)
+	err := enc.Encode(event)
+	if err != nil {
+		logp.Err("Fail to convert the event to msgpack (%v): %#v", err, event)
+	}
+
+	return serializedEvent, err
+}
+
+func (timeExt) WriteExt(v interface{}) []byte {
+	var ts time.Time
+	switch t := v.(type) {
+	case common.Time:
+		ts = time.Time(t)
+	case *common.Time:
+		ts = time.Time(*t)
+	case time.Time:
+		ts = t
+	case *time.Time:
+		ts = *t
+	default:
+		return nil
+	}
+	return []byte(ts.UTC().Format(common.TsLayout))
+}
+
+func (timeExt) ReadExt(dst interface{}, src []byte) {
+	ts, err := time.Parse(common.TsLayout, string(src))
+	if err != nil {
+		return
+	}
+
+	switch t := dst.(type) {
+	case *common.Time:
+		*t = common.Time(ts)
+	case *time.Time:
+		*t = ts
+	}
+}
@@ -0,0 +1,56 @@
+// +build !integration
+
+package msgpack
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestMsgpackCodec(t *testing.T) {
+	ts := time.Date(2017, 5, 1, 10, 30, 0, 0, time.UTC)
+	event := common.MapStr{
+		"@timestamp": common.Time(ts),
+		"message":    "hello",
+		"count":      3,
+		"beat":       common.MapStr{"name": "test"},
+		"tags":       []string{"a", "b"},
+	}
+
+	output, err := New().Encode(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	h := &codec.MsgpackHandle{}
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	if err := codec.NewDecoderBytes(output, h).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "2017-05-01T10:30:00.000Z", decoded["@timestamp"])
+	assert.Equal(t, "hello", decoded["message"])
+	assert.Equal(t, int64(3), decoded["count"])
+	assert.Equal(t, map[string]interface{}{"name": "test"}, decoded["beat"])
+	assert.Equal(t, []interface{}{"a", "b"}, decoded["tags"])
+}
+
+func TestMsgpackCodecCanonical(t *testing.T) {
+	event := common.MapStr{"b": 1, "a": 2, "c": common.MapStr{"z": 1, "y": 2}}
+
+	first, err := New().Encode(event)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		output, err := New().Encode(event.Clone())
+		assert.NoError(t, err)
+		assert.Equal(t, first, output)
+	}
+}
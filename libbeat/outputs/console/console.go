@@ -14,6 +14,7 @@ import (
 
 func init() {
 	outputs.RegisterOutputPlugin("console", New)
+	outputs.RegisterOutputChecker("console", checkConfig)
 }
 
 type console struct {
@@ -53,6 +54,20 @@ func New(_ string, config *common.Config, _ int) (outputs.Outputer, error) {
 	return c, nil
 }
 
+// checkConfig validates the output settings.
+func checkConfig(_ string, config *common.Config) error {
+	var unpackedConfig Config
+	if err := config.Unpack(&unpackedConfig); err != nil {
+		return err
+	}
+
+	if unpackedConfig.Codec.Namespace.IsSet() {
+		_, err := outputs.CreateEncoder(unpackedConfig.Codec)
+		return err
+	}
+	return nil
+}
+
 func newConsole(codec outputs.Codec) (*console, error) {
 	return &console{codec: codec, out: os.Stdout}, nil
 }
@@ -24,10 +24,12 @@ type Client struct {
 	Connection
 	tlsConfig *transport.TLSConfig
 
-	index    outil.Selector
-	pipeline *outil.Selector
-	params   map[string]string
-	timeout  time.Duration
+	index      outil.Selector
+	pipeline   *outil.Selector
+	documentID string
+	opType     string
+	params     map[string]string
+	timeout    time.Duration
 
 	// buffered bulk requests
 	bulkRequ *bulkRequest
@@ -49,6 +51,8 @@ type ClientSettings struct {
 	Headers            map[string]string
 	Index              outil.Selector
 	Pipeline           *outil.Selector
+	DocumentID         string
+	OpType             string
 	Timeout            time.Duration
 	CompressionLevel   int
 }
@@ -78,6 +82,8 @@ var (
 	statWriteBytes  = expvar.NewInt("libbeat.es.publish.write_bytes")
 	statReadErrors  = expvar.NewInt("libbeat.es.publish.read_errors")
 	statWriteErrors = expvar.NewInt("libbeat.es.publish.write_errors")
+
+	statDuplicateEvents = expvar.NewInt("libbeat.es.publish.duplicate_events")
 )
 
 var (
@@ -173,11 +179,13 @@ func NewClient(
 			},
 			encoder: encoder,
 		},
-		tlsConfig: s.TLS,
-		index:     s.Index,
-		pipeline:  pipeline,
-		params:    params,
-		timeout:   s.Timeout,
+		tlsConfig:  s.TLS,
+		index:      s.Index,
+		pipeline:   pipeline,
+		documentID: s.DocumentID,
+		opType:     s.OpType,
+		params:     params,
+		timeout:    s.Timeout,
 
 		bulkRequ: bulkRequ,
 
@@ -206,6 +214,8 @@ func (client *Client) Clone() *Client {
 			URL:              client.URL,
 			Index:            client.index,
 			Pipeline:         client.pipeline,
+			DocumentID:       client.documentID,
+			OpType:           client.opType,
 			Proxy:            client.proxyURL,
 			TLS:              client.tlsConfig,
 			Username:         client.Username,
@@ -238,7 +248,8 @@ func (client *Client) PublishEvents(
 
 	// encode events into bulk request buffer, dropping failed elements from
 	// events slice
-	data = bulkEncodePublishRequest(body, client.index, client.pipeline, data)
+	data = bulkEncodePublishRequest(body, client.index, client.pipeline,
+		client.documentID, client.opType, data)
 	if len(data) == 0 {
 		return nil, nil
 	}
@@ -282,11 +293,13 @@ func bulkEncodePublishRequest(
 	body bulkWriter,
 	index outil.Selector,
 	pipeline *outil.Selector,
+	documentID string,
+	opType string,
 	data []outputs.Data,
 ) []outputs.Data {
 	okEvents := data[:0]
 	for _, datum := range data {
-		meta := createEventBulkMeta(index, pipeline, datum)
+		meta := createEventBulkMeta(index, pipeline, documentID, opType, datum)
 		if err := body.Add(meta, datum.Event); err != nil {
 			logp.Err("Failed to encode event: %s", err)
 			continue
@@ -296,9 +309,30 @@ func bulkEncodePublishRequest(
 	return okEvents
 }
 
+type bulkMetaIndex struct {
+	Index    string `json:"_index"`
+	DocType  string `json:"_type"`
+	ID       string `json:"_id,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+type bulkIndexMeta struct {
+	Index bulkMetaIndex `json:"index"`
+}
+
+type bulkCreateMeta struct {
+	Create bulkMetaIndex `json:"create"`
+}
+
+// createEventBulkMeta creates the bulk action and metadata line of an event.
+// If documentID is set, the document _id is read from the event field of the
+// same name. Using the `create` op type, indexing a document with an already
+// existing _id fails with a version conflict instead of overwriting it.
 func createEventBulkMeta(
 	index outil.Selector,
 	pipelineSel *outil.Selector,
+	documentID string,
+	opType string,
 	data outputs.Data,
 ) interface{} {
 	event := data.Event
@@ -308,39 +342,38 @@ func createEventBulkMeta(
 		logp.Err("Failed to select pipeline: %v", err)
 	}
 
-	if pipeline == "" {
-		type bulkMetaIndex struct {
-			Index   string `json:"_index"`
-			DocType string `json:"_type"`
-		}
-		type bulkMeta struct {
-			Index bulkMetaIndex `json:"index"`
-		}
+	meta := bulkMetaIndex{
+		Index:    getIndex(event, index),
+		DocType:  event["type"].(string),
+		ID:       getDocumentID(event, documentID),
+		Pipeline: pipeline,
+	}
 
-		return bulkMeta{
-			Index: bulkMetaIndex{
-				Index:   getIndex(event, index),
-				DocType: event["type"].(string),
-			},
-		}
+	if opType == opTypeCreate {
+		return bulkCreateMeta{Create: meta}
 	}
+	return bulkIndexMeta{Index: meta}
+}
 
-	type bulkMetaIndex struct {
-		Index    string `json:"_index"`
-		DocType  string `json:"_type"`
-		Pipeline string `json:"pipeline"`
+// getDocumentID reads the document _id from the event. Events missing the
+// field get an _id generated by Elasticsearch.
+func getDocumentID(event common.MapStr, field string) string {
+	if field == "" {
+		return ""
 	}
-	type bulkMeta struct {
-		Index bulkMetaIndex `json:"index"`
+
+	value, err := event.GetValue(field)
+	if err != nil {
+		debugf("Event has no document id field %v", field)
+		return ""
 	}
 
-	return bulkMeta{
-		Index: bulkMetaIndex{
-			Index:    getIndex(event, index),
-			Pipeline: pipeline,
-			DocType:  event["type"].(string),
-		},
+	id, ok := value.(string)
+	if !ok {
+		logp.Warn("Document id field %v is no string", field)
+		return ""
 	}
+	return id
 }
 
 func getPipeline(data outputs.Data, pipelineSel *outil.Selector) (string, error) {
@@ -437,6 +470,14 @@ func bulkCollectPublishFails(
 			continue // ok value
 		}
 
+		if status == 409 {
+			// document with same _id exists already, e.g. when retrying a
+			// bulk request using document ids and the create op type
+			statDuplicateEvents.Add(1)
+			debugf("Bulk item already indexed (i=%v): %s", i, msg)
+			continue
+		}
+
 		if status < 500 && status != 429 {
 			// hard failure, don't collect
 			logp.Warn("Can not index event (status=%v): %s", status, msg)
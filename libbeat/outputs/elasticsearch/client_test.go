@@ -3,6 +3,7 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outest"
 	"github.com/elastic/beats/libbeat/outputs/outil"
 	"github.com/stretchr/testify/assert"
 )
@@ -124,6 +126,57 @@ func TestCollectPublishFailAll(t *testing.T) {
 	assert.Equal(t, events, res)
 }
 
+func TestCollectPublishFailDuplicates(t *testing.T) {
+	response := []byte(`
+    { "items": [
+      {"create": {"status": 201}},
+      {"create": {"status": 409, "error": "version_conflict_engine_exception"}},
+      {"create": {"status": 429, "error": "ups"}}
+    ]}
+  `)
+
+	event := outputs.Data{Event: common.MapStr{"field": 1}}
+	eventFail := outputs.Data{Event: common.MapStr{"field": 2}}
+	events := []outputs.Data{event, event, eventFail}
+
+	before := statDuplicateEvents.Value()
+	reader := newJSONReader(response)
+	res := bulkCollectPublishFails(reader, events)
+	assert.Equal(t, []outputs.Data{eventFail}, res)
+	assert.Equal(t, before+1, statDuplicateEvents.Value())
+}
+
+func TestCreateEventBulkMeta(t *testing.T) {
+	ts := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	index := outil.MakeSelector(outil.ConstSelectorExpr("test"))
+	pipeline := outil.MakeSelector(outil.ConstSelectorExpr("pipe"))
+	data := outputs.Data{Event: common.MapStr{
+		"@timestamp":  common.Time(ts),
+		"type":        "log",
+		"fingerprint": "abc",
+	}}
+
+	tests := []struct {
+		pipeline   *outil.Selector
+		documentID string
+		opType     string
+		expected   string
+	}{
+		{nil, "", opTypeIndex, `{"index":{"_index":"test","_type":"log"}}`},
+		{&pipeline, "", opTypeIndex, `{"index":{"_index":"test","_type":"log","pipeline":"pipe"}}`},
+		{nil, "fingerprint", opTypeIndex, `{"index":{"_index":"test","_type":"log","_id":"abc"}}`},
+		{nil, "fingerprint", opTypeCreate, `{"create":{"_index":"test","_type":"log","_id":"abc"}}`},
+		{nil, "missing", opTypeCreate, `{"create":{"_index":"test","_type":"log"}}`},
+	}
+
+	for _, test := range tests {
+		meta := createEventBulkMeta(index, test.pipeline, test.documentID, test.opType, data)
+		encoded, err := json.Marshal(meta)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, string(encoded))
+	}
+}
+
 func TestCollectPipelinePublishFail(t *testing.T) {
 	if testing.Verbose() {
 		logp.LogInit(logp.LOG_DEBUG, "", false, true, []string{"elasticsearch"})
@@ -308,3 +361,21 @@ func TestClientWithHeaders(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, requestCount)
 }
+
+func TestOutputTest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"version": {"number": "5.4.0"}}`)
+	}))
+	defer ts.Close()
+
+	d, out := outest.RunTest(t, "elasticsearch", map[string]interface{}{
+		"hosts":            []string{ts.URL},
+		"template.enabled": false,
+	})
+	assert.False(t, d.Failed(), out)
+	assert.Contains(t, out, "elasticsearch: "+ts.URL+"...")
+	assert.Contains(t, out, "dial up... OK")
+	assert.Contains(t, out, "TLS... WARN secure connection disabled")
+	assert.Contains(t, out, "talk to server... OK")
+	assert.Contains(t, out, "version: 5.4.0")
+}
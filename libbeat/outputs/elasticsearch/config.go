@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/elastic/beats/libbeat/outputs"
@@ -21,6 +22,8 @@ type elasticsearchConfig struct {
 	Timeout          time.Duration      `config:"timeout"`
 	SaveTopology     bool               `config:"save_topology"`
 	Template         Template           `config:"template"`
+	DocumentID       string             `config:"document_id"`
+	OpType           string             `config:"op_type"`
 }
 
 type Template struct {
@@ -45,6 +48,12 @@ const (
 	defaultBulkSize = 50
 )
 
+// Bulk API op types supported for indexing events.
+const (
+	opTypeIndex  = "index"
+	opTypeCreate = "create"
+)
+
 var (
 	defaultConfig = elasticsearchConfig{
 		Protocol:         "",
@@ -58,6 +67,7 @@ var (
 		CompressionLevel: 0,
 		TLS:              nil,
 		LoadBalance:      true,
+		OpType:           opTypeIndex,
 		Template: Template{
 			Enabled: true,
 			Versions: TemplateVersions{
@@ -75,5 +85,12 @@ func (c *elasticsearchConfig) Validate() error {
 		}
 	}
 
+	switch c.OpType {
+	case opTypeIndex, opTypeCreate:
+	default:
+		return fmt.Errorf("op_type %v not supported, use %v or %v",
+			c.OpType, opTypeIndex, opTypeCreate)
+	}
+
 	return nil
 }
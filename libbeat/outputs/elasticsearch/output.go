@@ -37,6 +37,8 @@ type elasticsearchOutput struct {
 
 func init() {
 	outputs.RegisterOutputPlugin("elasticsearch", New)
+	outputs.RegisterOutputChecker("elasticsearch", checkConfig)
+	outputs.RegisterOutputTester("elasticsearch", newTester)
 }
 
 var (
@@ -56,6 +58,27 @@ var (
 
 // New instantiates a new output plugin instance publishing to elasticsearch.
 func New(beatName string, cfg *common.Config, topologyExpire int) (outputs.Outputer, error) {
+	setDefaults(beatName, cfg)
+
+	output := &elasticsearchOutput{beatName: beatName}
+	err := output.init(cfg, topologyExpire)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// checkConfig validates the output settings and reads the templates, without
+// connecting to Elasticsearch.
+func checkConfig(beatName string, cfg *common.Config) error {
+	setDefaults(beatName, cfg)
+
+	output := &elasticsearchOutput{beatName: beatName}
+	_, _, err := output.configure(cfg)
+	return err
+}
+
+func setDefaults(beatName string, cfg *common.Config) {
 	if !cfg.HasField("bulk_max_size") {
 		cfg.SetInt("bulk_max_size", -1, defaultBulkSize)
 	}
@@ -64,13 +87,6 @@ func New(beatName string, cfg *common.Config, topologyExpire int) (outputs.Outpu
 		pattern := fmt.Sprintf("%v-%%{+yyyy.MM.dd}", beatName)
 		cfg.SetString("index", -1, pattern)
 	}
-
-	output := &elasticsearchOutput{beatName: beatName}
-	err := output.init(cfg, topologyExpire)
-	if err != nil {
-		return nil, err
-	}
-	return output, nil
 }
 
 // NewConnectedClient creates a new Elasticsearch client based on the given config.
@@ -164,9 +180,46 @@ func (out *elasticsearchOutput) init(
 	cfg *common.Config,
 	topologyExpire int,
 ) error {
+	config, clients, err := out.configure(cfg)
+	if err != nil {
+		return err
+	}
+
+	maxRetries := config.MaxRetries
+	maxAttempts := maxRetries + 1 // maximum number of send attempts (-1 = infinite)
+	if maxRetries < 0 {
+		maxAttempts = 0
+	}
+
+	var waitRetry = time.Duration(1) * time.Second
+	var maxWaitRetry = time.Duration(60) * time.Second
+
+	out.clients = clients
+	loadBalance := config.LoadBalance
+	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
+		Failover:     !loadBalance,
+		MaxAttempts:  maxAttempts,
+		Timeout:      config.Timeout,
+		WaitRetry:    waitRetry,
+		MaxWaitRetry: maxWaitRetry,
+	})
+	if err != nil {
+		return err
+	}
+
+	out.mode = m
+
+	return nil
+}
+
+// configure reads the settings and templates and creates the clients, but
+// does not connect them.
+func (out *elasticsearchOutput) configure(
+	cfg *common.Config,
+) (*elasticsearchConfig, []mode.ProtocolClient, error) {
 	config := defaultConfig
 	if err := cfg.Unpack(&config); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	index, err := outil.BuildSelectorFromConfig(cfg, outil.Settings{
@@ -176,17 +229,17 @@ func (out *elasticsearchOutput) init(
 		FailEmpty:        true,
 	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	tlsConfig, err := outputs.LoadTLSConfig(config.TLS)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	err = out.readTemplate(&config.Template)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	out.index = index
@@ -197,7 +250,7 @@ func (out *elasticsearchOutput) init(
 		FailEmpty:        false,
 	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if !pipeline.IsEmpty() {
@@ -206,34 +259,9 @@ func (out *elasticsearchOutput) init(
 
 	clients, err := modeutil.MakeClients(cfg, makeClientFactory(tlsConfig, &config, out))
 	if err != nil {
-		return err
-	}
-
-	maxRetries := config.MaxRetries
-	maxAttempts := maxRetries + 1 // maximum number of send attempts (-1 = infinite)
-	if maxRetries < 0 {
-		maxAttempts = 0
-	}
-
-	var waitRetry = time.Duration(1) * time.Second
-	var maxWaitRetry = time.Duration(60) * time.Second
-
-	out.clients = clients
-	loadBalance := config.LoadBalance
-	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
-		Failover:     !loadBalance,
-		MaxAttempts:  maxAttempts,
-		Timeout:      config.Timeout,
-		WaitRetry:    waitRetry,
-		MaxWaitRetry: maxWaitRetry,
-	})
-	if err != nil {
-		return err
+		return nil, nil, err
 	}
-
-	out.mode = m
-
-	return nil
+	return &config, clients, nil
 }
 
 // readTemplates reads the ES mapping template from the disk, if configured.
@@ -383,6 +411,8 @@ func makeClientFactory(
 			URL:              esURL,
 			Index:            out.index,
 			Pipeline:         out.pipeline,
+			DocumentID:       config.DocumentID,
+			OpType:           config.OpType,
 			Proxy:            proxyURL,
 			TLS:              tls,
 			Username:         config.Username,
@@ -0,0 +1,55 @@
+package elasticsearch
+
+import (
+	"net/url"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+// testClients checks the connection to each configured host and reports the
+// Elasticsearch version. No template is loaded.
+type testClients []Client
+
+func newTester(_ string, cfg *common.Config) (testing.Testable, error) {
+	clients, err := NewElasticsearchClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return testClients(clients), nil
+}
+
+func (clients testClients) Test(d testing.Driver) {
+	for i := range clients {
+		clients[i].Test(d)
+	}
+}
+
+// Test checks the connection to the Elasticsearch host of the client, by
+// dialing it and requesting the server version.
+func (client *Client) Test(d testing.Driver) {
+	d.Run("elasticsearch: "+client.URL, func(d testing.Driver) {
+		u, err := url.Parse(client.URL)
+		d.Fatal("parse url", err)
+
+		if client.proxyURL != nil {
+			d.Info("proxy", client.proxyURL.String())
+		} else {
+			transp := &transport.Config{Timeout: client.timeout}
+			port := 80
+			if u.Scheme == "https" {
+				port = 443
+				transp.TLS = client.tlsConfig
+				if transp.TLS == nil {
+					transp.TLS = &transport.TLSConfig{}
+				}
+			}
+			transport.TestDial(d, transp, "tcp", u.Host, port)
+		}
+
+		version, err := client.Ping(client.timeout)
+		d.Fatal("talk to server", err)
+		d.Info("version", version)
+	})
+}
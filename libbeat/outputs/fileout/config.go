@@ -2,23 +2,31 @@ package fileout
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 )
 
 type config struct {
-	Path          string              `config:"path"`
-	Filename      string              `config:"filename"`
-	RotateEveryKb int                 `config:"rotate_every_kb" validate:"min=1"`
-	NumberOfFiles int                 `config:"number_of_files"`
-	Codec         outputs.CodecConfig `config:"codec"`
+	Path           *fmtstr.EventFormatString `config:"path"`
+	Filename       *fmtstr.EventFormatString `config:"filename"`
+	RotateEveryKb  int                       `config:"rotate_every_kb" validate:"min=1"`
+	RotateInterval time.Duration             `config:"rotate_interval"`
+	NumberOfFiles  int                       `config:"number_of_files"`
+	Compress       bool                      `config:"compress"`
+	Permissions    uint32                    `config:"permissions"`
+	MaxOpenFiles   int                       `config:"max_open_files" validate:"min=1"`
+	Codec          outputs.CodecConfig       `config:"codec"`
 }
 
 var (
 	defaultConfig = config{
 		NumberOfFiles: 7,
 		RotateEveryKb: 10 * 1024,
+		Permissions:   0600,
+		MaxOpenFiles:  16,
 	}
 )
 
@@ -28,5 +36,13 @@ func (c *config) Validate() error {
 			logp.RotatorMaxFiles)
 	}
 
+	if c.RotateInterval < 0 {
+		return fmt.Errorf("The rotate_interval must not be negative")
+	}
+
+	if c.Permissions == 0 || c.Permissions > 0777 {
+		return fmt.Errorf("The permissions should be a file mode between 0001 and 0777")
+	}
+
 	return nil
 }
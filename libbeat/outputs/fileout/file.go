@@ -1,7 +1,15 @@
 package fileout
 
 import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/common/op"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
@@ -9,12 +17,21 @@ import (
 
 func init() {
 	outputs.RegisterOutputPlugin("file", New)
+	outputs.RegisterOutputChecker("file", checkConfig)
 }
 
 type fileOutput struct {
 	beatName string
-	rotator  logp.FileRotator
+	config   config
 	codec    outputs.Codec
+
+	mutex    sync.Mutex
+	dynamic  bool
+	rotators map[string]*logp.FileRotator
+
+	// open files, most recently used first
+	open    *list.List
+	openIdx map[string]*list.Element
 }
 
 // New instantiates a new file output instance.
@@ -35,14 +52,30 @@ func New(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
 	return output, nil
 }
 
+// checkConfig validates the output settings, without creating any file.
+func checkConfig(_ string, cfg *common.Config) error {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return err
+	}
+
+	_, err := outputs.CreateEncoder(config.Codec)
+	return err
+}
+
 func (out *fileOutput) init(config config) error {
 	var err error
 
-	out.rotator.Path = config.Path
-	out.rotator.Name = config.Filename
-	if out.rotator.Name == "" {
-		out.rotator.Name = out.beatName
+	if config.Path == nil {
+		config.Path = fmtstr.MustCompileEvent("")
 	}
+	if config.Filename == nil {
+		config.Filename = fmtstr.MustCompileEvent(out.beatName)
+	}
+	out.config = config
+	out.rotators = map[string]*logp.FileRotator{}
+	out.open = list.New()
+	out.openIdx = map[string]*list.Element{}
 
 	codec, err := outputs.CreateEncoder(config.Codec)
 	if err != nil {
@@ -51,33 +84,150 @@ func (out *fileOutput) init(config config) error {
 
 	out.codec = codec
 
-	logp.Info("File output path set to: %v", out.rotator.Path)
-	logp.Info("File output base filename set to: %v", out.rotator.Name)
-
-	rotateeverybytes := uint64(config.RotateEveryKb) * 1024
-	logp.Info("Rotate every bytes set to: %v", rotateeverybytes)
-	out.rotator.RotateEveryBytes = &rotateeverybytes
+	logp.Info("Rotate every bytes set to: %v", uint64(config.RotateEveryKb)*1024)
+	logp.Info("Rotate interval set to: %v", config.RotateInterval)
+	logp.Info("Number of files set to: %v", config.NumberOfFiles)
+	logp.Info("Compression of rotated files set to: %v", config.Compress)
 
-	keepfiles := config.NumberOfFiles
-	logp.Info("Number of files set to: %v", keepfiles)
-	out.rotator.KeepFiles = &keepfiles
+	out.dynamic = !config.Path.IsConst() || !config.Filename.IsConst()
+	if out.dynamic {
+		logp.Info("File output path set to dynamic format")
+		logp.Info("Max number of open files set to: %v", config.MaxOpenFiles)
+		return nil
+	}
 
-	err = out.rotator.CreateDirectory()
+	// static path and filename: check configuration is sane on startup
+	dir, name, err := out.resolve(nil)
 	if err != nil {
 		return err
 	}
 
-	err = out.rotator.CheckIfConfigSane()
+	logp.Info("File output path set to: %v", dir)
+	logp.Info("File output base filename set to: %v", name)
+
+	_, err = out.rotator(dir, name)
+	return err
+}
+
+// resolve computes the directory and base filename an event is written to.
+func (out *fileOutput) resolve(event common.MapStr) (string, string, error) {
+	if err := checkPathFields(event, out.config.Path, out.config.Filename); err != nil {
+		return "", "", err
+	}
+
+	dir, err := out.config.Path.Run(event)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
+	name, err := out.config.Filename.Run(event)
+	if err != nil {
+		return "", "", err
+	}
+
+	// filename might contain sub-directories
+	full := filepath.Join(dir, name)
+	return filepath.Dir(full), filepath.Base(full), nil
+}
+
+// checkPathFields returns an error if a value of the event used by the format
+// strings is an absolute path or contains `..`, so events can't be written
+// outside of the configured directory.
+func checkPathFields(event common.MapStr, formats ...*fmtstr.EventFormatString) error {
+	for _, format := range formats {
+		for _, field := range format.AllFields() {
+			v, err := event.GetValue(field)
+			if err != nil {
+				continue
+			}
+
+			s := fmt.Sprint(v)
+			if filepath.IsAbs(s) || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "\\") {
+				return fmt.Errorf("value '%v' of field %v is an absolute path", s, field)
+			}
+			for _, part := range strings.FieldsFunc(s, isPathSeparator) {
+				if part == ".." {
+					return fmt.Errorf("value '%v' of field %v contains '..'", s, field)
+				}
+			}
+		}
+	}
 	return nil
 }
 
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// rotator returns the rotator writing to name in dir, creating it if required.
+func (out *fileOutput) rotator(dir, name string) (*logp.FileRotator, error) {
+	key := filepath.Join(dir, name)
+	if rotator, exists := out.rotators[key]; exists {
+		return rotator, nil
+	}
+
+	rotateeverybytes := uint64(out.config.RotateEveryKb) * 1024
+	keepfiles := out.config.NumberOfFiles
+	rotator := &logp.FileRotator{
+		Path:             dir,
+		Name:             name,
+		RotateEveryBytes: &rotateeverybytes,
+		KeepFiles:        &keepfiles,
+		RotateInterval:   out.config.RotateInterval,
+		Compress:         out.config.Compress,
+		Permissions:      os.FileMode(out.config.Permissions),
+		// files of dynamic paths are reopened after being closed as least
+		// recently used
+		Append: out.dynamic,
+	}
+
+	if err := rotator.CreateDirectory(); err != nil {
+		return nil, err
+	}
+	if err := rotator.CheckIfConfigSane(); err != nil {
+		return nil, err
+	}
+
+	out.rotators[key] = rotator
+	return rotator, nil
+}
+
+// markOpen moves the file to the front of the LRU list, closing and
+// forgetting the least recently used file if max_open_files is exceeded.
+func (out *fileOutput) markOpen(key string) {
+	if elem, exists := out.openIdx[key]; exists {
+		out.open.MoveToFront(elem)
+		return
+	}
+
+	out.openIdx[key] = out.open.PushFront(key)
+	for out.open.Len() > out.config.MaxOpenFiles {
+		last := out.open.Back()
+		lastKey := out.open.Remove(last).(string)
+		delete(out.openIdx, lastKey)
+
+		logp.Debug("file", "Closing least recently used file: %v", lastKey)
+		if err := out.rotators[lastKey].Close(); err != nil {
+			logp.Err("Error closing file %v: %v", lastKey, err)
+		}
+		delete(out.rotators, lastKey)
+	}
+}
+
 // Implement Outputer
 func (out *fileOutput) Close() error {
-	return nil
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+
+	var err error
+	for key := range out.openIdx {
+		if cerr := out.rotators[key].Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	out.open.Init()
+	out.openIdx = map[string]*list.Element{}
+	return err
 }
 
 func (out *fileOutput) PublishEvent(
@@ -94,7 +244,17 @@ func (out *fileOutput) PublishEvent(
 		return err
 	}
 
-	err = out.rotator.WriteLine(serializedEvent)
+	dir, name, err := out.resolve(data.Event)
+	if err != nil {
+		logp.Err("Dropping event, failed to format file path: %v", err)
+		op.SigCompleted(sig)
+		return err
+	}
+
+	out.mutex.Lock()
+	err = out.writeLine(dir, name, serializedEvent)
+	out.mutex.Unlock()
+
 	if err != nil {
 		if opts.Guaranteed {
 			logp.Critical("Unable to write events to file: %s", err)
@@ -105,3 +265,14 @@ func (out *fileOutput) PublishEvent(
 	op.Sig(sig, err)
 	return err
 }
+
+func (out *fileOutput) writeLine(dir, name string, line []byte) error {
+	rotator, err := out.rotator(dir, name)
+	if err != nil {
+		return err
+	}
+
+	err = rotator.WriteLine(line)
+	out.markOpen(filepath.Join(dir, name))
+	return err
+}
@@ -1,3 +1,115 @@
 // +build !integration
 
 package fileout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+
+	_ "github.com/elastic/beats/libbeat/outputs/codecs/json"
+)
+
+func newTestOutput(t *testing.T, settings map[string]interface{}) *fileOutput {
+	cfg, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := New("testbeat", cfg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.(*fileOutput)
+}
+
+func publish(t *testing.T, out *fileOutput, event common.MapStr) {
+	err := out.PublishEvent(nil, outputs.Options{}, outputs.Data{Event: event})
+	assert.NoError(t, err)
+}
+
+func TestFileOutputStaticPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":        dir,
+		"permissions": 0640,
+	})
+	publish(t, out, common.MapStr{"message": "hello"})
+	assert.NoError(t, out.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "testbeat"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"message":"hello"}`+"\n", string(content))
+
+	info, err := os.Stat(filepath.Join(dir, "testbeat"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestFileOutputDynamicPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":           filepath.Join(dir, "%{[app_name]}"),
+		"filename":       "%{+yyyy.MM.dd}.ndjson",
+		"max_open_files": 1,
+	})
+
+	ts := common.Time(time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC))
+	publish(t, out, common.MapStr{"@timestamp": ts, "app_name": "a", "n": 1})
+	publish(t, out, common.MapStr{"@timestamp": ts, "app_name": "b", "n": 2})
+	assert.Equal(t, 1, out.open.Len())
+	publish(t, out, common.MapStr{"@timestamp": ts, "app_name": "a", "n": 3})
+	assert.Equal(t, 1, out.open.Len())
+	assert.Len(t, out.rotators, 1)
+	assert.NoError(t, out.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "a", "2017.03.01.ndjson"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2017-03-01T12:00:00.000Z","app_name":"a","n":1}`+"\n"+
+		`{"@timestamp":"2017-03-01T12:00:00.000Z","app_name":"a","n":3}`+"\n", string(content))
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "b", "2017.03.01.ndjson"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"@timestamp":"2017-03-01T12:00:00.000Z","app_name":"b","n":2}`+"\n", string(content))
+
+	// events missing the path fields are dropped
+	err = out.PublishEvent(nil, outputs.Options{}, outputs.Data{Event: common.MapStr{"@timestamp": ts}})
+	assert.Error(t, err)
+
+	// events with values escaping the directory are dropped
+	for _, name := range []string{"../a", "a/../../b", "/etc", `..\a`} {
+		event := common.MapStr{"@timestamp": ts, "app_name": name}
+		err = out.PublishEvent(nil, outputs.Options{}, outputs.Data{Event: event})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFileOutputInvalidPermissions(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"path":        "/tmp",
+		"permissions": 01777,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New("testbeat", cfg, 0)
+	assert.Error(t, err)
+}
@@ -17,11 +17,13 @@ import (
 )
 
 type client struct {
-	hosts  []string
-	topic  outil.Selector
-	key    *fmtstr.EventFormatString
-	codec  outputs.Codec
-	config sarama.Config
+	hosts     []string
+	topic     outil.Selector
+	key       *fmtstr.EventFormatString
+	timestamp timestampSource
+	codec     outputs.Codec
+	config    sarama.Config
+	stats     *deliveryStats
 
 	producer sarama.AsyncProducer
 
@@ -29,12 +31,14 @@ type client struct {
 }
 
 type msgRef struct {
-	count  int32
-	total  int
-	failed []outputs.Data
-	cb     func([]outputs.Data, error)
+	count int32
+	total int
+	cb    func([]outputs.Data, error)
 
-	err error
+	// failed events are added while publishing and by the error worker
+	mutex  sync.Mutex
+	failed []outputs.Data
+	err    error
 }
 
 var (
@@ -47,15 +51,19 @@ func newKafkaClient(
 	hosts []string,
 	key *fmtstr.EventFormatString,
 	topic outil.Selector,
+	timestamp timestampSource,
 	writer outputs.Codec,
 	cfg *sarama.Config,
+	stats *deliveryStats,
 ) (*client, error) {
 	c := &client{
-		hosts:  hosts,
-		topic:  topic,
-		key:    key,
-		codec:  writer,
-		config: *cfg,
+		hosts:     hosts,
+		topic:     topic,
+		key:       key,
+		timestamp: timestamp,
+		codec:     writer,
+		config:    *cfg,
+		stats:     stats,
 	}
 	return c, nil
 }
@@ -115,11 +123,25 @@ func (c *client) AsyncPublishEvents(
 
 	ch := c.producer.Input()
 
+	// After a temporary encoding error, like the avro schema registry being
+	// unavailable, the remaining events are retried without encoding them.
+	var retryErr error
 	for i := range data {
 		d := &data[i]
 
+		if retryErr != nil {
+			ref.retry(*d, retryErr)
+			continue
+		}
+
 		msg, err := c.getEventMessage(d)
 		if err != nil {
+			if isTemporary(err) {
+				logp.Err("Retrying events, failed to encode event: %v", err)
+				retryErr = err
+				ref.retry(*d, err)
+				continue
+			}
 			logp.Err("Dropping event: %v", err)
 			ref.done()
 			continue
@@ -127,6 +149,7 @@ func (c *client) AsyncPublishEvents(
 		msg.ref = ref
 
 		msg.initProducerMessage()
+		msg.sent = time.Now()
 		ch <- &msg.msg
 	}
 
@@ -155,9 +178,10 @@ func (c *client) getEventMessage(data *outputs.Data) (*message, error) {
 
 	msg.value = serializedEvent
 
-	// message timestamps have been added to kafka with version 0.10.0.0
+	// message timestamps have been added to kafka with version 0.10.0.0. If
+	// no timestamp is set, the producer sets the current time.
 	var ts time.Time
-	if c.config.Version.IsAtLeast(sarama.V0_10_0_0) {
+	if c.timestamp == timestampEvent && c.config.Version.IsAtLeast(sarama.V0_10_0_0) {
 		if tsRaw, ok := event["@timestamp"]; ok {
 			if tmp, ok := tsRaw.(common.Time); ok {
 				ts = time.Time(tmp)
@@ -183,6 +207,7 @@ func (c *client) successWorker(ch <-chan *sarama.ProducerMessage) {
 
 	for libMsg := range ch {
 		msg := libMsg.Metadata.(*message)
+		c.stats.acked(msg.topic, libMsg.Partition, time.Since(msg.sent))
 		msg.ref.done()
 	}
 }
@@ -193,28 +218,61 @@ func (c *client) errorWorker(ch <-chan *sarama.ProducerError) {
 
 	for errMsg := range ch {
 		msg := errMsg.Msg.Metadata.(*message)
-		msg.ref.fail(msg, errMsg.Err)
+		latency := time.Since(msg.sent)
+		if dropOnError(msg, errMsg.Err) {
+			c.stats.failed(msg.topic, errMsg.Msg.Partition, latency)
+			msg.ref.done()
+		} else {
+			c.stats.retried(msg.topic, errMsg.Msg.Partition, latency)
+			msg.ref.fail(msg, errMsg.Err)
+		}
 	}
 }
 
-func (r *msgRef) done() {
-	r.dec()
-}
-
-func (r *msgRef) fail(msg *message, err error) {
+// dropOnError reports if a message is dropped on error, instead of being
+// retried. Dropped messages are logged.
+func dropOnError(msg *message, err error) bool {
 	switch err {
 	case sarama.ErrInvalidMessage:
 		logp.Err("Kafka (topic=%v): dropping invalid message", msg.topic)
+		return true
 
 	case sarama.ErrMessageSizeTooLarge, sarama.ErrInvalidMessageSize:
 		logp.Err("Kafka (topic=%v): dropping too large message of size %v.",
 			msg.topic,
 			len(msg.key)+len(msg.value))
-
-	default:
-		r.failed = append(r.failed, msg.data)
-		r.err = err
+		return true
 	}
+	return false
+}
+
+// isTemporary reports if the error is temporary, so the event can be retried.
+func isTemporary(err error) bool {
+	temp, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && temp.Temporary()
+}
+
+func (r *msgRef) done() {
+	r.dec()
+}
+
+// retry marks an event not sent to kafka for retrying.
+func (r *msgRef) retry(data outputs.Data, err error) {
+	r.mutex.Lock()
+	r.failed = append(r.failed, data)
+	r.err = err
+	r.mutex.Unlock()
+	r.dec()
+}
+
+// fail marks a message failed to be sent for retrying.
+func (r *msgRef) fail(msg *message, err error) {
+	r.mutex.Lock()
+	r.failed = append(r.failed, msg.data)
+	r.err = err
+	r.mutex.Unlock()
 	r.dec()
 }
 
@@ -226,18 +284,20 @@ func (r *msgRef) dec() {
 
 	debugf("finished kafka batch")
 
-	err := r.err
+	r.mutex.Lock()
+	failed, err := r.failed, r.err
+	r.mutex.Unlock()
+
 	if err != nil {
-		failed := len(r.failed)
-		success := r.total - failed
+		success := r.total - len(failed)
 
-		eventsNotAcked.Add(int64(failed))
+		eventsNotAcked.Add(int64(len(failed)))
 		if success > 0 {
 			ackedEvents.Add(int64(success))
 		}
 
 		debugf("Kafka publish failed with: %v", err)
-		r.cb(r.failed, err)
+		r.cb(failed, err)
 	} else {
 		ackedEvents.Add(int64(r.total))
 		r.cb(nil, nil)
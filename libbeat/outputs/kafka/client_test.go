@@ -0,0 +1,245 @@
+// +build !integration
+
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codecs/json"
+	"github.com/elastic/beats/libbeat/outputs/outest"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	bt "github.com/elastic/beats/libbeat/testing"
+)
+
+const testTopic = "test.events"
+
+func newMockBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(testTopic, 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t),
+	})
+	return broker
+}
+
+func newTestClient(
+	t *testing.T,
+	broker *sarama.MockBroker,
+	timestamp timestampSource,
+	reg *monitoring.Registry,
+) *client {
+	return newTestClientWithCodec(t, broker, timestamp, reg, json.New(false))
+}
+
+func newTestClientWithCodec(
+	t *testing.T,
+	broker *sarama.MockBroker,
+	timestamp timestampSource,
+	reg *monitoring.Registry,
+	codec outputs.Codec,
+) *client {
+	config := defaultConfig
+	config.Hosts = []string{broker.Addr()}
+	config.Version = "0.9" // the mock broker does not support v2 produce responses
+	config.MaxRetries = 0
+	config.Metadata.Retry.Max = 0
+
+	libCfg, err := newKafkaConfig(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topic := outil.MakeSelector(outil.ConstSelectorExpr(testTopic))
+	c, err := newKafkaClient(config.Hosts, nil, topic, timestamp,
+		codec, libCfg, newDeliveryStats(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func publish(t *testing.T, c *client, events ...common.MapStr) ([]outputs.Data, error) {
+	data := make([]outputs.Data, len(events))
+	for i, event := range events {
+		data[i] = outputs.Data{Event: event}
+	}
+
+	type result struct {
+		failed []outputs.Data
+		err    error
+	}
+	done := make(chan result, 1)
+	err := c.AsyncPublishEvents(func(failed []outputs.Data, err error) {
+		done <- result{failed, err}
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case res := <-done:
+		return res.failed, res.err
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for publish result")
+	}
+	return nil, nil
+}
+
+func TestDeliveryStats(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	reg := monitoring.NewRegistry()
+	c := newTestClient(t, broker, timestampEvent, reg)
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	failed, err := publish(t, c, common.MapStr{"n": 1}, common.MapStr{"n": 2})
+	assert.NoError(t, err)
+	assert.Len(t, failed, 0)
+
+	// events too large are dropped
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetError(testTopic, 0, sarama.ErrMessageSizeTooLarge),
+	})
+	failed, err = publish(t, c, common.MapStr{"n": 3})
+	assert.NoError(t, err)
+	assert.Len(t, failed, 0)
+
+	// events failing with a retriable error are returned for retrying
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetError(testTopic, 0, sarama.ErrNotEnoughReplicas),
+	})
+	failed, err = publish(t, c, common.MapStr{"n": 4})
+	assert.Error(t, err)
+	assert.Len(t, failed, 1)
+
+	snapshot := monitoring.CollectFlatSnapshot(reg, monitoring.Full, false)
+	prefix := "libbeat.output.kafka.topics.test_events."
+	assert.Equal(t, int64(2), snapshot.Ints[prefix+"acked"])
+	assert.Equal(t, int64(1), snapshot.Ints[prefix+"retried"])
+	assert.Equal(t, int64(1), snapshot.Ints[prefix+"failed"])
+	assert.Equal(t, int64(2), snapshot.Ints[prefix+"partitions.0.acked"])
+	assert.Equal(t, int64(1), snapshot.Ints[prefix+"partitions.0.retried"])
+	assert.Equal(t, int64(1), snapshot.Ints[prefix+"partitions.0.failed"])
+	assert.Equal(t, int64(4), snapshot.Ints[prefix+"latency_ms.count"])
+}
+
+// failingCodec fails to encode any event.
+type failingCodec struct {
+	err error
+}
+
+func (c failingCodec) Encode(common.MapStr) ([]byte, error) { return nil, c.err }
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "registry unavailable" }
+func (temporaryError) Temporary() bool { return true }
+
+func TestEncodeErrors(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	// events failing with a temporary error are returned for retrying
+	c := newTestClientWithCodec(t, broker, timestampEvent, monitoring.NewRegistry(),
+		failingCodec{temporaryError{}})
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := publish(t, c, common.MapStr{"n": 1}, common.MapStr{"n": 2})
+	assert.Equal(t, temporaryError{}, err)
+	assert.Len(t, failed, 2)
+	c.Close()
+
+	// other events are dropped
+	c = newTestClientWithCodec(t, broker, timestampEvent, monitoring.NewRegistry(),
+		failingCodec{errors.New("invalid event")})
+	if err := c.Connect(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	failed, err = publish(t, c, common.MapStr{"n": 1})
+	assert.NoError(t, err)
+	assert.Len(t, failed, 0)
+	c.Close()
+}
+
+func TestMessageTimestamp(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	ts := time.Date(2017, 5, 1, 10, 30, 0, 0, time.UTC)
+	event := common.MapStr{"@timestamp": common.Time(ts), "message": "hello"}
+
+	c := newTestClient(t, broker, timestampEvent, monitoring.NewRegistry())
+	c.config.Version = sarama.V0_10_0_0
+	msg, err := c.getEventMessage(&outputs.Data{Event: event})
+	if assert.NoError(t, err) {
+		assert.Equal(t, ts, msg.ts)
+	}
+
+	// with timestamps set on produce, the producer assigns the current time
+	c = newTestClient(t, broker, timestampProduce, monitoring.NewRegistry())
+	c.config.Version = sarama.V0_10_0_0
+	msg, err = c.getEventMessage(&outputs.Data{Event: event})
+	if assert.NoError(t, err) {
+		assert.True(t, msg.ts.IsZero())
+	}
+}
+
+func TestTimestampSourceConfig(t *testing.T) {
+	cfg, _ := common.NewConfigFrom(map[string]interface{}{
+		"hosts":     []string{"localhost:9092"},
+		"timestamp": "produce",
+	})
+	config := defaultConfig
+	if assert.NoError(t, cfg.Unpack(&config)) {
+		assert.Equal(t, timestampProduce, config.Timestamp)
+	}
+
+	cfg, _ = common.NewConfigFrom(map[string]interface{}{
+		"hosts":     []string{"localhost:9092"},
+		"timestamp": "invalid",
+	})
+	config = defaultConfig
+	assert.Error(t, cfg.Unpack(&config))
+}
+
+func TestOutputTest(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	testOutput := func(topic string) (*bt.ConsoleDriver, string) {
+		return outest.RunTest(t, "kafka", map[string]interface{}{
+			"hosts":              []string{broker.Addr()},
+			"topic":              topic,
+			"metadata.retry.max": 0,
+		})
+	}
+
+	d, out := testOutput(testTopic)
+	assert.False(t, d.Failed(), out)
+	assert.Contains(t, out, "kafka: "+broker.Addr()+"...")
+	assert.Contains(t, out, "fetch metadata... OK")
+	assert.Contains(t, out, "topic "+testTopic+"... OK")
+	assert.Contains(t, out, "partitions: 1")
+	assert.Contains(t, out, "leaders: "+broker.Addr())
+
+	d, out = testOutput("unknown")
+	assert.True(t, d.Failed())
+	assert.Contains(t, out, "topic unknown... ERROR")
+}
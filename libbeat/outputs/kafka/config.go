@@ -18,6 +18,7 @@ type kafkaConfig struct {
 	Worker          int                       `config:"worker"              validate:"min=1"`
 	Metadata        metaConfig                `config:"metadata"`
 	Key             *fmtstr.EventFormatString `config:"key"`
+	Timestamp       timestampSource           `config:"timestamp"`
 	Partition       map[string]*common.Config `config:"partition"`
 	KeepAlive       time.Duration             `config:"keep_alive"          validate:"min=0"`
 	MaxMessageBytes *int                      `config:"max_message_bytes"   validate:"min=1"`
@@ -67,9 +68,33 @@ var (
 		ChanBufferSize:  256,
 		Username:        "",
 		Password:        "",
+		Timestamp:       timestampEvent,
 	}
 )
 
+// timestampSource configures the kafka message timestamps being set from the
+// event timestamp or the time the message is produced.
+type timestampSource uint8
+
+const (
+	timestampEvent timestampSource = iota
+	timestampProduce
+)
+
+var timestampSources = map[string]timestampSource{
+	"event":   timestampEvent,
+	"produce": timestampProduce,
+}
+
+func (t *timestampSource) Unpack(s string) error {
+	v, exists := timestampSources[strings.ToLower(s)]
+	if !exists {
+		return fmt.Errorf("unknown timestamp source '%v'", s)
+	}
+	*t = v
+	return nil
+}
+
 func (c *kafkaConfig) Validate() error {
 	if len(c.Hosts) == 0 {
 		return errors.New("no hosts configured")
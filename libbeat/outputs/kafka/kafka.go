@@ -52,6 +52,8 @@ func init() {
 	kafkaMetricsRegistryInstance = reg
 
 	outputs.RegisterOutputPlugin("kafka", New)
+	outputs.RegisterOutputChecker("kafka", checkConfig)
+	outputs.RegisterOutputTester("kafka", newTester)
 }
 
 var kafkaMetricsOnce sync.Once
@@ -109,6 +111,13 @@ func New(beatName string, cfg *common.Config, topologyExpire int) (outputs.Outpu
 	return output, nil
 }
 
+// checkConfig validates the output settings. The kafka clients are only
+// created on publish, so New does not connect either.
+func checkConfig(_ string, cfg *common.Config) error {
+	k := &kafka{}
+	return k.init(cfg)
+}
+
 func (k *kafka) init(cfg *common.Config) error {
 	debugf("initialize kafka output")
 
@@ -168,6 +177,7 @@ func (k *kafka) initMode(guaranteed bool) (mode.ConnectionMode, error) {
 	var clients []mode.AsyncProtocolClient
 	hosts := k.config.Hosts
 	topic := k.topic
+	stats := newDeliveryStats(monitoring.Default)
 
 	for i := 0; i < worker; i++ {
 		codec, err := outputs.CreateEncoder(k.config.Codec)
@@ -175,7 +185,8 @@ func (k *kafka) initMode(guaranteed bool) (mode.ConnectionMode, error) {
 			return nil, err
 		}
 
-		client, err := newKafkaClient(hosts, k.config.Key, topic, codec, libCfg)
+		client, err := newKafkaClient(hosts, k.config.Key, topic,
+			k.config.Timestamp, codec, libCfg, stats)
 		if err != nil {
 			logp.Err("Failed to create kafka client: %v", err)
 			return nil, err
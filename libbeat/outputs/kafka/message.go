@@ -16,6 +16,9 @@ type message struct {
 	ref   *msgRef
 	ts    time.Time
 
+	// time the message has been handed to the producer
+	sent time.Time
+
 	hash      uint32
 	partition int32
 
@@ -38,6 +41,7 @@ func (m *message) initProducerMessage() {
 	m.msg = sarama.ProducerMessage{
 		Metadata:  m,
 		Topic:     m.topic,
+		Partition: -1, // set by the producer when assigning the partition
 		Key:       sarama.ByteEncoder(m.key),
 		Value:     sarama.ByteEncoder(m.value),
 		Timestamp: m.ts,
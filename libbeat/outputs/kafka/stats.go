@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/monitoring/adapter"
+)
+
+// deliveryStats collects the delivery outcomes per topic and partition. The
+// metrics are registered with the monitoring registry under
+// `libbeat.output.kafka.topics.<topic>`:
+//
+//   - acked, failed, retried: number of events acknowledged by kafka, dropped,
+//     or handed back for retrying.
+//   - latency_ms: histogram of the time taken from handing an event to the
+//     producer until the delivery outcome is known.
+//   - partitions.<partition>.{acked,failed,retried}: per partition counters.
+//
+// Dots in topic names are replaced with underscores, as dots separate the
+// names in the monitoring registry.
+type deliveryStats struct {
+	reg *adapter.GoMetricsRegistry
+
+	mutex  sync.Mutex
+	topics map[string]*topicStats
+}
+
+type topicStats struct {
+	deliveryCounters
+	name       string
+	latency    metrics.Histogram
+	partitions map[int32]*deliveryCounters
+}
+
+type deliveryCounters struct {
+	acked, failed, retried metrics.Counter
+}
+
+func newDeliveryStats(parent *monitoring.Registry) *deliveryStats {
+	return &deliveryStats{
+		reg:    adapter.GetGoMetrics(parent, "libbeat.output.kafka.topics", adapter.Accept),
+		topics: map[string]*topicStats{},
+	}
+}
+
+func (s *deliveryStats) acked(topic string, partition int32, latency time.Duration) {
+	ts, pc := s.get(topic, partition)
+	ts.latency.Update(int64(latency / time.Millisecond))
+	ts.acked.Inc(1)
+	if pc != nil {
+		pc.acked.Inc(1)
+	}
+}
+
+func (s *deliveryStats) failed(topic string, partition int32, latency time.Duration) {
+	ts, pc := s.get(topic, partition)
+	ts.latency.Update(int64(latency / time.Millisecond))
+	ts.failed.Inc(1)
+	if pc != nil {
+		pc.failed.Inc(1)
+	}
+}
+
+func (s *deliveryStats) retried(topic string, partition int32, latency time.Duration) {
+	ts, pc := s.get(topic, partition)
+	ts.latency.Update(int64(latency / time.Millisecond))
+	ts.retried.Inc(1)
+	if pc != nil {
+		pc.retried.Inc(1)
+	}
+}
+
+// get returns the stats of a topic and partition, registering the metrics on
+// first use. The partition counters are nil if no partition has been assigned
+// to the message yet.
+func (s *deliveryStats) get(topic string, partition int32) (*topicStats, *deliveryCounters) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ts := s.topics[topic]
+	if ts == nil {
+		name := strings.Replace(topic, ".", "_", -1)
+		ts = &topicStats{
+			deliveryCounters: s.counters(name),
+			name:             name,
+			latency: s.reg.GetOrRegister(name+".latency_ms", func() interface{} {
+				return metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+			}).(metrics.Histogram),
+			partitions: map[int32]*deliveryCounters{},
+		}
+		s.topics[topic] = ts
+	}
+
+	if partition < 0 {
+		return ts, nil
+	}
+
+	pc := ts.partitions[partition]
+	if pc == nil {
+		counters := s.counters(fmt.Sprintf("%v.partitions.%v", ts.name, partition))
+		pc = &counters
+		ts.partitions[partition] = pc
+	}
+	return ts, pc
+}
+
+func (s *deliveryStats) counters(prefix string) deliveryCounters {
+	counter := func(name string) metrics.Counter {
+		return s.reg.GetOrRegister(prefix+"."+name, metrics.NewCounter).(metrics.Counter)
+	}
+	return deliveryCounters{
+		acked:   counter("acked"),
+		failed:  counter("failed"),
+		retried: counter("retried"),
+	}
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+const defaultKafkaPort = 9092
+
+func newTester(_ string, cfg *common.Config) (testing.Testable, error) {
+	k := &kafka{}
+	if err := k.init(cfg); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Test checks the connection to each configured broker and fetches the cluster
+// metadata. If the topic is not set per event, the topic is checked to exist.
+func (k *kafka) Test(d testing.Driver) {
+	tls, err := outputs.LoadTLSConfig(k.config.TLS)
+	d.Fatal("load TLS config", err)
+
+	transp := &transport.Config{Timeout: k.config.Timeout, TLS: tls}
+	for _, host := range k.config.Hosts {
+		host := host
+		d.Run("kafka: "+host, func(d testing.Driver) {
+			transport.TestDial(d, transp, "tcp", host, defaultKafkaPort)
+		})
+	}
+
+	d.Run("kafka: metadata", func(d testing.Driver) {
+		cfg, err := newKafkaConfig(&k.config)
+		d.Fatal("create config", err)
+
+		client, err := sarama.NewClient(k.config.Hosts, cfg)
+		d.Fatal("fetch metadata", err)
+		defer client.Close()
+
+		topics, err := client.Topics()
+		d.Error("list topics", err)
+		if err == nil {
+			d.Info("topics", strconv.Itoa(len(topics)))
+		}
+
+		if !k.topic.IsConst() {
+			return
+		}
+		topic, err := k.topic.Select(nil)
+		if err != nil || topic == "" {
+			return
+		}
+		partitions, err := client.Partitions(topic)
+		d.Fatal("topic "+topic, err)
+		d.Info("partitions", strconv.Itoa(len(partitions)))
+
+		var leaders []string
+		for _, partition := range partitions {
+			leader, err := client.Leader(topic, partition)
+			d.Error("leader of partition "+strconv.Itoa(int(partition)), err)
+			if err == nil && !containsString(leaders, leader.Addr()) {
+				leaders = append(leaders, leader.Addr())
+			}
+		}
+		d.Info("leaders", strings.Join(leaders, ", "))
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
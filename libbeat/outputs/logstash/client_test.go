@@ -11,6 +11,7 @@ import (
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outest"
 	"github.com/elastic/beats/libbeat/outputs/transport"
 	"github.com/elastic/beats/libbeat/outputs/transport/transptest"
 
@@ -206,6 +207,20 @@ func testMultiFailMaxTimeouts(t *testing.T, factory clientFactory) {
 	}
 }
 
+func TestOutputTest(t *testing.T) {
+	server := transptest.NewMockServerTCP(t, 1*time.Second, "", nil)
+	defer server.Close()
+
+	d, out := outest.RunTest(t, "logstash", map[string]interface{}{
+		"hosts": []string{server.Addr(), "127.0.0.1:1"},
+	})
+	assert.True(t, d.Failed())
+	assert.Contains(t, out, "logstash: "+server.Addr()+"...\n  connection...")
+	assert.Contains(t, out, "logstash: 127.0.0.1:1...")
+	assert.Contains(t, out, "dial up... OK")
+	assert.Contains(t, out, "dial up... ERROR")
+}
+
 func eventGet(event interface{}, path string) interface{} {
 	doc := event.(map[string]interface{})
 	elems := strings.Split(path, ".")
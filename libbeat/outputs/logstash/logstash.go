@@ -44,6 +44,8 @@ func init() {
 	log.Logger = logstashLogger{}
 
 	outputs.RegisterOutputPlugin("logstash", new)
+	outputs.RegisterOutputChecker("logstash", checkConfig)
+	outputs.RegisterOutputTester("logstash", newTester)
 }
 
 func new(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
@@ -59,20 +61,58 @@ func new(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
 	return output, nil
 }
 
+// checkConfig validates the output settings and creates the clients, without
+// connecting them.
+func checkConfig(beatName string, cfg *common.Config) error {
+	if !cfg.HasField("index") {
+		cfg.SetString("index", -1, beatName)
+	}
+
+	output := &logstash{}
+	config, err := output.configure(cfg)
+	if err != nil {
+		return err
+	}
+	_, _, err = makeClients(cfg, config, output.transp)
+	return err
+}
+
 type logstash struct {
 	mode  mode.ConnectionMode
 	index string
+
+	// settings required by Test
+	hosts  []string
+	port   int
+	transp *transport.Config
 }
 
 func (lj *logstash) init(cfg *common.Config) error {
+	config, err := lj.configure(cfg)
+	if err != nil {
+		return err
+	}
+
+	logp.Info("Max Retries set to: %v", config.MaxRetries)
+	m, err := initConnectionMode(cfg, config, lj.transp)
+	if err != nil {
+		return err
+	}
+
+	lj.mode = m
+	return nil
+}
+
+// configure reads the settings and the transport configuration.
+func (lj *logstash) configure(cfg *common.Config) (*logstashConfig, error) {
 	config := defaultConfig
 	if err := cfg.Unpack(&config); err != nil {
-		return err
+		return nil, err
 	}
 
 	tls, err := outputs.LoadTLSConfig(config.TLS)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	transp := &transport.Config{
@@ -87,16 +127,19 @@ func (lj *logstash) init(cfg *common.Config) error {
 		},
 	}
 
-	logp.Info("Max Retries set to: %v", config.MaxRetries)
-	m, err := initConnectionMode(cfg, &config, transp)
-	if err != nil {
-		return err
+	hosts := struct {
+		Hosts []string `config:"hosts"`
+	}{}
+	if err := cfg.Unpack(&hosts); err != nil {
+		return nil, err
 	}
 
-	lj.mode = m
 	lj.index = config.Index
+	lj.hosts = hosts.Hosts
+	lj.port = config.Port
+	lj.transp = transp
 
-	return nil
+	return &config, nil
 }
 
 func initConnectionMode(
@@ -118,19 +161,30 @@ func initConnectionMode(
 		MaxWaitRetry: defaultMaxWaitRetry,
 	}
 
+	clients, asyncClients, err := makeClients(cfg, config, transp)
+	if err != nil {
+		return nil, err
+	}
 	if config.Pipelining == 0 {
-		clients, err := modeutil.MakeClients(cfg, makeClientFactory(config, transp))
-		if err != nil {
-			return nil, err
-		}
 		return modeutil.NewConnectionMode(clients, settings)
 	}
+	return modeutil.NewAsyncConnectionMode(asyncClients, settings)
+}
 
-	clients, err := modeutil.MakeAsyncClients(cfg, makeAsyncClientFactory(config, transp))
-	if err != nil {
-		return nil, err
+// makeClients creates the synchronous clients, or the asynchronous clients if
+// pipelining is enabled.
+func makeClients(
+	cfg *common.Config,
+	config *logstashConfig,
+	transp *transport.Config,
+) ([]mode.ProtocolClient, []mode.AsyncProtocolClient, error) {
+	if config.Pipelining == 0 {
+		clients, err := modeutil.MakeClients(cfg, makeClientFactory(config, transp))
+		return clients, nil, err
 	}
-	return modeutil.NewAsyncConnectionMode(clients, settings)
+
+	clients, err := modeutil.MakeAsyncClients(cfg, makeAsyncClientFactory(config, transp))
+	return nil, clients, err
 }
 
 func makeClientFactory(
@@ -0,0 +1,27 @@
+package logstash
+
+import (
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+func newTester(_ string, cfg *common.Config) (testing.Testable, error) {
+	lj := &logstash{}
+	if _, err := lj.configure(cfg); err != nil {
+		return nil, err
+	}
+	return lj, nil
+}
+
+// Test checks the connection to each configured host. The lumberjack protocol
+// has no handshake, so no events are sent.
+func (lj *logstash) Test(d testing.Driver) {
+	transp := transport.TestingConfig(lj.transp)
+	for _, host := range lj.hosts {
+		host := host
+		d.Run("logstash: "+host, func(d testing.Driver) {
+			transport.TestDial(d, transp, "tcp", host, lj.port)
+		})
+	}
+}
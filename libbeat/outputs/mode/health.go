@@ -0,0 +1,115 @@
+package mode
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthState reports the recent publishing outcome of an output client.
+type HealthState struct {
+	// LastSuccess is the time events have last been published successfully.
+	LastSuccess time.Time
+
+	// FailingSince is the time of the first failure after the last success.
+	// It is zero if the last publish attempt did succeed.
+	FailingSince time.Time
+
+	// Failures is the number of failed publish and connect attempts since the
+	// last success.
+	Failures int64
+}
+
+// HealthTracker records the publishing outcome of a single output client,
+// e.g. one load balancer worker per host. Trackers are registered on creation
+// and contribute to Health until they are closed.
+type HealthTracker struct {
+	mutex sync.Mutex
+	state HealthState
+}
+
+var trackers = struct {
+	sync.Mutex
+	all map[*HealthTracker]struct{}
+}{all: map[*HealthTracker]struct{}{}}
+
+// NewHealthTracker creates and registers a new health tracker.
+func NewHealthTracker() *HealthTracker {
+	t := &HealthTracker{}
+
+	trackers.Lock()
+	defer trackers.Unlock()
+	trackers.all[t] = struct{}{}
+	return t
+}
+
+// Close unregisters the tracker, so it no longer contributes to Health.
+func (t *HealthTracker) Close() {
+	trackers.Lock()
+	defer trackers.Unlock()
+	delete(trackers.all, t)
+}
+
+// PublishSucceeded records events having been published successfully,
+// resetting the failure state.
+func (t *HealthTracker) PublishSucceeded() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.state.LastSuccess = time.Now()
+	t.state.FailingSince = time.Time{}
+	t.state.Failures = 0
+}
+
+// PublishFailed records a failed publish or connect attempt.
+func (t *HealthTracker) PublishFailed() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.state.Failures == 0 {
+		t.state.FailingSince = time.Now()
+	}
+	t.state.Failures++
+}
+
+// State returns the current health state of the tracker.
+func (t *HealthTracker) State() HealthState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.state
+}
+
+// Health returns the health state of the worst output client: the one failing
+// for the longest time. If no client is failing, the most recent success is
+// reported.
+func Health() HealthState {
+	trackers.Lock()
+	defer trackers.Unlock()
+
+	var worst HealthState
+	for t := range trackers.all {
+		worst = worse(worst, t.State())
+	}
+	return worst
+}
+
+func worse(a, b HealthState) HealthState {
+	aFailing, bFailing := !a.FailingSince.IsZero(), !b.FailingSince.IsZero()
+	switch {
+	case aFailing != bFailing:
+		if aFailing {
+			return a
+		}
+		return b
+	case aFailing:
+		if b.FailingSince.Before(a.FailingSince) ||
+			(b.FailingSince.Equal(a.FailingSince) && b.Failures > a.Failures) {
+			return b
+		}
+		return a
+	default:
+		if b.LastSuccess.After(a.LastSuccess) {
+			return b
+		}
+		return a
+	}
+}
@@ -23,6 +23,7 @@ type asyncWorker struct {
 	client  mode.AsyncProtocolClient
 	backoff *common.Backoff
 	ctx     context
+	health  *mode.HealthTracker
 }
 
 func AsyncClients(
@@ -57,6 +58,7 @@ func newAsyncWorker(
 		client:  client,
 		backoff: common.NewBackoff(ctx.done, waitRetry, maxWaitRetry),
 		ctx:     ctx,
+		health:  mode.NewHealthTracker(),
 	}
 }
 
@@ -65,6 +67,7 @@ func (w *asyncWorker) run() {
 
 	debugf("load balancer: start client loop")
 	defer debugf("load balancer: stop client loop")
+	defer w.health.Close()
 
 	done := false
 	for !done {
@@ -86,6 +89,7 @@ func (w *asyncWorker) connect() bool {
 		}
 
 		logp.Err("Connect failed with: %v", err)
+		w.health.PublishFailed()
 
 		cont := w.backoff.Wait()
 		if !cont {
@@ -142,6 +146,7 @@ func (w *asyncWorker) handleResult(msg eventsMessage) func(error) {
 			return
 		}
 
+		w.health.PublishSucceeded()
 		op.SigCompleted(msg.signaler)
 	}
 }
@@ -194,11 +199,13 @@ func (w *asyncWorker) handleResults(msg eventsMessage) func([]outputs.Data, erro
 
 		// all events published -> signal success
 		debugf("async bulk publish success")
+		w.health.PublishSucceeded()
 		op.SigCompleted(msg.signaler)
 	}
 }
 
 func (w *asyncWorker) onFail(msg eventsMessage, err error) {
+	w.health.PublishFailed()
 	if !w.ctx.tryPushFailed(msg) {
 		// break possible deadlock by spawning go-routine returning failed messages
 		// into retries queue
@@ -22,6 +22,7 @@ type syncWorker struct {
 	client  mode.ProtocolClient
 	backoff *common.Backoff
 	ctx     context
+	health  *mode.HealthTracker
 }
 
 func SyncClients(
@@ -56,6 +57,7 @@ func newSyncWorker(
 		client:  client,
 		backoff: common.NewBackoff(ctx.done, waitRetry, maxWaitRetry),
 		ctx:     ctx,
+		health:  mode.NewHealthTracker(),
 	}
 }
 
@@ -64,6 +66,7 @@ func (w *syncWorker) run() {
 
 	debugf("load balancer: start client loop")
 	defer debugf("load balancer: stop client loop")
+	defer w.health.Close()
 
 	done := false
 	for !done {
@@ -85,6 +88,7 @@ func (w *syncWorker) connect() bool {
 		}
 
 		logp.Err("Connect failed with: %v", err)
+		w.health.PublishFailed()
 
 		cont := w.backoff.Wait()
 		if !cont {
@@ -159,11 +163,13 @@ func (w *syncWorker) onMessage(msg eventsMessage) error {
 		}
 	}
 
+	w.health.PublishSucceeded()
 	op.SigCompleted(msg.signaler)
 	return nil
 }
 
 func (w *syncWorker) onFail(msg eventsMessage, err error) {
+	w.health.PublishFailed()
 	logp.Info("Error publishing events (retrying): %s", err)
 	w.ctx.pushFailed(msg)
 }
@@ -22,6 +22,7 @@ type Mode struct {
 
 	timeout time.Duration // connection timeout
 	backoff *common.Backoff
+	health  *mode.HealthTracker
 
 	// maximum number of configured send attempts. If set to 0, publisher will
 	// block until event has been successfully published.
@@ -46,6 +47,7 @@ func New(
 
 		timeout:     timeout,
 		backoff:     common.NewBackoff(nil, waitRetry, maxWaitRetry),
+		health:      mode.NewHealthTracker(),
 		maxAttempts: maxAttempts,
 	}
 
@@ -65,6 +67,7 @@ func (s *Mode) connect() error {
 // Close closes the underlying connection.
 func (s *Mode) Close() error {
 	s.closed = true
+	s.health.Close()
 	return s.closeClient()
 }
 
@@ -149,11 +152,13 @@ func (s *Mode) publish(
 
 		debugf("send completed")
 		s.backoff.Reset()
+		s.health.PublishSucceeded()
 		op.SigCompleted(signaler)
 		return nil
 
 	sendFail:
 		debugf("send fail")
+		s.health.PublishFailed()
 
 		fails++
 		if resetFail {
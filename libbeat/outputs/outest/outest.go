@@ -0,0 +1,39 @@
+// Package outest provides helpers for testing output plugins.
+package outest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	bt "github.com/elastic/beats/libbeat/testing"
+)
+
+// RunTest runs the tester registered for the output with the settings, like
+// the `test output` command does. It returns the driver and the steps
+// printed.
+func RunTest(
+	t *testing.T,
+	name string,
+	settings map[string]interface{},
+) (*bt.ConsoleDriver, string) {
+	cfg, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tester := outputs.FindOutputTester(name)
+	if tester == nil {
+		t.Fatalf("no tester registered for output %v", name)
+	}
+	testable, err := tester("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	d := bt.NewConsoleDriver(&buf)
+	testable.Test(d)
+	return d, buf.String()
+}
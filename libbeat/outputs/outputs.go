@@ -4,6 +4,7 @@ import (
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/op"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/testing"
 )
 
 type Options struct {
@@ -80,6 +81,38 @@ func FindOutputPlugin(name string) OutputBuilder {
 	return outputsPlugins[name]
 }
 
+// OutputChecker validates the configuration of an output plugin, without
+// creating the output or connecting to any host.
+type OutputChecker func(beatName string, config *common.Config) error
+
+var outputsCheckers = make(map[string]OutputChecker)
+
+// RegisterOutputChecker registers the checker used by the `config check`
+// command to validate the settings of the output plugin.
+func RegisterOutputChecker(name string, checker OutputChecker) {
+	outputsCheckers[name] = checker
+}
+
+func FindOutputChecker(name string) OutputChecker {
+	return outputsCheckers[name]
+}
+
+// OutputTester creates the Testable checking the connection to the hosts of an
+// output plugin, without creating the output.
+type OutputTester func(beatName string, config *common.Config) (testing.Testable, error)
+
+var outputsTesters = make(map[string]OutputTester)
+
+// RegisterOutputTester registers the tester used by the `test output` command
+// for the output plugin.
+func RegisterOutputTester(name string, tester OutputTester) {
+	outputsTesters[name] = tester
+}
+
+func FindOutputTester(name string) OutputTester {
+	return outputsTesters[name]
+}
+
 func InitOutputs(
 	beatName string,
 	configs map[string]*common.Config,
@@ -2,13 +2,16 @@ package redis
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
 
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 	"github.com/elastic/beats/libbeat/outputs/outil"
@@ -32,6 +35,12 @@ type client struct {
 	password string
 	publish  publishFn
 	codec    outputs.Codec
+	stream   streamSettings
+
+	// sentinel is used to discover the current master if configured. The
+	// transport client is replaced whenever the master changes.
+	sentinel   *sentinel
+	masterAddr string
 }
 
 type redisDataType uint16
@@ -39,9 +48,30 @@ type redisDataType uint16
 const (
 	redisListType redisDataType = iota
 	redisChannelType
+	redisStreamType
 )
 
-func newClient(tc *transport.Client, pass string, db int, key outil.Selector, dt redisDataType, codec outputs.Codec) *client {
+// streamSettings configures how events are added to a Redis stream.
+type streamSettings struct {
+	maxLen      int
+	approximate bool
+	fields      []streamField
+}
+
+type streamField struct {
+	name  string
+	value *fmtstr.EventFormatString
+}
+
+func newClient(
+	tc *transport.Client,
+	pass string,
+	db int,
+	key outil.Selector,
+	dt redisDataType,
+	codec outputs.Codec,
+	stream streamSettings,
+) *client {
 	return &client{
 		Client:   tc,
 		password: pass,
@@ -49,11 +79,37 @@ func newClient(tc *transport.Client, pass string, db int, key outil.Selector, dt
 		dataType: dt,
 		key:      key,
 		codec:    codec,
+		stream:   stream,
+	}
+}
+
+func newStreamSettings(cfg streamConfig) streamSettings {
+	names := make([]string, 0, len(cfg.Fields))
+	for name := range cfg.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]streamField, len(names))
+	for i, name := range names {
+		fields[i] = streamField{name: name, value: cfg.Fields[name]}
+	}
+
+	return streamSettings{
+		maxLen:      cfg.MaxLen,
+		approximate: cfg.Approximate,
+		fields:      fields,
 	}
 }
 
 func (c *client) Connect(to time.Duration) error {
 	debugf("connect")
+	if c.sentinel != nil {
+		if err := c.resolveMaster(); err != nil {
+			return err
+		}
+	}
+
 	err := c.Client.Connect()
 	if err != nil {
 		return err
@@ -66,12 +122,46 @@ func (c *client) Connect(to time.Duration) error {
 		}
 	}()
 
-	if err = initRedisConn(conn, c.password, c.db); err == nil {
-		c.publish, err = makePublish(conn, c.key, c.dataType, c.codec)
+	if err = initRedisConn(conn, c.password, c.db); err != nil {
+		return err
 	}
+
+	if c.sentinel != nil {
+		if err = checkMasterRole(conn); err != nil {
+			return err
+		}
+	}
+
+	c.publish, err = makePublish(conn, c.key, c.dataType, c.codec, c.stream)
 	return err
 }
 
+// resolveMaster asks the sentinels for the current master address, replacing
+// the transport client if the master has changed since the last connect.
+func (c *client) resolveMaster() error {
+	addr, err := c.sentinel.masterAddr()
+	if err != nil {
+		return err
+	}
+
+	if c.Client != nil && addr == c.masterAddr {
+		return nil
+	}
+
+	tc, err := transport.NewClient(c.sentinel.transp, "tcp", addr, 0)
+	if err != nil {
+		return err
+	}
+
+	if c.Client != nil {
+		logp.Info("Redis master changed from %v to %v", c.masterAddr, addr)
+		c.Client.Close()
+	}
+	c.Client = tc
+	c.masterAddr = addr
+	return nil
+}
+
 func initRedisConn(c redis.Conn, pwd string, db int) error {
 	if pwd != "" {
 		if _, err := c.Do("AUTH", pwd); err != nil {
@@ -94,6 +184,9 @@ func initRedisConn(c redis.Conn, pwd string, db int) error {
 
 func (c *client) Close() error {
 	debugf("close connection")
+	if c.Client == nil {
+		return nil
+	}
 	return c.Client.Close()
 }
 
@@ -111,9 +204,13 @@ func makePublish(
 	key outil.Selector,
 	dt redisDataType,
 	codec outputs.Codec,
+	stream streamSettings,
 ) (publishFn, error) {
-	if dt == redisChannelType {
+	switch dt {
+	case redisChannelType:
 		return makePublishPUBLISH(conn, codec)
+	case redisStreamType:
+		return makePublishXADD(conn, codec, stream)
 	}
 	return makePublishRPUSH(conn, key, codec)
 }
@@ -124,27 +221,7 @@ func makePublishRPUSH(conn redis.Conn, key outil.Selector, codec outputs.Codec)
 		return publishEventsPipeline(conn, "RPUSH", codec), nil
 	}
 
-	var major, minor int
-	var versionRaw [][]byte
-
-	respRaw, err := conn.Do("INFO")
-	resp, err := redis.Bytes(respRaw, err)
-	if err != nil {
-		return nil, err
-	}
-
-	versionRaw = versionRegex.FindSubmatch(resp)
-	if versionRaw == nil {
-		err = errors.New("unable to read redis_version")
-		return nil, err
-	}
-
-	major, err = strconv.Atoi(string(versionRaw[1]))
-	if err != nil {
-		return nil, err
-	}
-
-	minor, err = strconv.Atoi(string(versionRaw[2]))
+	major, minor, err := serverVersion(conn)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +242,46 @@ func makePublishRPUSH(conn redis.Conn, key outil.Selector, codec outputs.Codec)
 	return publishEventsPipeline(conn, "RPUSH", codec), nil
 }
 
+func makePublishXADD(conn redis.Conn, codec outputs.Codec, stream streamSettings) (publishFn, error) {
+	major, _, err := serverVersion(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Streams have been introduced with Redis 5.0.
+	// See: https://redis.io/commands/xadd
+	if major < 5 {
+		return nil, errors.New("the xadd data type requires Redis 5.0 or newer")
+	}
+	return publishEventsXADD(conn, codec, stream), nil
+}
+
+// serverVersion reads the major and minor version of the connected server.
+func serverVersion(conn redis.Conn) (int, int, error) {
+	respRaw, err := conn.Do("INFO")
+	resp, err := redis.Bytes(respRaw, err)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	versionRaw := versionRegex.FindSubmatch(resp)
+	if versionRaw == nil {
+		return 0, 0, errors.New("unable to read redis_version")
+	}
+
+	major, err := strconv.Atoi(string(versionRaw[1]))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	minor, err := strconv.Atoi(string(versionRaw[2]))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return major, minor, nil
+}
+
 func makePublishPUBLISH(conn redis.Conn, codec outputs.Codec) (publishFn, error) {
 	return publishEventsPipeline(conn, "PUBLISH", codec), nil
 }
@@ -202,6 +319,7 @@ func publishEventsPipeline(conn redis.Conn, command string, codec outputs.Codec)
 		}
 
 		data = okEvents[:0]
+		args := make([][]interface{}, 0, len(serialized))
 		for i, serializedEvent := range serialized {
 			eventKey, err := key.Select(okEvents[i].Event)
 			if err != nil {
@@ -210,37 +328,117 @@ func publishEventsPipeline(conn redis.Conn, command string, codec outputs.Codec)
 			}
 
 			data = append(data, okEvents[i])
-			if err := conn.Send(command, eventKey, serializedEvent); err != nil {
-				logp.Err("Failed to execute %v: %v", command, err)
-				return okEvents, err
+			args = append(args, []interface{}{eventKey, serializedEvent})
+		}
+
+		return sendPipeline(conn, command, data, args)
+	}
+}
+
+func publishEventsXADD(conn redis.Conn, codec outputs.Codec, stream streamSettings) publishFn {
+	return func(key outil.Selector, data []outputs.Data) ([]outputs.Data, error) {
+		okEvents := make([]outputs.Data, 0, len(data))
+		args := make([][]interface{}, 0, len(data))
+		for _, d := range data {
+			eventKey, err := key.Select(d.Event)
+			if err != nil {
+				logp.Err("Failed to set redis key: %v", err)
+				continue
+			}
+
+			eventArgs, err := xaddArgs(eventKey, d.Event, codec, stream)
+			if err != nil {
+				logp.Err("Dropping event, failed to build stream entry: %v", err)
+				continue
 			}
+
+			okEvents = append(okEvents, d)
+			args = append(args, eventArgs)
 		}
 
-		if err := conn.Flush(); err != nil {
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return sendPipeline(conn, "XADD", okEvents, args)
+	}
+}
+
+// xaddArgs creates the XADD arguments for adding one event to the stream
+// key. If no fields are configured the encoded event is stored in the
+// `event` field.
+func xaddArgs(
+	key string,
+	event common.MapStr,
+	codec outputs.Codec,
+	stream streamSettings,
+) ([]interface{}, error) {
+	args := []interface{}{key}
+	if stream.maxLen > 0 {
+		args = append(args, "MAXLEN")
+		if stream.approximate {
+			args = append(args, "~")
+		}
+		args = append(args, stream.maxLen)
+	}
+	args = append(args, "*")
+
+	if len(stream.fields) == 0 {
+		serialized, err := codec.Encode(event)
+		if err != nil {
+			return nil, err
+		}
+		return append(args, "event", serialized), nil
+	}
+
+	for _, field := range stream.fields {
+		value, err := field.value.Run(event)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %v", field.name, err)
+		}
+		args = append(args, field.name, value)
+	}
+	return args, nil
+}
+
+// sendPipeline sends one command per entry in args, returning the events
+// which failed to be published.
+func sendPipeline(
+	conn redis.Conn,
+	command string,
+	data []outputs.Data,
+	args [][]interface{},
+) ([]outputs.Data, error) {
+	for _, cmdArgs := range args {
+		if err := conn.Send(command, cmdArgs...); err != nil {
+			logp.Err("Failed to execute %v: %v", command, err)
 			return data, err
 		}
+	}
 
-		failed := data[:0]
-		var lastErr error
-		for i := range serialized {
-			_, err := conn.Receive()
-			if err != nil {
-				if _, ok := err.(redis.Error); ok {
-					logp.Err("Failed to %v event to list with %v",
-						command, err)
-					failed = append(failed, data[i])
-					lastErr = err
-				} else {
-					logp.Err("Failed to %v multiple events to list with %v",
-						command, err)
-					failed = append(failed, data[i:]...)
-					lastErr = err
-					break
-				}
+	if err := conn.Flush(); err != nil {
+		return data, err
+	}
+
+	failed := data[:0]
+	var lastErr error
+	for i := range args {
+		_, err := conn.Receive()
+		if err != nil {
+			if _, ok := err.(redis.Error); ok {
+				logp.Err("Failed to %v event to list with %v",
+					command, err)
+				failed = append(failed, data[i])
+				lastErr = err
+			} else {
+				logp.Err("Failed to %v multiple events to list with %v",
+					command, err)
+				failed = append(failed, data[i:]...)
+				lastErr = err
+				break
 			}
 		}
-		return failed, lastErr
 	}
+	return failed, lastErr
 }
 
 func serializeEvents(
@@ -0,0 +1,197 @@
+// +build !integration
+
+package redis
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codecs/json"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+const testTimeout = 5 * time.Second
+
+var testTransport = &transport.Config{Timeout: testTimeout}
+
+func testEvents(keys ...string) []outputs.Data {
+	data := make([]outputs.Data, len(keys))
+	for i, key := range keys {
+		data[i] = outputs.Data{Event: common.MapStr{"key": key, "n": i}}
+	}
+	return data
+}
+
+func TestKeySlot(t *testing.T) {
+	assert.Equal(t, 12739, keySlot("123456789"))
+	assert.Equal(t, 12182, keySlot("foo"))
+	assert.Equal(t, 5061, keySlot("bar"))
+	assert.Equal(t, keySlot("user1000"), keySlot("{user1000}.following"))
+	assert.Equal(t, keySlot("{user1000}.following"), keySlot("{user1000}.followers"))
+
+	// empty hash tags are ignored
+	assert.Equal(t, int(crc16([]byte("foo{}{bar}"))%clusterSlots), keySlot("foo{}{bar}"))
+}
+
+func TestXADDArgs(t *testing.T) {
+	event := common.MapStr{"message": "hello", "app": "a"}
+	codec := json.New(false)
+
+	args, err := xaddArgs("stream", event, codec, streamSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"stream", "*", "event", []byte(`{"app":"a","message":"hello"}`)}, args)
+
+	stream := newStreamSettings(streamConfig{
+		MaxLen:      100,
+		Approximate: true,
+		Fields: map[string]*fmtstr.EventFormatString{
+			"msg": fmtstr.MustCompileEvent("%{[message]}"),
+			"app": fmtstr.MustCompileEvent("%{[app]}"),
+		},
+	})
+	args, err = xaddArgs("stream", event, codec, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"stream", "MAXLEN", "~", 100, "*", "app", "a", "msg", "hello"}, args)
+
+	stream.approximate = false
+	delete(event, "app")
+	_, err = xaddArgs("stream", event, codec, stream)
+	assert.Error(t, err)
+}
+
+func TestPublishXADD(t *testing.T) {
+	server := newStubServer(t)
+	defer server.Close()
+
+	tc, err := transport.NewClient(testTransport, "tcp", server.Addr(), 6379)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := outil.MakeSelector(outil.ConstSelectorExpr("events"))
+	stream := streamSettings{maxLen: 10}
+	client := newClient(tc, "", 0, key, redisStreamType, json.New(false), stream)
+	if err := client.Connect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	failed, err := client.PublishEvents(testEvents("a", "b"))
+	assert.NoError(t, err)
+	assert.Len(t, failed, 0)
+
+	entries := server.stream("events")
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, []string{"MAXLEN", "10", "*", "event", `{"key":"a","n":0}`}, entries[0])
+	}
+
+	// streams are not supported by older Redis versions
+	server.setVersion("3.2.4")
+	assert.Error(t, client.Connect(testTimeout))
+}
+
+func TestSentinelFailover(t *testing.T) {
+	master := newStubServer(t)
+	defer master.Close()
+	replica := newStubServer(t)
+	defer replica.Close()
+	sentinelServer := newStubServer(t)
+	defer sentinelServer.Close()
+
+	sentinelServer.setMaster("mymaster", master.Addr())
+
+	key := outil.MakeSelector(outil.ConstSelectorExpr("list"))
+	client := newClient(nil, "", 0, key, redisListType, json.New(false), streamSettings{})
+	client.sentinel = newSentinel(
+		[]string{"127.0.0.1:1", sentinelServer.Addr()}, 26379,
+		sentinelConfig{MasterName: "mymaster"}, testTransport, testTimeout)
+
+	if err := client.Connect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err := client.PublishEvents(testEvents("a"))
+	assert.NoError(t, err)
+	assert.Len(t, master.list("list"), 1)
+
+	// failover: the old master is demoted and writes fail
+	master.setRole("slave")
+	sentinelServer.setMaster("mymaster", replica.Addr())
+
+	failed, err := client.PublishEvents(testEvents("b"))
+	assert.Error(t, err)
+	assert.Len(t, failed, 1)
+
+	// reconnecting picks up the new master
+	client.Close()
+	if err := client.Connect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.PublishEvents(failed)
+	assert.NoError(t, err)
+	assert.Len(t, replica.list("list"), 1)
+	assert.Equal(t, replica.Addr(), client.masterAddr)
+
+	// connecting to a server reporting to be a slave fails
+	sentinelServer.setMaster("mymaster", master.Addr())
+	client.Close()
+	assert.Error(t, client.Connect(testTimeout))
+
+	// unknown master
+	client.sentinel.masterName = "unknown"
+	assert.Error(t, client.Connect(testTimeout))
+}
+
+func TestClusterRouting(t *testing.T) {
+	nodeA := newStubServer(t)
+	defer nodeA.Close()
+	nodeB := newStubServer(t)
+	defer nodeB.Close()
+
+	slots := func(start, end int, addr string) interface{} {
+		host, port, _ := net.SplitHostPort(addr)
+		p, _ := strconv.Atoi(port)
+		return []interface{}{start, end, []interface{}{[]byte(host), p}}
+	}
+	nodeA.slots = []interface{}{
+		slots(0, 8191, nodeA.Addr()),
+		slots(8192, clusterSlots-1, nodeB.Addr()),
+	}
+
+	key := outil.MakeSelector(outil.FmtSelectorExpr(fmtstr.MustCompileEvent("%{[key]}"), ""))
+	client := newClusterClient(
+		[]string{"127.0.0.1:1", nodeA.Addr()}, 6379, testTransport, "",
+		key, redisListType, json.New(false), streamSettings{})
+	if err := client.Connect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	failed, err := client.PublishEvents(testEvents("foo", "bar", "foo"))
+	assert.NoError(t, err)
+	assert.Len(t, failed, 0)
+
+	assert.Equal(t, []string{`{"key":"bar","n":1}`}, nodeA.list("bar"))
+	assert.Equal(t, []string{`{"key":"foo","n":0}`, `{"key":"foo","n":2}`}, nodeB.list("foo"))
+	assert.Len(t, nodeA.list("foo"), 0)
+
+	// slots not covered by any node fail
+	nodeA.slots = nodeA.slots[:1]
+	client.Close()
+	if err := client.Connect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	failed, err = client.PublishEvents(testEvents("foo", "bar"))
+	assert.Equal(t, errSlotNotCovered, err)
+	assert.Len(t, failed, 1)
+	assert.Len(t, nodeA.list("bar"), 2)
+}
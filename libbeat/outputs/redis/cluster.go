@@ -0,0 +1,304 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+// number of hash slots in a Redis Cluster
+const clusterSlots = 16384
+
+// clusterClient publishes events to a Redis Cluster. Events are routed to the
+// master owning the hash slot of the event key. The slot mapping is read from
+// the seed nodes on connect. If the mapping changes (Redis replies with MOVED
+// or ASK), publishing fails and the mapping is reloaded on reconnect.
+type clusterClient struct {
+	seeds    []string
+	port     int
+	transp   *transport.Config
+	password string
+	key      outil.Selector
+	dataType redisDataType
+	codec    outputs.Codec
+	stream   streamSettings
+
+	slots [clusterSlots]*clusterNode
+	nodes map[string]*clusterNode
+}
+
+type clusterNode struct {
+	addr    string
+	client  *transport.Client
+	publish publishFn
+}
+
+type slotRange struct {
+	start, end int
+	addr       string
+}
+
+var errSlotNotCovered = errors.New("redis cluster hash slot not covered by any node")
+
+func newClusterClient(
+	seeds []string,
+	port int,
+	transp *transport.Config,
+	pass string,
+	key outil.Selector,
+	dt redisDataType,
+	codec outputs.Codec,
+	stream streamSettings,
+) *clusterClient {
+	return &clusterClient{
+		seeds:    seeds,
+		port:     port,
+		transp:   transp,
+		password: pass,
+		key:      key,
+		dataType: dt,
+		codec:    codec,
+		stream:   stream,
+	}
+}
+
+func (c *clusterClient) Connect(to time.Duration) error {
+	debugf("connect cluster")
+	c.closeNodes()
+
+	ranges, err := c.loadSlots(to)
+	if err != nil {
+		return err
+	}
+
+	nodes := map[string]*clusterNode{}
+	for _, r := range ranges {
+		node := nodes[r.addr]
+		if node == nil {
+			node, err = c.connectNode(r.addr, to)
+			if err != nil {
+				for _, n := range nodes {
+					n.client.Close()
+				}
+				return err
+			}
+			nodes[r.addr] = node
+		}
+
+		for slot := r.start; slot <= r.end && slot < clusterSlots; slot++ {
+			c.slots[slot] = node
+		}
+	}
+
+	c.nodes = nodes
+	return nil
+}
+
+// loadSlots reads the slot to master mapping from the first seed node
+// answering.
+func (c *clusterClient) loadSlots(to time.Duration) ([]slotRange, error) {
+	var err error
+	for _, seed := range c.seeds {
+		var ranges []slotRange
+		ranges, err = c.querySlots(seed, to)
+		if err == nil {
+			return ranges, nil
+		}
+		debugf("Failed to load cluster slots from %v: %v", seed, err)
+	}
+	return nil, fmt.Errorf("failed to load redis cluster slots: %v", err)
+}
+
+func (c *clusterClient) querySlots(seed string, to time.Duration) ([]slotRange, error) {
+	tc, err := transport.NewClient(c.transp, "tcp", seed, c.port)
+	if err != nil {
+		return nil, err
+	}
+	if err := tc.Connect(); err != nil {
+		return nil, err
+	}
+
+	conn := redis.NewConn(tc, to, to)
+	defer conn.Close()
+
+	if err := initRedisConn(conn, c.password, 0); err != nil {
+		return nil, err
+	}
+
+	reply, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return nil, err
+	}
+	return parseClusterSlots(reply)
+}
+
+// parseClusterSlots parses the CLUSTER SLOTS reply. Each entry contains the
+// slot range start and end followed by the master and its replicas, each
+// given as [ip, port, ...].
+func parseClusterSlots(reply []interface{}) ([]slotRange, error) {
+	ranges := make([]slotRange, 0, len(reply))
+	for _, entry := range reply {
+		fields, err := redis.Values(entry, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid cluster slots entry: %v", fields)
+		}
+
+		start, err := redis.Int(fields[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		end, err := redis.Int(fields[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		master, err := redis.Values(fields[2], nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(master) < 2 {
+			return nil, fmt.Errorf("invalid cluster node entry: %v", master)
+		}
+		ip, err := redis.String(master[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		port, err := redis.Int(master[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, slotRange{
+			start: start,
+			end:   end,
+			addr:  net.JoinHostPort(ip, strconv.Itoa(port)),
+		})
+	}
+	return ranges, nil
+}
+
+func (c *clusterClient) connectNode(addr string, to time.Duration) (*clusterNode, error) {
+	tc, err := transport.NewClient(c.transp, "tcp", addr, c.port)
+	if err != nil {
+		return nil, err
+	}
+	if err = tc.Connect(); err != nil {
+		return nil, err
+	}
+
+	conn := redis.NewConn(tc, to, to)
+	if err = initRedisConn(conn, c.password, 0); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	publish, err := makePublish(conn, c.key, c.dataType, c.codec, c.stream)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &clusterNode{addr: addr, client: tc, publish: publish}, nil
+}
+
+func (c *clusterClient) Close() error {
+	debugf("close cluster connections")
+	c.closeNodes()
+	return nil
+}
+
+func (c *clusterClient) closeNodes() {
+	for _, node := range c.nodes {
+		node.client.Close()
+	}
+	c.nodes = nil
+	for i := range c.slots {
+		c.slots[i] = nil
+	}
+}
+
+func (c *clusterClient) PublishEvent(data outputs.Data) error {
+	_, err := c.PublishEvents([]outputs.Data{data})
+	return err
+}
+
+func (c *clusterClient) PublishEvents(data []outputs.Data) ([]outputs.Data, error) {
+	var failed []outputs.Data
+	var lastErr error
+
+	// group events by node, keeping the order of events per node
+	var order []*clusterNode
+	groups := map[*clusterNode][]outputs.Data{}
+	for _, d := range data {
+		key, err := c.key.Select(d.Event)
+		if err != nil {
+			logp.Err("Failed to set redis key: %v", err)
+			continue
+		}
+
+		node := c.slots[keySlot(key)]
+		if node == nil {
+			failed = append(failed, d)
+			lastErr = errSlotNotCovered
+			continue
+		}
+
+		if _, exists := groups[node]; !exists {
+			order = append(order, node)
+		}
+		groups[node] = append(groups[node], d)
+	}
+
+	for _, node := range order {
+		rest, err := node.publish(c.key, groups[node])
+		if err != nil {
+			logp.Err("Failed to publish events to cluster node %v: %v", node.addr, err)
+			failed = append(failed, rest...)
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// keySlot computes the Redis Cluster hash slot of a key. If the key contains
+// a non-empty hash tag like `{user1000}.following`, only the tag is hashed.
+//
+// See: https://redis.io/topics/cluster-spec#keys-hash-tags
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16([]byte(key)) % clusterSlots)
+}
+
+// crc16 implements CRC16-CCITT (XMODEM) as used by Redis Cluster.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
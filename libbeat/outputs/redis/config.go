@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 	"github.com/elastic/beats/libbeat/outputs/transport"
@@ -21,8 +22,11 @@ type redisConfig struct {
 	TLS         *outputs.TLSConfig    `config:"ssl"`
 	Proxy       transport.ProxyConfig `config:",inline"`
 
-	Db       int    `config:"db"`
-	DataType string `config:"datatype"`
+	Db       int            `config:"db"`
+	DataType string         `config:"datatype"`
+	Stream   streamConfig   `config:"stream"`
+	Sentinel sentinelConfig `config:"sentinel"`
+	Cluster  bool           `config:"cluster"`
 
 	HostTopology     string              `config:"host_topology"`
 	PasswordTopology string              `config:"password_topology"`
@@ -30,15 +34,33 @@ type redisConfig struct {
 	Codec            outputs.CodecConfig `config:"codec"`
 }
 
+type streamConfig struct {
+	MaxLen      int                                  `config:"maxlen" validate:"min=0"`
+	Approximate bool                                 `config:"approximate"`
+	Fields      map[string]*fmtstr.EventFormatString `config:"fields"`
+}
+
+type sentinelConfig struct {
+	MasterName string `config:"master_name"`
+	Password   string `config:"password"`
+	Port       int    `config:"port"`
+}
+
 var (
 	defaultConfig = redisConfig{
-		Port:             6379,
-		LoadBalance:      true,
-		Timeout:          5 * time.Second,
-		MaxRetries:       3,
-		TLS:              nil,
-		Db:               0,
-		DataType:         "list",
+		Port:        6379,
+		LoadBalance: true,
+		Timeout:     5 * time.Second,
+		MaxRetries:  3,
+		TLS:         nil,
+		Db:          0,
+		DataType:    "list",
+		Stream: streamConfig{
+			Approximate: true,
+		},
+		Sentinel: sentinelConfig{
+			Port: 26379,
+		},
 		HostTopology:     "",
 		PasswordTopology: "",
 		DbTopology:       1,
@@ -47,11 +69,19 @@ var (
 
 func (c *redisConfig) Validate() error {
 	switch c.DataType {
-	case "", "list", "channel":
+	case "", "list", "channel", "xadd":
 	default:
 		return fmt.Errorf("redis data type %v not supported", c.DataType)
 	}
 
+	if c.Cluster && c.Sentinel.MasterName != "" {
+		return errors.New("Cannot use both `output.redis.cluster` and `output.redis.sentinel`")
+	}
+
+	if c.Cluster && c.Db != 0 {
+		return errors.New("Redis Cluster only supports database 0, `output.redis.db` must not be set")
+	}
+
 	if c.Key != "" && c.Index != "" {
 		return errors.New("Cannot use both `output.redis.key` and `output.redis.index` configuration options." +
 			" Set only `output.redis.key`")
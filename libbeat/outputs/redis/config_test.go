@@ -20,6 +20,12 @@ func TestValidate(t *testing.T) {
 		{"Invalid Datatype", redisConfig{Key: "test", DataType: "something"}, false},
 		{"List Datatype", redisConfig{Key: "test", DataType: "list"}, true},
 		{"Channel Datatype", redisConfig{Key: "test", DataType: "channel"}, true},
+		{"XADD Datatype", redisConfig{Key: "test", DataType: "xadd"}, true},
+
+		{"Sentinel", redisConfig{Key: "test", Sentinel: sentinelConfig{MasterName: "mymaster"}}, true},
+		{"Cluster", redisConfig{Key: "test", Cluster: true}, true},
+		{"Cluster and Sentinel", redisConfig{Key: "test", Cluster: true, Sentinel: sentinelConfig{MasterName: "mymaster"}}, false},
+		{"Cluster with db", redisConfig{Key: "test", Cluster: true, Db: 1}, false},
 	}
 
 	for _, test := range tests {
@@ -19,6 +19,11 @@ type redisOut struct {
 	mode mode.ConnectionMode
 	topology
 	beatName string
+
+	// settings required by Test
+	config redisConfig
+	hosts  []string
+	transp *transport.Config
 }
 
 var debugf = logp.MakeDebug("redis")
@@ -38,6 +43,8 @@ const (
 
 func init() {
 	outputs.RegisterOutputPlugin("redis", new)
+	outputs.RegisterOutputChecker("redis", checkConfig)
+	outputs.RegisterOutputTester("redis", newTester)
 }
 
 func new(beatName string, cfg *common.Config, expireTopo int) (outputs.Outputer, error) {
@@ -48,35 +55,69 @@ func new(beatName string, cfg *common.Config, expireTopo int) (outputs.Outputer,
 	return r, nil
 }
 
+// checkConfig validates the output settings and creates the clients, without
+// connecting them.
+func checkConfig(beatName string, cfg *common.Config) error {
+	r := &redisOut{beatName: beatName}
+	_, err := r.configure(cfg, 0)
+	return err
+}
+
 func (r *redisOut) init(cfg *common.Config, expireTopo int) error {
-	config := defaultConfig
-	if err := cfg.Unpack(&config); err != nil {
+	clients, err := r.configure(cfg, expireTopo)
+	if err != nil {
 		return err
 	}
 
-	sendRetries := config.MaxRetries
-	maxAttempts := config.MaxRetries + 1
+	sendRetries := r.config.MaxRetries
+	maxAttempts := r.config.MaxRetries + 1
 	if sendRetries < 0 {
 		maxAttempts = 0
 	}
 
+	logp.Info("Max Retries set to: %v", sendRetries)
+	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
+		Failover:     !r.config.LoadBalance,
+		MaxAttempts:  maxAttempts,
+		Timeout:      r.config.Timeout,
+		WaitRetry:    defaultWaitRetry,
+		MaxWaitRetry: defaultMaxWaitRetry,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mode = m
+	return nil
+}
+
+// configure reads the settings and creates the clients, but does not connect
+// them.
+func (r *redisOut) configure(cfg *common.Config, expireTopo int) ([]mode.ProtocolClient, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
 	var dataType redisDataType
 	switch config.DataType {
 	case "", "list":
 		dataType = redisListType
 	case "channel":
 		dataType = redisChannelType
+	case "xadd":
+		dataType = redisStreamType
 	default:
-		return errors.New("Bad Redis data type")
+		return nil, errors.New("Bad Redis data type")
 	}
 
 	if cfg.HasField("index") && !cfg.HasField("key") {
 		s, err := cfg.String("index", -1)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if err := cfg.SetString("key", -1, s); err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if !cfg.HasField("key") {
@@ -90,12 +131,12 @@ func (r *redisOut) init(cfg *common.Config, expireTopo int) error {
 		FailEmpty:        true,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tls, err := outputs.LoadTLSConfig(config.TLS)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	transp := &transport.Config{
@@ -118,39 +159,79 @@ func (r *redisOut) init(cfg *common.Config, expireTopo int) error {
 		expire:   time.Duration(expireTopo) * time.Second,
 	})
 
+	codec, err := outputs.CreateEncoder(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	stream := newStreamSettings(config.Stream)
+
 	// configure publisher clients
-	clients, err := modeutil.MakeClients(cfg, func(host string) (mode.ProtocolClient, error) {
+	var clients []mode.ProtocolClient
+	switch {
+	case config.Cluster:
+		clients, err = makeSharedHostsClients(cfg, func(hosts []string) mode.ProtocolClient {
+			return newClusterClient(hosts, config.Port, transp, config.Password,
+				key, dataType, codec, stream)
+		})
+	case config.Sentinel.MasterName != "":
+		clients, err = makeSharedHostsClients(cfg, func(hosts []string) mode.ProtocolClient {
+			c := newClient(nil, config.Password, config.Db, key, dataType, codec, stream)
+			c.sentinel = newSentinel(hosts, config.Sentinel.Port, config.Sentinel,
+				transp, config.Timeout)
+			return c
+		})
+	default:
+		clients, err = modeutil.MakeClients(cfg, func(host string) (mode.ProtocolClient, error) {
+			t, err := transport.NewClient(transp, "tcp", host, config.Port)
+			if err != nil {
+				return nil, err
+			}
+
+			return newClient(t, config.Password, config.Db, key, dataType, codec, stream), nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		t, err := transport.NewClient(transp, "tcp", host, config.Port)
-		if err != nil {
-			return nil, err
-		}
+	hosts := struct {
+		Hosts []string `config:"hosts"`
+	}{}
+	if err := cfg.Unpack(&hosts); err != nil {
+		return nil, err
+	}
 
-		codec, err := outputs.CreateEncoder(config.Codec)
-		if err != nil {
-			return nil, err
-		}
+	r.config = config
+	r.hosts = hosts.Hosts
+	r.transp = transp
+	return clients, nil
+}
 
-		return newClient(t, config.Password, config.Db, key, dataType, codec), nil
-	})
-	if err != nil {
-		return err
+// makeSharedHostsClients creates `worker` clients, each one using all
+// configured hosts. Used if hosts are cluster seed nodes or sentinels instead
+// of independent Redis servers.
+func makeSharedHostsClients(
+	cfg *common.Config,
+	newClient func(hosts []string) mode.ProtocolClient,
+) ([]mode.ProtocolClient, error) {
+	config := struct {
+		Hosts  []string `config:"hosts"  validate:"required"`
+		Worker int      `config:"worker" validate:"min=1"`
+	}{
+		Worker: 1,
 	}
-
-	logp.Info("Max Retries set to: %v", sendRetries)
-	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
-		Failover:     !config.LoadBalance,
-		MaxAttempts:  maxAttempts,
-		Timeout:      config.Timeout,
-		WaitRetry:    defaultWaitRetry,
-		MaxWaitRetry: defaultMaxWaitRetry,
-	})
-	if err != nil {
-		return err
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	if len(config.Hosts) == 0 {
+		return nil, mode.ErrNoHostsConfigured
 	}
 
-	r.mode = m
-	return nil
+	clients := make([]mode.ProtocolClient, config.Worker)
+	for i := range clients {
+		clients[i] = newClient(config.Hosts)
+	}
+	return clients, nil
 }
 
 func (r *redisOut) Close() error {
@@ -0,0 +1,53 @@
+// +build !integration
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/outputs/outest"
+)
+
+func TestOutputTest(t *testing.T) {
+	server := newStubServer(t)
+	defer server.Close()
+
+	d, out := outest.RunTest(t, "redis", map[string]interface{}{
+		"hosts":    []string{server.Addr()},
+		"password": "secret",
+		"db":       2,
+	})
+	assert.False(t, d.Failed(), out)
+	assert.Contains(t, out, "redis: "+server.Addr()+"...")
+	assert.Contains(t, out, "authenticate... OK")
+	assert.Contains(t, out, "ping reply: PONG")
+	assert.Contains(t, out, "select db 2... OK")
+	assert.Contains(t, out, "version: 5.0")
+}
+
+func TestOutputTestSentinel(t *testing.T) {
+	master := newStubServer(t)
+	defer master.Close()
+	sentinelServer := newStubServer(t)
+	defer sentinelServer.Close()
+
+	sentinelServer.setMaster("mymaster", master.Addr())
+
+	d, out := outest.RunTest(t, "redis", map[string]interface{}{
+		"hosts":                []string{sentinelServer.Addr()},
+		"sentinel.master_name": "mymaster",
+	})
+	assert.False(t, d.Failed(), out)
+	assert.Contains(t, out, "master: "+master.Addr())
+	assert.Contains(t, out, "ping reply: PONG")
+}
+
+func TestOutputTestFailure(t *testing.T) {
+	d, out := outest.RunTest(t, "redis", map[string]interface{}{
+		"hosts": []string{"127.0.0.1:1"},
+	})
+	assert.True(t, d.Failed())
+	assert.Contains(t, out, "dial up... ERROR")
+}
@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+// sentinel discovers the address of the current master from a set of Redis
+// Sentinel instances.
+type sentinel struct {
+	masterName string
+	password   string
+	timeout    time.Duration
+
+	// transp is used to connect to the master. Sentinels are connected to
+	// using the same settings.
+	transp *transport.Config
+
+	mutex sync.Mutex
+	hosts []string
+}
+
+var errNoSentinel = errors.New("no redis sentinel available")
+
+func newSentinel(
+	hosts []string,
+	port int,
+	cfg sentinelConfig,
+	transp *transport.Config,
+	timeout time.Duration,
+) *sentinel {
+	addrs := make([]string, len(hosts))
+	for i, host := range hosts {
+		addrs[i] = host
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			addrs[i] = net.JoinHostPort(host, fmt.Sprint(port))
+		}
+	}
+
+	return &sentinel{
+		masterName: cfg.MasterName,
+		password:   cfg.Password,
+		timeout:    timeout,
+		transp:     transp,
+		hosts:      addrs,
+	}
+}
+
+// masterAddr queries the sentinels in order for the master address. The first
+// sentinel answering is moved to the front of the list, so it is asked first
+// on the next call.
+func (s *sentinel) masterAddr() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := errNoSentinel
+	for i, host := range s.hosts {
+		var addr string
+		addr, err = s.queryMaster(host)
+		if err != nil {
+			debugf("Failed to query sentinel %v: %v", host, err)
+			continue
+		}
+
+		copy(s.hosts[1:i+1], s.hosts[:i])
+		s.hosts[0] = host
+		debugf("Sentinel %v reports master %v at %v", host, s.masterName, addr)
+		return addr, nil
+	}
+
+	return "", fmt.Errorf("failed to discover redis master '%v': %v", s.masterName, err)
+}
+
+func (s *sentinel) queryMaster(host string) (string, error) {
+	dialOpts := []redis.DialOption{
+		redis.DialConnectTimeout(s.timeout),
+		redis.DialReadTimeout(s.timeout),
+		redis.DialWriteTimeout(s.timeout),
+	}
+	if s.password != "" {
+		dialOpts = append(dialOpts, redis.DialPassword(s.password))
+	}
+	if s.transp != nil {
+		d, err := transport.MakeDialer(s.transp)
+		if err != nil {
+			return "", err
+		}
+		dialOpts = append(dialOpts, redis.DialNetDial(d.Dial))
+	}
+
+	conn, err := redis.Dial("tcp", host, dialOpts...)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	reply, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", s.masterName))
+	if err == redis.ErrNil {
+		return "", fmt.Errorf("unknown master '%v'", s.masterName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(reply) != 2 {
+		return "", fmt.Errorf("unexpected sentinel reply: %v", reply)
+	}
+
+	return net.JoinHostPort(reply[0], reply[1]), nil
+}
+
+// checkMasterRole verifies the connected server being a master. The sentinels
+// might still report an old master while a failover is in progress.
+func checkMasterRole(conn redis.Conn) error {
+	reply, err := redis.Values(conn.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return errors.New("empty reply to ROLE command")
+	}
+
+	role, err := redis.String(reply[0], nil)
+	if err != nil {
+		return err
+	}
+	if role != "master" {
+		return fmt.Errorf("redis server has role '%v', but master is required", role)
+	}
+	return nil
+}
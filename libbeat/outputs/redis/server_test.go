@@ -0,0 +1,215 @@
+// +build !integration
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubServer is a minimal in-process Redis stand-in speaking RESP. It
+// implements the commands used by the output, the sentinel discovery and the
+// cluster slot lookup.
+type stubServer struct {
+	listener net.Listener
+
+	mutex   sync.Mutex
+	version string
+	role    string
+	lists   map[string][]string
+	streams map[string][][]string
+
+	// sentinel support: master name -> address
+	masters map[string]string
+
+	// cluster support: reply to CLUSTER SLOTS
+	slots []interface{}
+}
+
+type stubError string
+
+func newStubServer(t *testing.T) *stubServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &stubServer{
+		listener: l,
+		version:  "5.0.0",
+		role:     "master",
+		lists:    map[string][]string{},
+		streams:  map[string][][]string{},
+		masters:  map[string]string{},
+	}
+	go s.serve()
+	return s
+}
+
+func (s *stubServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *stubServer) Close() {
+	s.listener.Close()
+}
+
+func (s *stubServer) setVersion(version string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.version = version
+}
+
+func (s *stubServer) setRole(role string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.role = role
+}
+
+func (s *stubServer) setMaster(name, addr string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.masters[name] = addr
+}
+
+func (s *stubServer) list(key string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.lists[key]...)
+}
+
+func (s *stubServer) stream(key string) [][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([][]string(nil), s.streams[key]...)
+}
+
+func (s *stubServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *stubServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		writeReply(w, s.exec(args))
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *stubServer) exec(args []string) interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "PONG"
+	case "AUTH", "SELECT":
+		return "OK"
+	case "INFO":
+		return []byte(fmt.Sprintf("# Server\r\nredis_version:%v\r\n", s.version))
+	case "ROLE":
+		return []interface{}{[]byte(s.role), 0, []interface{}{}}
+	case "RPUSH":
+		if s.role != "master" {
+			return stubError("READONLY You can't write against a read only slave.")
+		}
+		s.lists[args[1]] = append(s.lists[args[1]], args[2:]...)
+		return len(s.lists[args[1]])
+	case "PUBLISH":
+		return 0
+	case "XADD":
+		s.streams[args[1]] = append(s.streams[args[1]], args[2:])
+		return []byte(fmt.Sprintf("%v-0", len(s.streams[args[1]])))
+	case "SENTINEL":
+		addr, exists := s.masters[args[2]]
+		if !exists {
+			return nil
+		}
+		host, port, _ := net.SplitHostPort(addr)
+		return []interface{}{[]byte(host), []byte(port)}
+	case "CLUSTER":
+		return s.slots
+	}
+	return stubError("ERR unknown command '" + args[0] + "'")
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[0] != '*' {
+		return nil, errors.New("invalid command")
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		l, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, l+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:l])
+	}
+	return args, nil
+}
+
+func writeReply(w *bufio.Writer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case string:
+		fmt.Fprintf(w, "+%v\r\n", v)
+	case stubError:
+		fmt.Fprintf(w, "-%v\r\n", string(v))
+	case int:
+		fmt.Fprintf(w, ":%v\r\n", v)
+	case []byte:
+		fmt.Fprintf(w, "$%v\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		fmt.Fprintf(w, "*%v\r\n", len(v))
+		for _, elem := range v {
+			writeReply(w, elem)
+		}
+	default:
+		panic(fmt.Sprintf("unsupported reply type %T", v))
+	}
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+func newTester(beatName string, cfg *common.Config) (testing.Testable, error) {
+	r := &redisOut{beatName: beatName}
+	if _, err := r.configure(cfg, 0); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Test connects to the configured hosts, or to the master reported by the
+// sentinels, and checks authentication and database selection.
+func (r *redisOut) Test(d testing.Driver) {
+	transp := transport.TestingConfig(r.transp)
+
+	if r.config.Sentinel.MasterName != "" {
+		d.Run("redis: sentinel "+r.config.Sentinel.MasterName, func(d testing.Driver) {
+			s := newSentinel(r.hosts, r.config.Sentinel.Port, r.config.Sentinel,
+				transp, r.config.Timeout)
+			addr, err := s.masterAddr()
+			d.Fatal("discover master", err)
+			d.Info("master", addr)
+
+			d.Run("redis: "+addr, func(d testing.Driver) {
+				r.testServer(d, transp, addr)
+			})
+		})
+		return
+	}
+
+	for _, host := range r.hosts {
+		host := host
+		d.Run("redis: "+host, func(d testing.Driver) {
+			r.testServer(d, transp, host)
+		})
+	}
+}
+
+func (r *redisOut) testServer(d testing.Driver, transp *transport.Config, host string) {
+	transport.TestDial(d, transp, "tcp", host, r.config.Port)
+
+	d.Run("talk to server", func(d testing.Driver) {
+		t, err := transport.NewClient(transp, "tcp", host, r.config.Port)
+		d.Fatal("create client", err)
+		d.Fatal("connect", t.Connect())
+		defer t.Close()
+
+		conn := redis.NewConn(t, r.config.Timeout, r.config.Timeout)
+		if r.config.Password != "" {
+			_, err := conn.Do("AUTH", r.config.Password)
+			d.Fatal("authenticate", err)
+		}
+
+		reply, err := redis.String(conn.Do("PING"))
+		d.Fatal("ping", err)
+		d.Info("ping reply", reply)
+
+		if r.config.Db != 0 {
+			_, err := conn.Do("SELECT", r.config.Db)
+			d.Fatal(fmt.Sprintf("select db %v", r.config.Db), err)
+		}
+
+		major, minor, err := serverVersion(conn)
+		d.Error("server version", err)
+		if err == nil {
+			d.Info("version", fmt.Sprintf("%v.%v", major, minor))
+		}
+	})
+}
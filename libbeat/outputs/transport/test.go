@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+// certificateExpiryWarning is the duration before the expiry of the server
+// certificate, from when on TestDial warns about the expiry.
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// TestDial tests connecting to the address step by step, reporting the
+// resolved addresses and, if TLS is configured, the details of the TLS session
+// and the server certificate. No data is sent.
+func TestDial(d testing.Driver, c *Config, network, address string, defaultPort int) {
+	address = fullAddress(address, defaultPort)
+
+	d.Run("connection", func(d testing.Driver) {
+		host, _, err := net.SplitHostPort(address)
+		d.Fatal("parse host", err)
+
+		if c.Proxy != nil && c.Proxy.URL != "" {
+			proxyURL, err := url.Parse(c.Proxy.URL)
+			d.Fatal("parse proxy url", err)
+			d.Info("proxy", proxyURL.Host)
+		}
+		if c.Proxy == nil || c.Proxy.URL == "" || c.Proxy.LocalResolve {
+			addresses, err := net.LookupHost(host)
+			d.Fatal("dns lookup", err)
+			d.Info("addresses", strings.Join(addresses, ", "))
+		}
+
+		conn, err := testDialer(c).Dial(network, address)
+		d.Fatal("dial up", err)
+		conn.Close()
+	})
+
+	if c.TLS == nil {
+		d.Warn("TLS", "secure connection disabled")
+		return
+	}
+
+	d.Run("TLS", func(d testing.Driver) {
+		if c.TLS.Verification == VerifyNone {
+			d.Warn("security", "server's certificate chain verification is disabled")
+		} else {
+			d.Info("security", "server's certificate chain verification is enabled")
+		}
+		if len(c.TLS.Certificates) > 0 {
+			d.Info("client certificate", "configured")
+		}
+
+		socket, err := testDialer(c).Dial(network, address)
+		d.Fatal("dial up", err)
+
+		host, _, _ := net.SplitHostPort(address)
+		conn := tls.Client(socket, c.TLS.BuildModuleConfig(host))
+		defer conn.Close()
+
+		if c.Timeout > 0 {
+			conn.SetDeadline(time.Now().Add(c.Timeout))
+		}
+		d.Fatal("handshake", conn.Handshake())
+		d.Fatal("check TLS version", postVerifyTLSConnection(conn, c.TLS))
+
+		st := conn.ConnectionState()
+		d.Info("TLS version", TLSVersion(st.Version).String())
+		d.Info("cipher suite", fmt.Sprintf("0x%04x", st.CipherSuite))
+		if len(st.PeerCertificates) == 0 {
+			return
+		}
+
+		cert := st.PeerCertificates[0]
+		d.Info("certificate subject", certificateName(cert.Subject))
+		d.Info("certificate issuer", certificateName(cert.Issuer))
+		d.Info("certificate expires", cert.NotAfter.UTC().Format(time.RFC3339))
+		if time.Now().Add(certificateExpiryWarning).After(cert.NotAfter) {
+			d.Warn("certificate expiry", "the server certificate expires in less than 30 days")
+		}
+	})
+}
+
+// TestingConfig returns a copy of the config for the connections opened by
+// tests, which are not accounted in the output metrics.
+func TestingConfig(c *Config) *Config {
+	config := *c
+	config.Stats = nil
+	return &config
+}
+
+// certificateName returns the common name or, if not set, the organization.
+func certificateName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return strings.Join(name.Organization, ", ")
+}
+
+// testDialer creates a dialer connecting via the proxy, if configured, but
+// without TLS.
+func testDialer(c *Config) Dialer {
+	dialer := NetDialer(c.Timeout)
+	if proxied, err := ProxyDialer(c.Proxy, dialer); err == nil {
+		return proxied
+	}
+	return dialer
+}
@@ -0,0 +1,92 @@
+// +build !integration
+
+package transptest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	libtesting "github.com/elastic/beats/libbeat/testing"
+)
+
+// acceptAll accepts connections and runs the TLS handshake, if any, until
+// the listener is closed.
+func acceptAll(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			tlsConn.Handshake()
+		}
+		conn.Close()
+	}
+}
+
+func TestDialTCP(t *testing.T) {
+	server := NewMockServerTCP(t, time.Second, "", nil)
+	defer server.Close()
+	go acceptAll(server.Listener)
+
+	var out bytes.Buffer
+	d := libtesting.NewConsoleDriver(&out)
+	transport.TestDial(d, &transport.Config{Timeout: time.Second}, "tcp", server.Addr(), 0)
+
+	assert.False(t, d.Failed(), out.String())
+	assert.Contains(t, out.String(), "dial up... OK")
+	assert.Contains(t, out.String(), "TLS... WARN secure connection disabled")
+}
+
+func TestDialTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transptest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cert := filepath.Join(dir, "ca_test")
+	require.NoError(t, GenCertsForIPIfMIssing(t, net.IP{127, 0, 0, 1}, cert))
+
+	server := NewMockServerTLS(t, time.Second, cert, nil)
+	defer server.Close()
+	go acceptAll(server.Listener)
+
+	tlsConfig, err := outputs.LoadTLSConfig(&outputs.TLSConfig{
+		CAs: []string{cert + ".pem"},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	d := libtesting.NewConsoleDriver(&out)
+	config := &transport.Config{TLS: tlsConfig, Timeout: time.Second}
+	transport.TestDial(d, config, "tcp", server.Addr(), 0)
+
+	assert.False(t, d.Failed(), out.String())
+	assert.Contains(t, out.String(), "handshake... OK")
+	assert.Contains(t, out.String(), "TLS version: TLSv1.2")
+	assert.Contains(t, out.String(), "certificate subject: elastic")
+}
+
+func TestDialFailure(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	var out bytes.Buffer
+	d := libtesting.NewConsoleDriver(&out)
+	transport.TestDial(d, &transport.Config{Timeout: time.Second}, "tcp", addr, 0)
+
+	assert.True(t, d.Failed())
+	assert.Contains(t, out.String(), "dial up... ERROR")
+}
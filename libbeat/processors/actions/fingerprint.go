@@ -0,0 +1,124 @@
+package actions
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/pierrec/xxHash/xxHash64"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+type fingerprint struct {
+	fields        []string
+	method        string
+	newHash       func() hash.Hash
+	target        string
+	ignoreMissing bool
+}
+
+type fingerprintConfig struct {
+	Fields        []string `config:"fields"`
+	Method        string   `config:"method"`
+	Target        string   `config:"target_field"`
+	IgnoreMissing bool     `config:"ignore_missing"`
+}
+
+var (
+	// The default fields identify a filebeat event by the line read.
+	defaultFingerprintConfig = fingerprintConfig{
+		Fields: []string{"source", "offset", "beat.hostname"},
+		Method: "sha1",
+		Target: "fingerprint",
+	}
+
+	fingerprintMethods = map[string]func() hash.Hash{
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+		"xxhash": func() hash.Hash { return xxHash64.New(0) },
+	}
+)
+
+func init() {
+	processors.RegisterPlugin("fingerprint",
+		configChecked(newFingerprint,
+			allowedFields("fields", "method", "target_field", "ignore_missing", "when")))
+}
+
+func newFingerprint(c common.Config) (processors.Processor, error) {
+	config := defaultFingerprintConfig
+	config.Fields = append([]string(nil), defaultFingerprintConfig.Fields...)
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unpack the fingerprint configuration: %s", err)
+	}
+
+	if len(config.Fields) == 0 {
+		return nil, fmt.Errorf("fingerprint requires at least one field")
+	}
+	if config.Target == "" {
+		return nil, fmt.Errorf("fingerprint requires a target_field")
+	}
+
+	newHash, exists := fingerprintMethods[config.Method]
+	if !exists {
+		return nil, fmt.Errorf("unsupported fingerprint method '%v'", config.Method)
+	}
+
+	f := fingerprint{
+		fields:        config.Fields,
+		method:        config.Method,
+		newHash:       newHash,
+		target:        config.Target,
+		ignoreMissing: config.IgnoreMissing,
+	}
+	return f, nil
+}
+
+// Run hashes the configured fields in order, writing the hex encoded hash into
+// the target field. Each field is hashed with its name, such that moving a
+// value to another field changes the fingerprint.
+func (f fingerprint) Run(event common.MapStr) (common.MapStr, error) {
+	h := f.newHash()
+	for _, field := range f.fields {
+		value, err := event.GetValue(field)
+		if err != nil {
+			if f.ignoreMissing {
+				continue
+			}
+			return event, fmt.Errorf("failed to compute fingerprint: missing field %v", field)
+		}
+
+		encoded, err := encodeFingerprintValue(value)
+		if err != nil {
+			return event, fmt.Errorf("failed to compute fingerprint of field %v: %v", field, err)
+		}
+
+		fmt.Fprintf(h, "%s=", field)
+		h.Write(encoded)
+		h.Write([]byte{0})
+	}
+
+	event.Put(f.target, hex.EncodeToString(h.Sum(nil)))
+	return event, nil
+}
+
+func encodeFingerprintValue(value interface{}) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+
+	// JSON encoding is deterministic, also for nested maps (keys are sorted)
+	return json.Marshal(value)
+}
+
+func (f fingerprint) String() string {
+	return fmt.Sprintf("fingerprint=[method=%v, fields=%v, target_field=%v]",
+		f.method, strings.Join(f.fields, ", "), f.target)
+}
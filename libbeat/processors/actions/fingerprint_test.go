@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestFingerprint(t *testing.T, settings map[string]interface{}) fingerprint {
+	config, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newFingerprint(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(fingerprint)
+}
+
+func TestFingerprintDefaults(t *testing.T) {
+	p := newTestFingerprint(t, map[string]interface{}{})
+
+	event := common.MapStr{
+		"source":  "/var/log/messages",
+		"offset":  1024,
+		"beat":    common.MapStr{"hostname": "node1"},
+		"message": "hello",
+	}
+	actual, err := p.Run(event)
+	assert.NoError(t, err)
+
+	fp, ok := actual["fingerprint"].(string)
+	assert.True(t, ok)
+	assert.Len(t, fp, 40)
+
+	// fingerprint only depends on the configured fields
+	other := common.MapStr{
+		"source":  "/var/log/messages",
+		"offset":  1024,
+		"beat":    common.MapStr{"hostname": "node1"},
+		"message": "other message",
+	}
+	actual, err = p.Run(other)
+	assert.NoError(t, err)
+	assert.Equal(t, fp, actual["fingerprint"])
+
+	other.Put("offset", 2048)
+	actual, err = p.Run(other)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fp, actual["fingerprint"])
+}
+
+func TestFingerprintMethods(t *testing.T) {
+	tests := []struct {
+		method string
+		length int
+	}{
+		{"sha1", 40},
+		{"sha256", 64},
+		{"xxhash", 16},
+	}
+
+	for _, test := range tests {
+		p := newTestFingerprint(t, map[string]interface{}{
+			"fields":       []string{"message", "nested"},
+			"method":       test.method,
+			"target_field": "meta.id",
+		})
+
+		event := common.MapStr{
+			"message": "hello",
+			"nested":  common.MapStr{"b": 2, "a": 1},
+		}
+		actual, err := p.Run(event)
+		assert.NoError(t, err, test.method)
+
+		id, err := actual.GetValue("meta.id")
+		assert.NoError(t, err, test.method)
+		assert.Len(t, id, test.length, test.method)
+	}
+}
+
+func TestFingerprintMissingField(t *testing.T) {
+	event := common.MapStr{"message": "hello"}
+
+	p := newTestFingerprint(t, map[string]interface{}{
+		"fields": []string{"message", "missing"},
+	})
+	actual, err := p.Run(event)
+	assert.Error(t, err)
+	assert.Nil(t, actual["fingerprint"])
+
+	p = newTestFingerprint(t, map[string]interface{}{
+		"fields":         []string{"message", "missing"},
+		"ignore_missing": true,
+	})
+	actual, err = p.Run(event)
+	assert.NoError(t, err)
+	assert.NotNil(t, actual["fingerprint"])
+}
+
+func TestFingerprintInvalidMethod(t *testing.T) {
+	config, _ := common.NewConfigFrom(map[string]interface{}{
+		"method": "md5",
+	})
+	_, err := newFingerprint(*config)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+type sample struct {
+	rate     float64
+	keyField string
+	random   func() float64
+}
+
+type sampleConfig struct {
+	Rate     float64 `config:"rate"`
+	KeyField string  `config:"key_field"`
+}
+
+func init() {
+	processors.RegisterPlugin("sample",
+		configChecked(newSample,
+			requireFields("rate"),
+			allowedFields("rate", "key_field", "when")))
+}
+
+func newSample(c common.Config) (processors.Processor, error) {
+	config := sampleConfig{}
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unpack the sample configuration: %s", err)
+	}
+
+	if config.Rate <= 0 || config.Rate > 1 {
+		return nil, fmt.Errorf("sample rate must be in (0, 1], but is %v", config.Rate)
+	}
+
+	return &sample{
+		rate:     config.Rate,
+		keyField: config.KeyField,
+		random:   rand.Float64,
+	}, nil
+}
+
+// Run keeps the fraction of events given by the rate. If a key field is
+// configured, the decision is based on the hash of the key, so all events
+// sharing a key are either kept or dropped. Events without the key field are
+// sampled randomly.
+func (s *sample) Run(event common.MapStr) (common.MapStr, error) {
+	if s.rate >= 1 {
+		return event, nil
+	}
+
+	if s.keyField != "" {
+		if value, err := event.GetValue(s.keyField); err == nil {
+			if s.keep(value) {
+				return event, nil
+			}
+			return nil, nil
+		}
+	}
+
+	if s.random() < s.rate {
+		return event, nil
+	}
+	return nil, nil
+}
+
+// keep maps the hash of the key to [0, 1) and compares it with the rate.
+func (s *sample) keep(key interface{}) bool {
+	encoded, err := encodeFingerprintValue(key)
+	if err != nil {
+		return s.random() < s.rate
+	}
+
+	h := fnv.New64a()
+	h.Write(encoded)
+	return float64(h.Sum64()>>11)/(1<<53) < s.rate
+}
+
+func (s *sample) String() string {
+	return fmt.Sprintf("sample=[rate=%v, key_field=%v]", s.rate, s.keyField)
+}
@@ -0,0 +1,80 @@
+package actions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestSample(t *testing.T, settings map[string]interface{}) *sample {
+	config, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newSample(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*sample)
+}
+
+func TestSampleConfig(t *testing.T) {
+	for _, rate := range []float64{0, -0.5, 1.5} {
+		config, _ := common.NewConfigFrom(map[string]interface{}{"rate": rate})
+		_, err := newSample(*config)
+		assert.Error(t, err, "rate %v", rate)
+	}
+}
+
+func TestSampleKeyField(t *testing.T) {
+	p := newTestSample(t, map[string]interface{}{
+		"rate":      0.25,
+		"key_field": "trace.id",
+	})
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("trace-%d", i)
+		first, _ := p.Run(common.MapStr{"trace": common.MapStr{"id": id}, "n": 1})
+		second, _ := p.Run(common.MapStr{"trace": common.MapStr{"id": id}, "n": 2})
+
+		// all events of a trace are kept or dropped together
+		assert.Equal(t, first == nil, second == nil)
+		if first != nil {
+			kept++
+		}
+	}
+	assert.InDelta(t, 250, kept, 50)
+}
+
+func TestSampleRandom(t *testing.T) {
+	p := newTestSample(t, map[string]interface{}{
+		"rate":      0.5,
+		"key_field": "trace.id",
+	})
+
+	values := []float64{0.2, 0.7}
+	p.random = func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+
+	// events without the key field are sampled randomly
+	event, _ := p.Run(common.MapStr{"message": "a"})
+	assert.NotNil(t, event)
+	event, _ = p.Run(common.MapStr{"message": "b"})
+	assert.Nil(t, event)
+}
+
+func TestSampleAll(t *testing.T) {
+	p := newTestSample(t, map[string]interface{}{"rate": 1})
+
+	event, err := p.Run(common.MapStr{"message": "a"})
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+}
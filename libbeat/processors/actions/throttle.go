@@ -0,0 +1,154 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+type throttle struct {
+	fields       []string
+	limit        int
+	period       time.Duration
+	summaryField string
+
+	now func() time.Time
+
+	mutex     sync.Mutex
+	windows   map[string]*throttleWindow
+	nextSweep time.Time
+}
+
+// throttleWindow counts the events of a key since the window started.
+type throttleWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+type throttleConfig struct {
+	Fields       []string      `config:"fields"`
+	Limit        int           `config:"limit"         validate:"min=1"`
+	Period       time.Duration `config:"period"        validate:"nonzero,positive"`
+	SummaryField string        `config:"summary_field"`
+}
+
+var defaultThrottleConfig = throttleConfig{
+	Period:       time.Minute,
+	SummaryField: "throttle.suppressed",
+}
+
+func init() {
+	processors.RegisterPlugin("throttle",
+		configChecked(newThrottle,
+			requireFields("limit"),
+			allowedFields("fields", "limit", "period", "summary_field", "when")))
+}
+
+func newThrottle(c common.Config) (processors.Processor, error) {
+	config := defaultThrottleConfig
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unpack the throttle configuration: %s", err)
+	}
+
+	return &throttle{
+		fields:       config.Fields,
+		limit:        config.Limit,
+		period:       config.Period,
+		summaryField: config.SummaryField,
+		now:          time.Now,
+		windows:      map[string]*throttleWindow{},
+	}, nil
+}
+
+// Run passes up to limit events per key and period. Further events are
+// dropped. The first event of a key passing after a window with dropped events
+// closed reports the number of dropped events in the summary field. If no
+// event of the key follows within another period, the number is logged by the
+// next sweep. Processors can't create events or run timers, as they are never
+// closed, so nothing is reported while no events are processed.
+func (t *throttle) Run(event common.MapStr) (common.MapStr, error) {
+	key, err := t.key(event)
+	if err != nil {
+		return event, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	w := t.windows[key]
+	if w != nil && now.Sub(w.start) >= t.period {
+		if w.suppressed > 0 && t.summaryField != "" {
+			event.Put(t.summaryField, w.suppressed)
+		}
+		w = nil
+	}
+	if w == nil {
+		w = &throttleWindow{start: now}
+		t.windows[key] = w
+	}
+
+	if w.count >= t.limit {
+		w.suppressed++
+		return nil, nil
+	}
+	w.count++
+	return event, nil
+}
+
+// key joins the values of the configured fields. Missing fields are treated
+// as empty values.
+func (t *throttle) key(event common.MapStr) (string, error) {
+	if len(t.fields) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, len(t.fields))
+	for i, field := range t.fields {
+		value, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+
+		encoded, err := encodeFingerprintValue(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to build throttle key of field %v: %v", field, err)
+		}
+		parts[i] = string(encoded)
+	}
+	return strings.Join(parts, "\x00"), nil
+}
+
+// sweep removes the windows closed for at least one period, so keys not seen
+// anymore don't accumulate. It runs at most once per period.
+func (t *throttle) sweep(now time.Time) {
+	if now.Before(t.nextSweep) {
+		return
+	}
+	t.nextSweep = now.Add(t.period)
+
+	for key, w := range t.windows {
+		if now.Sub(w.start) < 2*t.period {
+			continue
+		}
+		if w.suppressed > 0 {
+			logp.Info("throttle: dropped %d events of key '%v' within %v",
+				w.suppressed, strings.Replace(key, "\x00", ", ", -1), t.period)
+		}
+		delete(t.windows, key)
+	}
+}
+
+func (t *throttle) String() string {
+	return fmt.Sprintf("throttle=[fields=%v, limit=%v, period=%v]",
+		strings.Join(t.fields, ", "), t.limit, t.period)
+}
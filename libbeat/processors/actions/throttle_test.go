@@ -0,0 +1,109 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestThrottle(t *testing.T, settings map[string]interface{}) (*throttle, *time.Time) {
+	config, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newThrottle(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	th := p.(*throttle)
+	th.now = func() time.Time { return now }
+	return th, &now
+}
+
+func runThrottle(t *testing.T, p *throttle, message string, n int) (passed int, last common.MapStr) {
+	for i := 0; i < n; i++ {
+		event, err := p.Run(common.MapStr{"message": message})
+		assert.NoError(t, err)
+		if event != nil {
+			passed++
+			last = event
+		}
+	}
+	return passed, last
+}
+
+func TestThrottleConfig(t *testing.T) {
+	for _, settings := range []map[string]interface{}{
+		{},
+		{"limit": 0},
+		{"limit": 1, "period": "0s"},
+		{"limit": 1, "unknown": true},
+	} {
+		config, _ := common.NewConfigFrom(settings)
+		_, err := configChecked(newThrottle,
+			requireFields("limit"),
+			allowedFields("fields", "limit", "period", "summary_field", "when"))(*config)
+		assert.Error(t, err, "%v", settings)
+	}
+}
+
+func TestThrottlePerKey(t *testing.T) {
+	p, now := newTestThrottle(t, map[string]interface{}{
+		"fields": []string{"message"},
+		"limit":  3,
+		"period": "10s",
+	})
+
+	passed, _ := runThrottle(t, p, "a", 10)
+	assert.Equal(t, 3, passed)
+	passed, _ = runThrottle(t, p, "b", 2)
+	assert.Equal(t, 2, passed)
+
+	// the next window reports the dropped events with the first event
+	*now = now.Add(10 * time.Second)
+	event, _ := p.Run(common.MapStr{"message": "a"})
+	suppressed, err := event.GetValue("throttle.suppressed")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, suppressed)
+
+	event, _ = p.Run(common.MapStr{"message": "b"})
+	assert.NotContains(t, event, "throttle")
+
+	passed, last := runThrottle(t, p, "a", 5)
+	assert.Equal(t, 2, passed)
+	assert.NotContains(t, last, "throttle")
+}
+
+func TestThrottleSweep(t *testing.T) {
+	p, now := newTestThrottle(t, map[string]interface{}{
+		"fields": []string{"message"},
+		"limit":  1,
+		"period": "10s",
+	})
+
+	runThrottle(t, p, "a", 2)
+	runThrottle(t, p, "b", 1)
+	assert.Len(t, p.windows, 2)
+
+	*now = now.Add(20 * time.Second)
+	runThrottle(t, p, "c", 1)
+	assert.Len(t, p.windows, 1)
+}
+
+func TestThrottleNoFields(t *testing.T) {
+	p, _ := newTestThrottle(t, map[string]interface{}{
+		"limit":         2,
+		"summary_field": "dropped",
+	})
+
+	passed, _ := runThrottle(t, p, "a", 3)
+	assert.Equal(t, 2, passed)
+	passed, _ = runThrottle(t, p, "b", 3)
+	assert.Equal(t, 0, passed)
+}
@@ -0,0 +1,281 @@
+package add_host_metadata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+var debugf = logp.MakeDebug("filters")
+
+// osFamilies maps distribution IDs, as found in the ID and ID_LIKE fields of
+// os-release, to the OS family.
+var osFamilies = map[string]string{
+	"rhel":     "redhat",
+	"redhat":   "redhat",
+	"centos":   "redhat",
+	"fedora":   "redhat",
+	"amzn":     "redhat",
+	"ol":       "redhat",
+	"debian":   "debian",
+	"ubuntu":   "debian",
+	"raspbian": "debian",
+	"suse":     "suse",
+	"sles":     "suse",
+	"opensuse": "suse",
+	"arch":     "arch",
+	"alpine":   "alpine",
+	"gentoo":   "gentoo",
+	"coreos":   "coreos",
+}
+
+// init registers the add_host_metadata processor.
+func init() {
+	processors.RegisterPlugin("add_host_metadata", newHostMetadata)
+}
+
+type addHostMetadata struct {
+	interval time.Duration
+
+	// root is the path the /proc and /etc directories are read from.
+	root string
+
+	mutex    sync.Mutex
+	data     common.MapStr
+	lastLoad time.Time
+}
+
+func newHostMetadata(c common.Config) (processors.Processor, error) {
+	config := struct {
+		RefreshInterval time.Duration `config:"refresh_interval" validate:"min=0"`
+	}{
+		RefreshInterval: 5 * time.Minute,
+	}
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unpack add_host_metadata config")
+	}
+
+	p := &addHostMetadata{
+		interval: config.RefreshInterval,
+		root:     "/",
+	}
+	p.load(time.Now())
+	logp.Info("add_host_metadata: host metadata=%v", p.data.String())
+
+	return p, nil
+}
+
+// Run adds the host metadata to the event. The metadata is collected again,
+// if it is older than the refresh interval. A refresh interval of 0 disables
+// refreshing.
+func (p *addHostMetadata) Run(event common.MapStr) (common.MapStr, error) {
+	p.mutex.Lock()
+	now := time.Now()
+	if p.interval > 0 && now.Sub(p.lastLoad) >= p.interval {
+		p.load(now)
+	}
+	data := p.data.Clone()
+	p.mutex.Unlock()
+
+	if len(data) == 0 {
+		return event, nil
+	}
+
+	// Existing host metadata is overwritten, but a host field with a different
+	// meaning, like the monitored host in Heartbeat, is kept.
+	if host, exists := event["host"]; exists {
+		if _, ok := host.(common.MapStr); !ok {
+			debugf("add_host_metadata: event has a host field of type %T, not adding host metadata", host)
+			return event, nil
+		}
+	}
+
+	event["host"] = data
+	return event, nil
+}
+
+func (p *addHostMetadata) load(now time.Time) {
+	p.data = p.collect()
+	p.lastLoad = now
+}
+
+// collect gathers the host facts. Facts that can not be read are omitted.
+func (p *addHostMetadata) collect() common.MapStr {
+	data := common.MapStr{}
+
+	if hostname, err := os.Hostname(); err == nil {
+		data["hostname"] = hostname
+	}
+
+	if osInfo := p.osInfo(); len(osInfo) > 0 {
+		data["os"] = osInfo
+	}
+
+	if id := p.readFirstLine("etc/machine-id", "var/lib/dbus/machine-id"); id != "" {
+		data["id"] = id
+	}
+	if bootID := p.readFirstLine("proc/sys/kernel/random/boot_id"); bootID != "" {
+		data["boot_id"] = bootID
+	}
+
+	ips, macs, err := interfaceAddrs()
+	if err != nil {
+		debugf("add_host_metadata: failed to read network interfaces: %v", err)
+	}
+	if len(ips) > 0 {
+		data["ip"] = ips
+	}
+	if len(macs) > 0 {
+		data["mac"] = macs
+	}
+
+	return data
+}
+
+// osInfo reads the operating system details from os-release and the kernel
+// version from /proc.
+func (p *addHostMetadata) osInfo() common.MapStr {
+	info := common.MapStr{}
+
+	release, err := p.readOSRelease()
+	if err != nil {
+		debugf("add_host_metadata: failed to read os-release: %v", err)
+	}
+	if name := release["NAME"]; name != "" {
+		info["name"] = name
+	}
+	if version := release["VERSION"]; version != "" {
+		info["version"] = version
+	}
+	if id := release["ID"]; id != "" {
+		info["platform"] = id
+		info["family"] = osFamily(id, release["ID_LIKE"])
+	}
+
+	if kernel := p.readFirstLine("proc/sys/kernel/osrelease"); kernel != "" {
+		info["kernel"] = kernel
+	}
+	return info
+}
+
+// readOSRelease parses /etc/os-release, or /usr/lib/os-release as fallback.
+func (p *addHostMetadata) readOSRelease() (map[string]string, error) {
+	var err error
+	for _, name := range []string{"etc/os-release", "usr/lib/os-release"} {
+		var f *os.File
+		f, err = os.Open(filepath.Join(p.root, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		return parseOSRelease(f)
+	}
+	return nil, err
+}
+
+func parseOSRelease(r io.Reader) (map[string]string, error) {
+	release := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		release[parts[0]] = strings.Trim(parts[1], `"'`)
+	}
+	return release, scanner.Err()
+}
+
+// osFamily returns the family of the distribution with the given ID. If the
+// ID is unknown, the IDs of the distributions it is derived from are checked.
+// If none is known, the ID is returned.
+func osFamily(id, idLike string) string {
+	for _, candidate := range append([]string{id}, strings.Fields(idLike)...) {
+		if family, found := osFamilies[candidate]; found {
+			return family
+		}
+	}
+	return id
+}
+
+// readFirstLine returns the trimmed first line of the first file found.
+func (p *addHostMetadata) readFirstLine(names ...string) string {
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(p.root, name))
+		if err != nil {
+			continue
+		}
+
+		line := strings.SplitN(string(content), "\n", 2)[0]
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// interfaceAddrs returns the IP addresses and MAC addresses of all network
+// interfaces being up, except for loopback interfaces. Link-local addresses
+// are not reported.
+func interfaceAddrs() ([]string, []string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ips, macs []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		if mac := iface.HardwareAddr.String(); mac != "" && !containsString(macs, mac) {
+			macs = append(macs, mac)
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return ips, macs, fmt.Errorf("failed to read addresses of %v: %v", iface.Name, err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() || ipNet.IP.IsLoopback() {
+				continue
+			}
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	return ips, macs, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *addHostMetadata) String() string {
+	return fmt.Sprintf("add_host_metadata=[refresh_interval=%v]", p.interval)
+}
@@ -0,0 +1,118 @@
+package add_host_metadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func writeFile(t *testing.T, root, name, content string) {
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestRoot(t *testing.T) string {
+	root, err := ioutil.TempDir("", "add_host_metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, root, "etc/os-release", `NAME="CentOS Linux"
+VERSION="7 (Core)"
+ID="centos"
+ID_LIKE="rhel fedora"
+`)
+	writeFile(t, root, "etc/machine-id", "6e8e4a0a6c2e4e36a7f0a4a2a3b1c2d4\n")
+	writeFile(t, root, "proc/sys/kernel/random/boot_id", "9f1c5a7e-3b2d-4f6a-8e1c-2d3b4a5c6e7f\n")
+	writeFile(t, root, "proc/sys/kernel/osrelease", "3.10.0-514.el7.x86_64\n")
+	return root
+}
+
+func TestHostMetadata(t *testing.T) {
+	root := newTestRoot(t)
+	defer os.RemoveAll(root)
+
+	p := &addHostMetadata{root: root}
+	p.load(time.Now())
+
+	actual, err := p.Run(common.MapStr{})
+	assert.NoError(t, err)
+
+	osInfo, err := actual.GetValue("host.os")
+	assert.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"name":     "CentOS Linux",
+		"version":  "7 (Core)",
+		"platform": "centos",
+		"family":   "redhat",
+		"kernel":   "3.10.0-514.el7.x86_64",
+	}, osInfo)
+
+	id, _ := actual.GetValue("host.id")
+	assert.Equal(t, "6e8e4a0a6c2e4e36a7f0a4a2a3b1c2d4", id)
+	bootID, _ := actual.GetValue("host.boot_id")
+	assert.Equal(t, "9f1c5a7e-3b2d-4f6a-8e1c-2d3b4a5c6e7f", bootID)
+}
+
+func TestHostMetadataRefresh(t *testing.T) {
+	root := newTestRoot(t)
+	defer os.RemoveAll(root)
+
+	p := &addHostMetadata{root: root, interval: time.Hour}
+	p.load(time.Now())
+
+	writeFile(t, root, "proc/sys/kernel/random/boot_id", "changed\n")
+	actual, _ := p.Run(common.MapStr{})
+	bootID, _ := actual.GetValue("host.boot_id")
+	assert.Equal(t, "9f1c5a7e-3b2d-4f6a-8e1c-2d3b4a5c6e7f", bootID)
+
+	// the metadata is collected again after the refresh interval
+	p.lastLoad = time.Now().Add(-time.Hour)
+	actual, _ = p.Run(common.MapStr{})
+	bootID, _ = actual.GetValue("host.boot_id")
+	assert.Equal(t, "changed", bootID)
+}
+
+func TestHostMetadataEventsNotShared(t *testing.T) {
+	root := newTestRoot(t)
+	defer os.RemoveAll(root)
+
+	p := &addHostMetadata{root: root}
+	p.load(time.Now())
+
+	first, _ := p.Run(common.MapStr{})
+	first.Put("host.os.name", "modified")
+
+	second, _ := p.Run(common.MapStr{})
+	name, _ := second.GetValue("host.os.name")
+	assert.Equal(t, "CentOS Linux", name)
+}
+
+func TestOSFamily(t *testing.T) {
+	assert.Equal(t, "debian", osFamily("ubuntu", ""))
+	assert.Equal(t, "redhat", osFamily("scientific", "rhel centos fedora"))
+	assert.Equal(t, "nixos", osFamily("nixos", ""))
+}
+
+func TestHostMetadataKeepsHostField(t *testing.T) {
+	root := newTestRoot(t)
+	defer os.RemoveAll(root)
+
+	p := &addHostMetadata{root: root}
+	p.load(time.Now())
+
+	actual, err := p.Run(common.MapStr{"host": "monitored.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "monitored.example.com", actual["host"])
+}
@@ -3,6 +3,7 @@ package processors
 import (
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -19,9 +20,15 @@ type RangeValue struct {
 	lt  *float64
 }
 
+// EqualsValue is the value a field is compared with by the equals condition.
+// Only the field matching the kind of the configured value is set.
 type EqualsValue struct {
-	Int uint64
-	Str string
+	Int   uint64
+	Float float64
+	Bool  bool
+	Str   string
+
+	kind reflect.Kind
 }
 
 type Condition struct {
@@ -30,10 +37,12 @@ type Condition struct {
 		name    string
 		filters map[string]match.Matcher
 	}
-	rangexp map[string]RangeValue
-	or      []Condition
-	and     []Condition
-	not     *Condition
+	rangexp   map[string]RangeValue
+	network   map[string][]netMatcher
+	hasfields []string
+	or        []Condition
+	and       []Condition
+	not       *Condition
 }
 
 type WhenProcessor struct {
@@ -74,6 +83,10 @@ func NewCondition(config *ConditionConfig) (*Condition, error) {
 		c.matches.filters, err = compileMatches(config.Regexp.fields, match.Compile)
 	case config.Range != nil:
 		err = c.setRange(config.Range)
+	case config.Network != nil:
+		c.network, err = compileNetworks(config.Network.fields)
+	case len(config.HasFields) > 0:
+		c.hasfields = config.HasFields
 	case len(config.OR) > 0:
 		c.or, err = NewConditionList(config.OR)
 	case len(config.AND) > 0:
@@ -109,15 +122,22 @@ func (c *Condition) setEquals(cfg *ConditionFields) error {
 	c.equals = map[string]EqualsValue{}
 
 	for field, value := range cfg.fields {
-		uintValue, err := extractInt(value)
-		if err == nil {
-			c.equals[field] = EqualsValue{Int: uintValue}
-		} else {
-			sValue, err := extractString(value)
+		if uintValue, err := extractInt(value); err == nil {
+			c.equals[field] = EqualsValue{Int: uintValue, kind: reflect.Uint64}
+			continue
+		}
+
+		switch v := value.(type) {
+		case bool:
+			c.equals[field] = EqualsValue{Bool: v, kind: reflect.Bool}
+		case string:
+			c.equals[field] = EqualsValue{Str: v, kind: reflect.String}
+		default:
+			floatValue, err := extractNumber(value)
 			if err != nil {
-				return err
+				return fmt.Errorf("unexpected type %T of %v in equals condition", value, value)
 			}
-			c.equals[field] = EqualsValue{Str: sValue}
+			c.equals[field] = EqualsValue{Float: floatValue, kind: reflect.Float64}
 		}
 	}
 
@@ -212,7 +232,9 @@ func (c *Condition) Check(event common.MapStr) bool {
 
 	return c.checkEquals(event) &&
 		c.checkMatches(event) &&
-		c.checkRange(event)
+		c.checkRange(event) &&
+		c.checkNetwork(event) &&
+		c.checkHasFields(event)
 }
 
 func (c *Condition) checkEquals(event common.MapStr) bool {
@@ -224,20 +246,8 @@ func (c *Condition) checkEquals(event common.MapStr) bool {
 			return false
 		}
 
-		intValue, err := extractInt(value)
-		if err == nil {
-			if intValue != equalValue.Int {
-				return false
-			}
-		} else {
-			sValue, err := extractString(value)
-			if err != nil {
-				logp.Warn("unexpected type %T in equals condition as it accepts only integers and strings. ", value)
-				return false
-			}
-			if sValue != equalValue.Str {
-				return false
-			}
+		if !equalValue.matches(value) {
+			return false
 		}
 	}
 
@@ -245,6 +255,32 @@ func (c *Condition) checkEquals(event common.MapStr) bool {
 
 }
 
+// matches compares the value with the configured value. Numbers are compared
+// by value, independent of their type.
+func (e EqualsValue) matches(value interface{}) bool {
+	switch e.kind {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		return ok && b == e.Bool
+
+	case reflect.String:
+		s, err := extractString(value)
+		return err == nil && s == e.Str
+
+	case reflect.Uint64:
+		if intValue, err := extractInt(value); err == nil {
+			return intValue == e.Int
+		}
+		floatValue, err := extractNumber(value)
+		return err == nil && floatValue == float64(e.Int)
+
+	case reflect.Float64:
+		floatValue, err := extractNumber(value)
+		return err == nil && floatValue == e.Float
+	}
+	return false
+}
+
 func (c *Condition) checkMatches(event common.MapStr) bool {
 	matchers := c.matches.filters
 	if matchers == nil {
@@ -269,9 +305,9 @@ func (c *Condition) checkMatches(event common.MapStr) bool {
 			}
 
 		default:
-			str, err := extractString(value)
+			str, err := formatValue(value)
 			if err != nil {
-				logp.Warn("unexpected type %T in %v condition as it accepts only strings.", value, c.matches.name)
+				logp.Warn("unexpected type %T in %v condition as it accepts only strings, numbers and booleans.", value, c.matches.name)
 				return false
 			}
 
@@ -349,6 +385,77 @@ func (c *Condition) checkRange(event common.MapStr) bool {
 	return true
 }
 
+func (c *Condition) checkNetwork(event common.MapStr) bool {
+
+	for field, networks := range c.network {
+
+		value, err := event.GetValue(field)
+		if err != nil {
+			return false
+		}
+
+		switch v := value.(type) {
+		case string:
+			ip := net.ParseIP(v)
+			if ip == nil || !networksContain(networks, ip) {
+				return false
+			}
+
+		case net.IP:
+			if !networksContain(networks, v) {
+				return false
+			}
+
+		case []string:
+			found := false
+			for _, s := range v {
+				if ip := net.ParseIP(s); ip != nil && networksContain(networks, ip) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+
+		case []interface{}:
+			// e.g. lists of addresses decoded from JSON
+			found := false
+			for _, item := range v {
+				var ip net.IP
+				switch item := item.(type) {
+				case string:
+					ip = net.ParseIP(item)
+				case net.IP:
+					ip = item
+				}
+				if ip != nil && networksContain(networks, ip) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+
+		default:
+			logp.Warn("unexpected type %T in network condition as it accepts only IP addresses as strings.", value)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Condition) checkHasFields(event common.MapStr) bool {
+
+	for _, field := range c.hasfields {
+		if _, err := event.GetValue(field); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Condition) checkOR(event common.MapStr) bool {
 
 	for _, cond := range c.or {
@@ -390,6 +497,12 @@ func (c Condition) String() string {
 	if len(c.rangexp) > 0 {
 		s = s + fmt.Sprintf("range: %v", c.rangexp)
 	}
+	if len(c.network) > 0 {
+		s = s + fmt.Sprintf("network: %v", c.network)
+	}
+	if len(c.hasfields) > 0 {
+		s = s + fmt.Sprintf("has_fields: %v", c.hasfields)
+	}
 	if len(c.or) > 0 {
 		for _, cond := range c.or {
 			s = s + cond.String() + " or "
@@ -440,10 +553,15 @@ func (r RangeValue) String() string {
 
 func (e EqualsValue) String() string {
 
-	if len(e.Str) > 0 {
-		return e.Str
+	switch e.kind {
+	case reflect.Bool:
+		return strconv.FormatBool(e.Bool)
+	case reflect.Uint64:
+		return strconv.FormatUint(e.Int, 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(e.Float, 'f', -1, 64)
 	}
-	return strconv.Itoa(int(e.Int))
+	return e.Str
 }
 
 func NewConditionRule(
@@ -1,7 +1,9 @@
 package processors
 
 import (
+	"encoding/json"
 	"errors"
+	"net"
 	"testing"
 
 	"github.com/elastic/beats/libbeat/common"
@@ -29,7 +31,19 @@ func TestBadCondition(t *testing.T) {
 	configs := []ConditionConfig{
 		{
 			Equals: &ConditionFields{fields: map[string]interface{}{
-				"proc.pid": 0.08,
+				"proc.pid": nil,
+			}},
+		},
+
+		{
+			Network: &NetworkFields{fields: map[string][]string{
+				"ip": {"10.0.0.0/33"},
+			}},
+		},
+
+		{
+			Network: &NetworkFields{fields: map[string][]string{
+				"ip": {"privat"},
 			}},
 		},
 
@@ -125,6 +139,182 @@ func TestEqualsCondition(t *testing.T) {
 	assert.False(t, conds[2].Check(event))
 }
 
+func TestEqualsConditionTypes(t *testing.T) {
+
+	configs := []ConditionConfig{
+		{
+			Equals: &ConditionFields{fields: map[string]interface{}{
+				"proc.cpu.total_p": 0.08,
+			}},
+		},
+
+		{
+			Equals: &ConditionFields{fields: map[string]interface{}{
+				"proc.running": true,
+			}},
+		},
+
+		{
+			Equals: &ConditionFields{fields: map[string]interface{}{
+				"proc.running": false,
+			}},
+		},
+
+		{
+			Equals: &ConditionFields{fields: map[string]interface{}{
+				"proc.ratio": 2,
+			}},
+		},
+
+		{
+			Equals: &ConditionFields{fields: map[string]interface{}{
+				"proc.name": 0,
+			}},
+		},
+	}
+
+	conds := GetConditions(t, configs)
+
+	event := common.MapStr{
+		"proc": common.MapStr{
+			"cpu": common.MapStr{
+				"total_p": 0.08,
+			},
+			"name":    "secd",
+			"ratio":   2.0,
+			"running": true,
+		},
+	}
+
+	assert.True(t, conds[0].Check(event))
+	assert.True(t, conds[1].Check(event))
+	assert.False(t, conds[2].Check(event))
+	assert.True(t, conds[3].Check(event))
+	assert.False(t, conds[4].Check(event))
+}
+
+func TestNetworkCondition(t *testing.T) {
+
+	config := func(networks ...string) ConditionConfig {
+		return ConditionConfig{
+			Network: &NetworkFields{fields: map[string][]string{
+				"ip": networks,
+			}},
+		}
+	}
+
+	tests := []struct {
+		networks []string
+		ip       interface{}
+		result   bool
+	}{
+		{[]string{"192.168.1.0/24"}, "192.168.1.10", true},
+		{[]string{"192.168.1.0/24"}, "192.168.2.10", false},
+		{[]string{"10.0.0.0/8", "private"}, "172.16.3.1", true},
+		{[]string{"private"}, "8.8.8.8", false},
+		{[]string{"private"}, "fd00::1", true},
+		{[]string{"public"}, "8.8.8.8", true},
+		{[]string{"public"}, "10.1.1.1", false},
+		{[]string{"loopback"}, "127.0.0.1", true},
+		{[]string{"loopback"}, "::1", true},
+		{[]string{"multicast"}, "224.0.0.1", true},
+		{[]string{"link_local_unicast"}, "169.254.1.1", true},
+		{[]string{"unspecified"}, "0.0.0.0", true},
+		{[]string{"loopback"}, []string{"10.0.0.1", "127.0.0.1"}, true},
+		{[]string{"loopback"}, []interface{}{"10.0.0.1", "127.0.0.1"}, true},
+		{[]string{"loopback"}, []interface{}{1, net.ParseIP("::1")}, true},
+		{[]string{"loopback"}, []interface{}{"10.0.0.1", 127}, false},
+		{[]string{"loopback"}, []interface{}{}, false},
+		{[]string{"loopback"}, "not an ip", false},
+		{[]string{"loopback"}, 127, false},
+	}
+
+	for _, test := range tests {
+		c := config(test.networks...)
+		cond, err := NewCondition(&c)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		event := common.MapStr{"ip": test.ip}
+		assert.Equal(t, test.result, cond.Check(event), "%v in %v", test.ip, test.networks)
+	}
+
+	// the field must exist
+	c := config("loopback")
+	cond, err := NewCondition(&c)
+	assert.NoError(t, err)
+	assert.False(t, cond.Check(common.MapStr{}))
+}
+
+func TestNetworkConditionJSON(t *testing.T) {
+	var event common.MapStr
+	err := json.Unmarshal([]byte(`{"ip": ["8.8.8.8", "192.168.0.1"]}`), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ConditionConfig{
+		Network: &NetworkFields{fields: map[string][]string{"ip": {"private"}}},
+	}
+	cond, err := NewCondition(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, cond.Check(event))
+}
+
+func TestNetworkConditionUnpack(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"network": map[string]interface{}{
+			"source.ip": []string{"10.0.0.0/8", "loopback"},
+			"dest.ip":   "private",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := ConditionConfig{}
+	if err := cfg.Unpack(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	cond, err := NewCondition(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, cond.Check(common.MapStr{
+		"source": common.MapStr{"ip": "127.0.0.1"},
+		"dest":   common.MapStr{"ip": "192.168.0.1"},
+	}))
+	assert.False(t, cond.Check(common.MapStr{
+		"source": common.MapStr{"ip": "127.0.0.1"},
+		"dest":   common.MapStr{"ip": "8.8.8.8"},
+	}))
+}
+
+func TestHasFieldsCondition(t *testing.T) {
+
+	configs := []ConditionConfig{
+		{HasFields: []string{"proc.name", "type"}},
+		{HasFields: []string{"proc.name", "proc.missing"}},
+	}
+
+	conds := GetConditions(t, configs)
+
+	event := common.MapStr{
+		"proc": common.MapStr{
+			"name": "secd",
+		},
+		"type": "process",
+	}
+
+	assert.True(t, conds[0].Check(event))
+	assert.False(t, conds[1].Check(event))
+}
+
 func TestContainsCondition(t *testing.T) {
 
 	if testing.Verbose() {
@@ -236,6 +426,29 @@ func TestRegexpCondition(t *testing.T) {
 	assert.False(t, conds[2].Check(event1))
 }
 
+func TestRegexpConditionNumbers(t *testing.T) {
+
+	configs := []ConditionConfig{
+		{
+			Regexp: &ConditionFields{fields: map[string]interface{}{
+				"http.response.code": "^5..$",
+			}},
+		},
+		{
+			Contains: &ConditionFields{fields: map[string]interface{}{
+				"proc.cpu.total_p": "0.0",
+			}},
+		},
+	}
+
+	conds := GetConditions(t, configs)
+
+	assert.True(t, conds[0].Check(common.MapStr{"http": common.MapStr{"response": common.MapStr{"code": 503}}}))
+	assert.False(t, conds[0].Check(common.MapStr{"http": common.MapStr{"response": common.MapStr{"code": uint16(200)}}}))
+	assert.True(t, conds[1].Check(common.MapStr{"proc": common.MapStr{"cpu": common.MapStr{"total_p": 0.08}}}))
+	assert.False(t, conds[1].Check(common.MapStr{"proc": common.MapStr{"cpu": common.MapStr{"total_p": 1.5}}}))
+}
+
 func TestRangeCondition(t *testing.T) {
 
 	if testing.Verbose() {
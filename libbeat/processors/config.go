@@ -3,25 +3,34 @@ package processors
 import (
 	"fmt"
 	"math"
+	"reflect"
 	"strconv"
 
 	"github.com/elastic/beats/libbeat/common"
 )
 
 type ConditionConfig struct {
-	Equals   *ConditionFields  `config:"equals"`
-	Contains *ConditionFields  `config:"contains"`
-	Regexp   *ConditionFields  `config:"regexp"`
-	Range    *ConditionFields  `config:"range"`
-	OR       []ConditionConfig `config:"or"`
-	AND      []ConditionConfig `config:"and"`
-	NOT      *ConditionConfig  `config:"not"`
+	Equals    *ConditionFields  `config:"equals"`
+	Contains  *ConditionFields  `config:"contains"`
+	Regexp    *ConditionFields  `config:"regexp"`
+	Range     *ConditionFields  `config:"range"`
+	Network   *NetworkFields    `config:"network"`
+	HasFields []string          `config:"has_fields"`
+	OR        []ConditionConfig `config:"or"`
+	AND       []ConditionConfig `config:"and"`
+	NOT       *ConditionConfig  `config:"not"`
 }
 
 type ConditionFields struct {
 	fields map[string]interface{}
 }
 
+// NetworkFields maps field names to a list of networks, each given in CIDR
+// notation or as a named network like `private`.
+type NetworkFields struct {
+	fields map[string][]string
+}
+
 type PluginConfig []map[string]common.Config
 
 // fields that should be always exported
@@ -58,12 +67,56 @@ func (f *ConditionFields) Unpack(to interface{}) error {
 	return nil
 }
 
+func (f *NetworkFields) Unpack(to interface{}) error {
+	m, ok := to.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("wrong type, expect map")
+	}
+
+	f.fields = map[string][]string{}
+
+	var expand func(key string, value interface{}) error
+
+	expand = func(key string, value interface{}) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, val := range v {
+				if err := expand(fmt.Sprintf("%v.%v", key, k), val); err != nil {
+					return err
+				}
+			}
+		case string:
+			f.fields[key] = []string{v}
+		case []interface{}:
+			for _, network := range v {
+				s, ok := network.(string)
+				if !ok {
+					return fmt.Errorf("unexpected type %T of network %v in field %v", network, network, key)
+				}
+				f.fields[key] = append(f.fields[key], s)
+			}
+		default:
+			return fmt.Errorf("unexpected type %T of networks in field %v", value, key)
+		}
+		return nil
+	}
+
+	for k, val := range m {
+		if err := expand(k, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func extractFloat(unk interface{}) (float64, error) {
 	switch i := unk.(type) {
 	case float64:
 		return float64(i), nil
 	case float32:
 		return float64(i), nil
+	case common.Float:
+		return float64(i), nil
 	case int64:
 		return float64(i), nil
 	case int32:
@@ -130,3 +183,29 @@ func extractString(unk interface{}) (string, error) {
 		return "", fmt.Errorf("unknown type %T passed to extractString", unk)
 	}
 }
+
+// extractNumber is like extractFloat, but does not parse strings.
+func extractNumber(unk interface{}) (float64, error) {
+	if _, ok := unk.(string); ok {
+		return math.NaN(), fmt.Errorf("unknown type %T passed to extractNumber", unk)
+	}
+	return extractFloat(unk)
+}
+
+// formatValue formats numbers and bools, so they can be matched like strings.
+func formatValue(unk interface{}) (string, error) {
+	switch v := unk.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64:
+		return strconv.FormatInt(reflect.ValueOf(v).Int(), 10), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return strconv.FormatUint(reflect.ValueOf(v).Uint(), 10), nil
+	case float32, float64, common.Float:
+		return strconv.FormatFloat(reflect.ValueOf(v).Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unknown type %T passed to formatValue", unk)
+	}
+}
@@ -0,0 +1,128 @@
+package processors
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// netMatcher checks if an IP address is part of a network.
+type netMatcher interface {
+	Contains(ip net.IP) bool
+	String() string
+}
+
+// namedNetwork is a network identified by name, like `private`, that can not
+// be expressed as a single CIDR.
+type namedNetwork struct {
+	name     string
+	contains func(ip net.IP) bool
+}
+
+func (n namedNetwork) Contains(ip net.IP) bool { return n.contains(ip) }
+func (n namedNetwork) String() string          { return n.name }
+
+// cidrNetwork is a network given in CIDR notation.
+type cidrNetwork struct {
+	*net.IPNet
+}
+
+// privateNetworks are the IPv4 private address ranges (RFC 1918) and the IPv6
+// unique local addresses (RFC 4193).
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+var namedNetworks = map[string]func(ip net.IP) bool{
+	"loopback":                  net.IP.IsLoopback,
+	"unicast":                   net.IP.IsGlobalUnicast,
+	"multicast":                 net.IP.IsMulticast,
+	"interface_local_multicast": net.IP.IsInterfaceLocalMulticast,
+	"link_local_unicast":        net.IP.IsLinkLocalUnicast,
+	"link_local_multicast":      net.IP.IsLinkLocalMulticast,
+	"unspecified":               net.IP.IsUnspecified,
+	"private":                   isPrivateIP,
+	"public":                    isPublicIP,
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+func isPrivateIP(ip net.IP) bool {
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP reports global unicast addresses not being part of a private
+// network.
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !isPrivateIP(ip)
+}
+
+// compileNetworks parses the networks configured per field.
+func compileNetworks(fields map[string][]string) (map[string][]netMatcher, error) {
+	out := map[string][]netMatcher{}
+	for field, networks := range fields {
+		if len(networks) == 0 {
+			return nil, fmt.Errorf("no networks given for field %v", field)
+		}
+
+		for _, network := range networks {
+			m, err := parseNetwork(network)
+			if err != nil {
+				return nil, err
+			}
+			out[field] = append(out[field], m)
+		}
+	}
+	return out, nil
+}
+
+func parseNetwork(network string) (netMatcher, error) {
+	if contains, found := namedNetworks[network]; found {
+		return namedNetwork{network, contains}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network '%v', expected CIDR or one of %v",
+			network, strings.Join(namedNetworkNames(), ", "))
+	}
+	return cidrNetwork{ipNet}, nil
+}
+
+func namedNetworkNames() []string {
+	names := make([]string, 0, len(namedNetworks))
+	for name := range namedNetworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// networksContain checks if the IP is part of any of the networks.
+func networksContain(networks []netMatcher, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/elastic/beats/libbeat/processors"
 	_ "github.com/elastic/beats/libbeat/processors/actions"
 	_ "github.com/elastic/beats/libbeat/processors/add_cloud_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/add_host_metadata"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,13 +16,14 @@ const (
 
 func newAsyncPipeline(
 	pub *BeatPublisher,
+	outputWorkers []*outputWorker,
 	hwm, bulkHWM int,
 	ws *workerSignal,
 ) *asyncPipeline {
 	p := &asyncPipeline{pub: pub}
 
 	var outputs []worker
-	for _, out := range pub.Output {
+	for _, out := range outputWorkers {
 		outputs = append(outputs, makeAsyncOutput(ws, hwm, bulkHWM, out))
 	}
 
@@ -130,7 +130,26 @@ func (b *bulkWorker) publish() {
 	b.data = make([]outputs.Data, 0, b.maxBatchSize)
 }
 
+// drain batches all messages left in the queues and forwards the batched
+// events to the output.
+func (b *bulkWorker) drain() {
+	for {
+		select {
+		case m := <-b.queue:
+			b.onEvent(&m.context, m.datum)
+		case m := <-b.bulkQueue:
+			b.onEvents(&m.context, m.data)
+		default:
+			b.flush()
+			return
+		}
+	}
+}
+
 func (b *bulkWorker) shutdown() {
+	if b.ws.draining {
+		b.drain()
+	}
 	b.flushTicker.Stop()
 	stopQueue(b.queue)
 	stopQueue(b.bulkQueue)
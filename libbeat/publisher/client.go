@@ -3,6 +3,7 @@ package publisher
 import (
 	"errors"
 	"expvar"
+	"sync"
 	"sync/atomic"
 
 	"github.com/elastic/beats/libbeat/common"
@@ -98,13 +99,18 @@ func (c *client) Close() error {
 func (c *client) PublishEvent(event common.MapStr, opts ...ClientOption) bool {
 	c.annotateEvent(event)
 
+	inflight := c.lockPipeline()
+	defer inflight.Done()
+
 	publishEvent := c.filterEvent(event)
+	meta, ctx, pipeline := c.getPipeline(opts)
+	c.unlockPipeline()
+
 	if publishEvent == nil {
 		return false
 	}
 
 	var values *outputs.Values
-	meta, ctx, pipeline := c.getPipeline(opts)
 	if len(meta) != 0 {
 		if len(meta) != 1 {
 			logp.Debug("publish", "too many metadata, pick first")
@@ -124,6 +130,9 @@ func (c *client) PublishEvent(event common.MapStr, opts ...ClientOption) bool {
 func (c *client) PublishEvents(events []common.MapStr, opts ...ClientOption) bool {
 	var valuesAll *outputs.Values
 
+	inflight := c.lockPipeline()
+	defer inflight.Done()
+
 	meta, ctx, pipeline := c.getPipeline(opts)
 	if len(meta) != 0 && len(events) != len(meta) {
 		if len(meta) != 1 {
@@ -153,6 +162,7 @@ func (c *client) PublishEvents(events []common.MapStr, opts ...ClientOption) boo
 		}
 		data = append(data, evt)
 	}
+	c.unlockPipeline()
 
 	if len(data) == 0 {
 		logp.Debug("filter", "No events to publish")
@@ -216,6 +226,21 @@ func (c *client) filterEvent(event common.MapStr) *common.MapStr {
 	return &publishEvent
 }
 
+// lockPipeline prevents the processors and pipelines from being replaced by
+// a reload, until unlockPipeline is called. The returned WaitGroup must be
+// marked done once the events have been handed to the pipeline, such that a
+// reload can drain the replaced pipeline.
+func (c *client) lockPipeline() *sync.WaitGroup {
+	c.publisher.reloadLock.RLock()
+	inflight := c.publisher.inflight
+	inflight.Add(1)
+	return inflight
+}
+
+func (c *client) unlockPipeline() {
+	c.publisher.reloadLock.RUnlock()
+}
+
 func (c *client) getPipeline(opts []ClientOption) ([]common.MapStr, Context, pipeline) {
 	values, ctx := MakeContext(opts)
 	if ctx.Sync {
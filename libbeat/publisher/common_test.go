@@ -4,6 +4,7 @@ package publisher
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -149,8 +150,10 @@ const (
 
 func newTestPublisher(bulkSize int, response OutputResponse) *testPublisher {
 	pub := &BeatPublisher{}
-	pub.wsOutput.Init()
-	pub.wsPublisher.Init()
+	pub.wsOutput = newWorkerSignal()
+	pub.wsPublisher = newWorkerSignal()
+	pub.inflight = &sync.WaitGroup{}
+	pub.done = make(chan struct{})
 
 	mh := &testMessageHandler{
 		msgs:     make(chan message, 10),
@@ -160,12 +163,12 @@ func newTestPublisher(bulkSize int, response OutputResponse) *testPublisher {
 	ow := &outputWorker{}
 	ow.config.BulkMaxSize = bulkSize
 	ow.handler = mh
-	ow.messageWorker.init(&pub.wsOutput, DefaultQueueSize, DefaultBulkQueueSize, mh)
+	ow.messageWorker.init(pub.wsOutput, DefaultQueueSize, DefaultBulkQueueSize, mh)
 
 	pub.Output = []*outputWorker{ow}
 
-	pub.pipelines.sync = newSyncPipeline(pub, DefaultQueueSize, DefaultBulkQueueSize)
-	pub.pipelines.async = newAsyncPipeline(pub, DefaultQueueSize, DefaultBulkQueueSize, &pub.wsPublisher)
+	pub.pipelines.sync = newSyncPipeline(pub, pub.Output, DefaultQueueSize, DefaultBulkQueueSize)
+	pub.pipelines.async = newAsyncPipeline(pub, pub.Output, DefaultQueueSize, DefaultBulkQueueSize, pub.wsPublisher)
 
 	return &testPublisher{
 		pub:              pub,
@@ -2,6 +2,7 @@ package publisher
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
@@ -15,6 +16,11 @@ type outputWorker struct {
 	out         outputs.BulkOutputer
 	config      outputConfig
 	maxBulkSize int
+
+	// pending counts the messages not yet ACKed by the output
+	pending sync.WaitGroup
+
+	closeOnce sync.Once
 }
 
 type outputConfig struct {
@@ -57,13 +63,47 @@ func newOutputWorker(
 }
 
 func (o *outputWorker) onStop() {
-	err := o.out.Close()
-	if err != nil {
-		logp.Info("Failed to close outputer: %s", err)
+	if o.ws.draining {
+		// Async and load balancing outputs drop the events still queued,
+		// retried or in flight on Close, so wait for them to be ACKed first.
+		o.waitPending()
+	}
+	o.close()
+}
+
+// close closes the outputer once. It is called on stop, or before by the
+// publisher to abort draining an output blocked on publishing.
+func (o *outputWorker) close() {
+	o.closeOnce.Do(func() {
+		err := o.out.Close()
+		if err != nil {
+			logp.Info("Failed to close outputer: %s", err)
+		}
+	})
+}
+
+func (o *outputWorker) waitPending() {
+	done := make(chan struct{})
+	go func() {
+		o.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-o.ws.abort:
+		debug("output worker: stop waiting for pending events")
 	}
 }
 
 func (o *outputWorker) onMessage(m message) {
+	o.pending.Add(1)
+	signal := m.context.Signal
+	m.context.Signal = op.SignalCallback(func(res op.SignalResponse) {
+		res.Apply(signal)
+		o.pending.Done()
+	})
+
 	if m.datum.Event != nil {
 		o.onEvent(&m.context, m.datum)
 	} else {
@@ -3,7 +3,9 @@ package publisher
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,8 +25,10 @@ import (
 	_ "github.com/elastic/beats/libbeat/outputs/redis"
 
 	// load support output codec
+	_ "github.com/elastic/beats/libbeat/outputs/codecs/avro"
 	_ "github.com/elastic/beats/libbeat/outputs/codecs/format"
 	_ "github.com/elastic/beats/libbeat/outputs/codecs/json"
+	_ "github.com/elastic/beats/libbeat/outputs/codecs/msgpack"
 )
 
 // command line flags
@@ -55,6 +59,8 @@ type Publisher interface {
 }
 
 type BeatPublisher struct {
+	beatName       string
+	shipper        ShipperConfig
 	shipperName    string // Shipper name as set in the configuration file
 	hostname       string // Host name as returned by the operation system
 	name           string // The shipperName if configured, the hostname otherwise
@@ -74,14 +80,27 @@ type BeatPublisher struct {
 	// On shutdown the publisher is finished first and the outputers next,
 	// so no publisher will attempt to send messages on closed channels.
 	// Note: beat data producers must be shutdown before the publisher plugin
-	wsPublisher workerSignal
-	wsOutput    workerSignal
+	wsPublisher *workerSignal
+	wsOutput    *workerSignal
 
 	pipelines struct {
 		sync  pipeline
 		async pipeline
 	}
 
+	// reloadLock guards the processors, outputs and pipelines being swapped
+	// on Reload. Clients hold the read lock while filtering events and
+	// selecting the pipeline, and are counted in inflight until the events
+	// have been handed to the pipeline.
+	reloadLock sync.RWMutex
+	inflight   *sync.WaitGroup
+
+	// drains waits for the outputs replaced by Reload to be closed. done is
+	// closed on Stop, so the drains stop waiting for their events to be
+	// ACKed.
+	drains sync.WaitGroup
+	done   chan struct{}
+
 	// keep count of clients connected to publisher. A publisher is allowed to
 	// Stop only if all clients have been disconnected
 	numClients uint32
@@ -114,6 +133,12 @@ func init() {
 	publishDisabled = flag.Bool("N", false, "Disable actual publishing for testing")
 }
 
+// DisablePublishing disables all outputs, like the -N flag. Publishers created
+// afterwards neither create nor connect outputs.
+func DisablePublishing() {
+	*publishDisabled = true
+}
+
 func (publisher *BeatPublisher) IsPublisherIP(ip string) bool {
 	for _, myip := range publisher.IPAddrs {
 		if myip == ip {
@@ -217,55 +242,22 @@ func (publisher *BeatPublisher) init(
 	}
 
 	shipper.InitShipperConfig()
+	publisher.beatName = beatName
+	publisher.shipper = shipper
 
 	publisher.geoLite = common.LoadGeoIPData(shipper.Geoip)
 
-	publisher.wsPublisher.Init()
-	publisher.wsOutput.Init()
+	publisher.wsPublisher = newWorkerSignal()
+	publisher.wsOutput = newWorkerSignal()
+	publisher.done = make(chan struct{})
+	publisher.inflight = &sync.WaitGroup{}
 
 	if !publisher.disabled {
-		plugins, err := outputs.InitOutputs(beatName, configs, shipper.TopologyExpire)
+		outputers, topoOutput, err := publisher.initOutputs(configs, publisher.wsOutput)
 		if err != nil {
 			return err
 		}
 
-		var outputers []*outputWorker
-		var topoOutput outputs.TopologyOutputer
-		for _, plugin := range plugins {
-			output := plugin.Output
-			config := plugin.Config
-
-			debug("Create output worker")
-
-			outputers = append(outputers,
-				newOutputWorker(
-					config,
-					output,
-					&publisher.wsOutput,
-					*shipper.QueueSize,
-					*shipper.BulkQueueSize))
-
-			if ok, _ := config.Bool("save_topology", 0); !ok {
-				continue
-			}
-
-			topo, ok := output.(outputs.TopologyOutputer)
-			if !ok {
-				logp.Err("Output type %s does not support topology logging",
-					plugin.Name)
-				return errors.New("Topology output not supported")
-			}
-
-			if topoOutput != nil {
-				logp.Err("Multiple outputs defined to store topology. " +
-					"Please add save_topology = true option only for one output.")
-				return errors.New("Multiple outputs defined to store topology")
-			}
-
-			topoOutput = topo
-			logp.Info("Using %s to store the topology", plugin.Name)
-		}
-
 		publisher.Output = outputers
 		publisher.TopologyOutput = topoOutput
 	}
@@ -322,16 +314,194 @@ func (publisher *BeatPublisher) init(
 		go publisher.UpdateTopologyPeriodically()
 	}
 
-	publisher.pipelines.async = newAsyncPipeline(publisher, *shipper.QueueSize, *shipper.BulkQueueSize, &publisher.wsPublisher)
-	publisher.pipelines.sync = newSyncPipeline(publisher, *shipper.QueueSize, *shipper.BulkQueueSize)
+	publisher.pipelines.async = newAsyncPipeline(publisher, publisher.Output,
+		*shipper.QueueSize, *shipper.BulkQueueSize, publisher.wsPublisher)
+	publisher.pipelines.sync = newSyncPipeline(publisher, publisher.Output,
+		*shipper.QueueSize, *shipper.BulkQueueSize)
 	return nil
 }
 
+// initOutputs creates the output plugins and their workers. It also returns
+// the output configured to store the topology, if any.
+func (publisher *BeatPublisher) initOutputs(
+	configs map[string]*common.Config,
+	ws *workerSignal,
+) ([]*outputWorker, outputs.TopologyOutputer, error) {
+	shipper := publisher.shipper
+	plugins, err := outputs.InitOutputs(publisher.beatName, configs, shipper.TopologyExpire)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var outputers []*outputWorker
+	var topoOutput outputs.TopologyOutputer
+	for _, plugin := range plugins {
+		output := plugin.Output
+		config := plugin.Config
+
+		debug("Create output worker")
+
+		worker := newOutputWorker(
+			config,
+			output,
+			ws,
+			*shipper.QueueSize,
+			*shipper.BulkQueueSize)
+		if worker == nil {
+			return nil, nil, fmt.Errorf("failed to initialize output worker for %s", plugin.Name)
+		}
+		outputers = append(outputers, worker)
+
+		if ok, _ := config.Bool("save_topology", 0); !ok {
+			continue
+		}
+
+		topo, ok := output.(outputs.TopologyOutputer)
+		if !ok {
+			logp.Err("Output type %s does not support topology logging",
+				plugin.Name)
+			return nil, nil, errors.New("Topology output not supported")
+		}
+
+		if topoOutput != nil {
+			logp.Err("Multiple outputs defined to store topology. " +
+				"Please add save_topology = true option only for one output.")
+			return nil, nil, errors.New("Multiple outputs defined to store topology")
+		}
+
+		topoOutput = topo
+		logp.Info("Using %s to store the topology", plugin.Name)
+	}
+
+	return outputers, topoOutput, nil
+}
+
+// Reload rebuilds the processors and outputs from the given configurations
+// and atomically replaces the running ones. Events already handed to the
+// former outputs are published by these outputs, before they are closed in
+// the background. Outputs not drained within drainTimeout are closed, dropping
+// the events still pending. If the new configuration is invalid, an error is
+// returned and the running processors and outputs are kept.
+//
+// Reloading the outputs is not supported if an output stores the topology.
+func (publisher *BeatPublisher) Reload(
+	configs map[string]*common.Config,
+	processorsConfig processors.PluginConfig,
+	drainTimeout time.Duration,
+) error {
+	procs, err := processors.New(processorsConfig)
+	if err != nil {
+		return fmt.Errorf("error initializing processors: %v", err)
+	}
+
+	if publisher.disabled {
+		publisher.reloadLock.Lock()
+		publisher.Processors = procs
+		publisher.reloadLock.Unlock()
+		return nil
+	}
+
+	if publisher.TopologyOutput != nil {
+		return errors.New("reloading outputs is not supported if save_topology is enabled")
+	}
+
+	wsOutput := newWorkerSignal()
+	outputers, topoOutput, err := publisher.initOutputs(configs, wsOutput)
+	if err == nil && len(outputers) == 0 {
+		err = errors.New("No outputs are defined. Please define one under the output section.")
+	}
+	if err == nil && topoOutput != nil {
+		err = errors.New("reloading outputs is not supported if save_topology is enabled")
+	}
+	if err != nil {
+		// close all outputs created so far
+		wsOutput.stop()
+		return fmt.Errorf("error initializing outputs: %v", err)
+	}
+
+	shipper := publisher.shipper
+	wsPublisher := newWorkerSignal()
+	asyncPipe := newAsyncPipeline(publisher, outputers,
+		*shipper.QueueSize, *shipper.BulkQueueSize, wsPublisher)
+	syncPipe := newSyncPipeline(publisher, outputers,
+		*shipper.QueueSize, *shipper.BulkQueueSize)
+
+	publisher.reloadLock.Lock()
+	oldInflight := publisher.inflight
+	oldOutput := publisher.Output
+	oldWsPublisher := publisher.wsPublisher
+	oldWsOutput := publisher.wsOutput
+
+	publisher.Processors = procs
+	publisher.Output = outputers
+	publisher.pipelines.async = asyncPipe
+	publisher.pipelines.sync = syncPipe
+	publisher.wsPublisher = wsPublisher
+	publisher.wsOutput = wsOutput
+	publisher.inflight = &sync.WaitGroup{}
+	publisher.reloadLock.Unlock()
+
+	logp.Info("Publisher processors and outputs reloaded")
+
+	publisher.drains.Add(1)
+	go func() {
+		defer publisher.drains.Done()
+
+		drained := make(chan struct{})
+		defer close(drained)
+		abort := abortDrain(oldOutput, drainTimeout, drained, publisher.done)
+
+		// wait for clients still publishing to the former pipelines, then
+		// flush all queued and batched events to the former outputs, waiting
+		// for them to be ACKed
+		oldInflight.Wait()
+		oldWsPublisher.drainAndStop(abort)
+		oldWsOutput.drainAndStop(abort)
+		debug("Former outputs drained and closed")
+	}()
+	return nil
+}
+
+// abortDrain closes the returned channel and force closes the outputs if they
+// are not drained within timeout or done is closed first. Closing the outputs
+// unblocks output workers still retrying to publish, so clients waiting for
+// the former pipelines do not block forever.
+func abortDrain(
+	outputs []*outputWorker,
+	timeout time.Duration,
+	drained, done <-chan struct{},
+) <-chan struct{} {
+	abort := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-drained:
+			return
+		case <-done:
+		case <-timer.C:
+			logp.Warn("Former outputs not drained within %v, closing them", timeout)
+		}
+
+		close(abort)
+		for _, o := range outputs {
+			o.close()
+		}
+	}()
+	return abort
+}
+
 func (publisher *BeatPublisher) Stop() {
 	if atomic.LoadUint32(&publisher.numClients) > 0 {
 		panic("All clients must disconnect before shutting down publisher pipeline")
 	}
 
+	close(publisher.done)
+	publisher.drains.Wait()
+
+	publisher.reloadLock.Lock()
+	defer publisher.reloadLock.Unlock()
 	publisher.wsPublisher.stop()
 	publisher.wsOutput.stop()
 }
@@ -0,0 +1,361 @@
+// +build !integration
+
+package publisher
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/op"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode/lb"
+	"github.com/elastic/beats/libbeat/outputs/mode/modetest"
+	"github.com/elastic/beats/libbeat/processors"
+	_ "github.com/elastic/beats/libbeat/processors/actions"
+)
+
+// reloadTestOutput records all events published and whether it has been
+// closed, by the configured output name.
+type reloadTestOutput struct {
+	name string
+}
+
+var reloadTestState = struct {
+	sync.Mutex
+	events map[string][]common.MapStr
+	closed map[string]bool
+}{
+	events: map[string][]common.MapStr{},
+	closed: map[string]bool{},
+}
+
+func init() {
+	outputs.RegisterOutputPlugin("reloadtest", func(
+		_ string,
+		cfg *common.Config,
+		_ int,
+	) (outputs.Outputer, error) {
+		name, err := cfg.String("name", -1)
+		if err != nil || name == "" {
+			return nil, errors.New("name required")
+		}
+		return &reloadTestOutput{name: name}, nil
+	})
+}
+
+// reloadTestAsyncOutput is a load balancing output holding all batches until
+// reloadTestRelease is closed.
+type reloadTestAsyncOutput struct {
+	*lb.LB
+	name string
+}
+
+var (
+	reloadTestPublished chan struct{}
+	reloadTestRelease   chan struct{}
+)
+
+func init() {
+	outputs.RegisterOutputPlugin("reloadtestasync", func(
+		_ string,
+		cfg *common.Config,
+		_ int,
+	) (outputs.Outputer, error) {
+		name, err := cfg.String("name", -1)
+		if err != nil || name == "" {
+			return nil, errors.New("name required")
+		}
+
+		published, release := reloadTestPublished, reloadTestRelease
+		clients := modetest.AsyncClients(1, &modetest.MockClient{
+			CBAsyncPublish: func(cb func([]outputs.Data, error), data []outputs.Data) error {
+				published <- struct{}{}
+				go func() {
+					<-release
+					reloadTestState.Lock()
+					for _, d := range data {
+						reloadTestState.events[name] = append(reloadTestState.events[name], d.Event)
+					}
+					reloadTestState.Unlock()
+					cb(nil, nil)
+				}()
+				return nil
+			},
+		})
+		mode, err := lb.NewAsync(clients, 1, time.Millisecond, time.Second, time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return &reloadTestAsyncOutput{LB: mode, name: name}, nil
+	})
+}
+
+func (o *reloadTestAsyncOutput) Close() error {
+	err := o.LB.Close()
+	reloadTestState.Lock()
+	defer reloadTestState.Unlock()
+	reloadTestState.closed[o.name] = true
+	return err
+}
+
+// reloadTestBlockingOutput never publishes any event, blocking until closed.
+type reloadTestBlockingOutput struct {
+	reloadTestOutput
+	done chan struct{}
+}
+
+func init() {
+	outputs.RegisterOutputPlugin("reloadtestblocking", func(
+		_ string,
+		cfg *common.Config,
+		_ int,
+	) (outputs.Outputer, error) {
+		name, err := cfg.String("name", -1)
+		if err != nil || name == "" {
+			return nil, errors.New("name required")
+		}
+		return &reloadTestBlockingOutput{
+			reloadTestOutput: reloadTestOutput{name: name},
+			done:             make(chan struct{}),
+		}, nil
+	})
+}
+
+func (o *reloadTestBlockingOutput) PublishEvent(sig op.Signaler, _ outputs.Options, _ outputs.Data) error {
+	<-o.done
+	op.SigFailed(sig, errors.New("output closed"))
+	return nil
+}
+
+func (o *reloadTestBlockingOutput) Close() error {
+	close(o.done)
+	return o.reloadTestOutput.Close()
+}
+
+func (o *reloadTestOutput) PublishEvent(sig op.Signaler, _ outputs.Options, data outputs.Data) error {
+	reloadTestState.Lock()
+	reloadTestState.events[o.name] = append(reloadTestState.events[o.name], data.Event)
+	reloadTestState.Unlock()
+
+	op.SigCompleted(sig)
+	return nil
+}
+
+func (o *reloadTestOutput) Close() error {
+	reloadTestState.Lock()
+	defer reloadTestState.Unlock()
+	reloadTestState.closed[o.name] = true
+	return nil
+}
+
+func reloadTestEvents(name string) ([]common.MapStr, bool) {
+	reloadTestState.Lock()
+	defer reloadTestState.Unlock()
+	return reloadTestState.events[name], reloadTestState.closed[name]
+}
+
+func reloadTestOutputs(t *testing.T, name string) map[string]*common.Config {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"name": name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]*common.Config{"reloadtest": cfg}
+}
+
+func resetReloadTestState() {
+	reloadTestState.Lock()
+	reloadTestState.events = map[string][]common.MapStr{}
+	reloadTestState.closed = map[string]bool{}
+	reloadTestState.Unlock()
+
+	reloadTestPublished = make(chan struct{}, 10)
+	reloadTestRelease = make(chan struct{})
+}
+
+func TestReload(t *testing.T) {
+	resetReloadTestState()
+
+	procs, _ := processors.New(nil)
+	pub, err := New("testbeat", "1.0.0", reloadTestOutputs(t, "before"), ShipperConfig{}, procs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := pub.Connect()
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 1, "x": true}, Sync))
+
+	// events still batched by the async pipeline are drained to the former
+	// output on reload
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 2, "x": true}))
+
+	dropX, err := common.NewConfigFrom(map[string]interface{}{
+		"fields": []string{"x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	processorsConfig := processors.PluginConfig{{"drop_fields": *dropX}}
+
+	err = pub.Reload(reloadTestOutputs(t, "after"), processorsConfig, 10*time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 3, "x": true}, Sync))
+
+	pub.drains.Wait()
+	before, closed := reloadTestEvents("before")
+	assert.True(t, closed)
+	if assert.Len(t, before, 2) {
+		assert.Equal(t, 1, before[0]["n"])
+		assert.Equal(t, 2, before[1]["n"])
+	}
+
+	after, closed := reloadTestEvents("after")
+	assert.False(t, closed)
+	if assert.Len(t, after, 1) {
+		assert.Equal(t, 3, after[0]["n"])
+		assert.NotContains(t, after[0], "x")
+	}
+
+	// invalid configurations are rejected, keeping the running outputs
+	err = pub.Reload(reloadTestOutputs(t, ""), processorsConfig, 10*time.Second)
+	assert.Error(t, err)
+	err = pub.Reload(map[string]*common.Config{}, processorsConfig, 10*time.Second)
+	assert.Error(t, err)
+
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 4}, Sync))
+	after, _ = reloadTestEvents("after")
+	assert.Len(t, after, 2)
+
+	client.Close()
+	pub.Stop()
+	_, closed = reloadTestEvents("after")
+	assert.True(t, closed)
+}
+
+func TestReloadWaitsForAsyncOutput(t *testing.T) {
+	resetReloadTestState()
+
+	cfg, _ := common.NewConfigFrom(map[string]interface{}{"name": "before"})
+	configs := map[string]*common.Config{"reloadtestasync": cfg}
+
+	procs, _ := processors.New(nil)
+	pub, err := New("testbeat", "1.0.0", configs, ShipperConfig{}, procs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := pub.Connect()
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 1}))
+
+	err = pub.Reload(reloadTestOutputs(t, "after"), nil, 10*time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the former output must not be closed while it has events in flight
+	select {
+	case <-reloadTestPublished:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the former output to publish")
+	}
+	time.Sleep(50 * time.Millisecond)
+	_, closed := reloadTestEvents("before")
+	assert.False(t, closed)
+
+	close(reloadTestRelease)
+	pub.drains.Wait()
+	before, closed := reloadTestEvents("before")
+	assert.True(t, closed)
+	if assert.Len(t, before, 1) {
+		assert.Equal(t, 1, before[0]["n"])
+	}
+
+	client.Close()
+	pub.Stop()
+}
+
+func TestReloadStopAbortsDrain(t *testing.T) {
+	resetReloadTestState()
+	defer close(reloadTestRelease)
+
+	cfg, _ := common.NewConfigFrom(map[string]interface{}{"name": "before"})
+	configs := map[string]*common.Config{"reloadtestasync": cfg}
+
+	procs, _ := processors.New(nil)
+	pub, err := New("testbeat", "1.0.0", configs, ShipperConfig{}, procs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := pub.Connect()
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 1}))
+	err = pub.Reload(reloadTestOutputs(t, "after"), nil, 10*time.Second)
+	if !assert.NoError(t, err) {
+		return
+	}
+	<-reloadTestPublished
+
+	// events never ACKed by the former output must not block the shutdown
+	stopped := make(chan struct{})
+	go func() {
+		client.Close()
+		pub.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the publisher to stop")
+	}
+	_, closed := reloadTestEvents("before")
+	assert.True(t, closed)
+}
+
+func TestReloadDrainTimeout(t *testing.T) {
+	resetReloadTestState()
+
+	cfg, _ := common.NewConfigFrom(map[string]interface{}{"name": "blocked"})
+	configs := map[string]*common.Config{"reloadtestblocking": cfg}
+
+	procs, _ := processors.New(nil)
+	pub, err := New("testbeat", "1.0.0", configs, ShipperConfig{}, procs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := pub.Connect()
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 1}))
+	err = pub.Reload(reloadTestOutputs(t, "after"), nil, 50*time.Millisecond)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the former output blocked on publishing is closed after the timeout
+	drained := make(chan struct{})
+	go func() {
+		pub.drains.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the former output to be closed")
+	}
+	blocked, closed := reloadTestEvents("blocked")
+	assert.True(t, closed)
+	assert.Len(t, blocked, 0)
+
+	assert.True(t, client.PublishEvent(common.MapStr{"n": 2}, Sync))
+	after, _ := reloadTestEvents("after")
+	assert.Len(t, after, 1)
+
+	client.Close()
+	pub.Stop()
+}
@@ -3,11 +3,16 @@ package publisher
 import "github.com/elastic/beats/libbeat/common/op"
 
 type syncPipeline struct {
-	pub *BeatPublisher
+	pub     *BeatPublisher
+	outputs []*outputWorker
 }
 
-func newSyncPipeline(pub *BeatPublisher, hwm, bulkHWM int) *syncPipeline {
-	return &syncPipeline{pub: pub}
+func newSyncPipeline(
+	pub *BeatPublisher,
+	outputs []*outputWorker,
+	hwm, bulkHWM int,
+) *syncPipeline {
+	return &syncPipeline{pub: pub, outputs: outputs}
 }
 
 func (p *syncPipeline) publish(m message) bool {
@@ -20,13 +25,13 @@ func (p *syncPipeline) publish(m message) bool {
 	client := m.client
 	signal := m.context.Signal
 	sync := op.NewSignalChannel()
-	if len(p.pub.Output) > 1 {
-		m.context.Signal = op.SplitSignaler(sync, len(p.pub.Output))
+	if len(p.outputs) > 1 {
+		m.context.Signal = op.SplitSignaler(sync, len(p.outputs))
 	} else {
 		m.context.Signal = sync
 	}
 
-	for _, o := range p.pub.Output {
+	for _, o := range p.outputs {
 		o.send(m)
 	}
 
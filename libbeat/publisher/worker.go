@@ -27,6 +27,14 @@ type messageWorker struct {
 type workerSignal struct {
 	done chan struct{}
 	wg   sync.WaitGroup
+
+	// draining is set if the workers must process all queued messages
+	// before shutting down.
+	draining bool
+
+	// abort stops the draining workers from waiting for their events to be
+	// ACKed.
+	abort <-chan struct{}
 }
 
 type message struct {
@@ -72,12 +80,29 @@ func (p *messageWorker) run() {
 }
 
 func (p *messageWorker) shutdown() {
+	if p.ws.draining {
+		p.drain()
+	}
 	p.handler.onStop()
 	stopQueue(p.queue)
 	stopQueue(p.bulkQueue)
 	p.ws.wg.Done()
 }
 
+// drain processes all messages left in the queues.
+func (p *messageWorker) drain() {
+	for {
+		select {
+		case m := <-p.queue:
+			p.onEvent(m)
+		case m := <-p.bulkQueue:
+			p.onEvent(m)
+		default:
+			return
+		}
+	}
+}
+
 func (p *messageWorker) onEvent(m message) {
 	messagesInWorkerQueues.Add(-1)
 	p.handler.onMessage(m)
@@ -92,6 +117,15 @@ func (ws *workerSignal) stop() {
 	ws.wg.Wait()
 }
 
+// drainAndStop stops the workers after they processed all queued messages.
+// No more messages must be send to the workers. Closing abort stops the
+// workers from waiting for the events still in flight.
+func (ws *workerSignal) drainAndStop(abort <-chan struct{}) {
+	ws.draining = true
+	ws.abort = abort
+	ws.stop()
+}
+
 func newWorkerSignal() *workerSignal {
 	w := &workerSignal{}
 	w.Init()
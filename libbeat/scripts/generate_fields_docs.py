@@ -55,6 +55,36 @@ def document_field(output, field, path):
         output.write("{}\n\n".format(field["description"]))
 
 
+def top_level_fields(section):
+    """
+    Yields the name of each top-level field of the section and whether the
+    field is an object.
+    """
+    for field in section.get("fields") or []:
+        name = field["name"]
+        if "." in name:
+            yield name.split(".")[0], True
+        else:
+            yield name, field.get("type") == "group"
+
+
+def overridden_sections(sections):
+    """
+    Returns the keys of the sections defining a top-level object, that a later
+    section defines as a single value. The later definition is the one used in
+    the template, so the fields of the object can't be exported.
+    """
+    keys = set()
+    for i, section in enumerate(sections):
+        objects = set(name for name, obj in top_level_fields(section) if obj)
+        for later in sections[i + 1:]:
+            values = set(name for name, obj in top_level_fields(later) if not obj)
+            if objects & values:
+                keys.add(section["key"])
+                break
+    return keys
+
+
 def fields_to_asciidoc(input, output, beat):
 
     dict = {'beat': beat}
@@ -82,6 +112,11 @@ grouped in the following categories:
         print("fields.yml file is empty. fields.asciidoc cannot be generated.")
         return
 
+    # Drop the sections conflicting with the fields of the beat, like the host
+    # metadata with the monitored host of Heartbeat
+    overridden = overridden_sections(docs["fields"])
+    docs["fields"] = [v for v in docs["fields"] if v["key"] not in overridden]
+
     # Create sections from available fields
     sections = {}
     for v in docs["fields"]:
@@ -2,6 +2,26 @@
 
 package service
 
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
 // ProcessWindowsControlEvents is not used on non-windows platforms.
 func ProcessWindowsControlEvents(stopCallback func()) {
 }
+
+// HandleReload calls reloadFunction whenever SIGHUP is received.
+func HandleReload(reloadFunction func()) {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+	go func() {
+		for range sigc {
+			logp.Debug("service", "Received sighup, reloading")
+			reloadFunction()
+		}
+	}()
+}
@@ -61,3 +61,7 @@ func ProcessWindowsControlEvents(stopCallback func()) {
 		stopCallback()
 	}
 }
+
+// HandleReload is not supported on windows, as there is no SIGHUP.
+func HandleReload(reloadFunction func()) {
+}
@@ -0,0 +1,78 @@
+package testing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConsoleDriver prints the steps to a writer, indenting nested steps.
+type ConsoleDriver struct {
+	out    io.Writer
+	level  int
+	failed *bool
+}
+
+// fatalError stops the function run by Run.
+type fatalError struct{}
+
+// NewConsoleDriver creates a driver printing to out.
+func NewConsoleDriver(out io.Writer) *ConsoleDriver {
+	return &ConsoleDriver{out: out, failed: new(bool)}
+}
+
+// Failed returns true if any error has been reported.
+func (d *ConsoleDriver) Failed() bool {
+	return *d.failed
+}
+
+func (d *ConsoleDriver) Run(name string, f func(Driver)) {
+	d.printf("%s...\n", name)
+
+	nested := &ConsoleDriver{out: d.out, level: d.level + 1, failed: d.failed}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(fatalError); !ok {
+				panic(r)
+			}
+		}
+	}()
+	f(nested)
+}
+
+func (d *ConsoleDriver) Info(field, value string) {
+	d.printf("%s: %s\n", field, value)
+}
+
+func (d *ConsoleDriver) Warn(field, reason string) {
+	d.printf("%s... WARN %s\n", field, reason)
+}
+
+func (d *ConsoleDriver) Error(field string, err error) {
+	if err == nil {
+		d.printf("%s... OK\n", field)
+		return
+	}
+
+	*d.failed = true
+	d.printf("%s... ERROR %v\n", field, err)
+}
+
+func (d *ConsoleDriver) Fatal(field string, err error) {
+	d.Error(field, err)
+	if err != nil {
+		panic(fatalError{})
+	}
+}
+
+func (d *ConsoleDriver) Result(data string) {
+	d.printf("result:\n")
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		d.printf("  %s\n", line)
+	}
+}
+
+func (d *ConsoleDriver) printf(format string, v ...interface{}) {
+	fmt.Fprint(d.out, strings.Repeat("  ", d.level))
+	fmt.Fprintf(d.out, format, v...)
+}
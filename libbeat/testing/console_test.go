@@ -0,0 +1,48 @@
+// +build !integration
+
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleDriver(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewConsoleDriver(&buf)
+
+	d.Run("output", func(d Driver) {
+		d.Error("parse url", nil)
+		d.Run("connection", func(d Driver) {
+			d.Info("addresses", "127.0.0.1")
+			d.Fatal("dial up", errors.New("connection refused"))
+			d.Info("never", "reported")
+		})
+		d.Warn("TLS", "secure connection disabled")
+		d.Result("line 1\nline 2\n")
+	})
+
+	assert.True(t, d.Failed())
+	assert.Equal(t, `output...
+  parse url... OK
+  connection...
+    addresses: 127.0.0.1
+    dial up... ERROR connection refused
+  TLS... WARN secure connection disabled
+  result:
+    line 1
+    line 2
+`, buf.String())
+}
+
+func TestConsoleDriverOK(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewConsoleDriver(&buf)
+	d.Run("output", func(d Driver) {
+		d.Error("talk to server", nil)
+	})
+	assert.False(t, d.Failed())
+}
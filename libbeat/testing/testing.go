@@ -0,0 +1,32 @@
+// Package testing provides the means for components, e.g. outputs, to report
+// the steps and results of testing their configuration against the real
+// systems, like the `test output` command does.
+package testing
+
+// Driver reports the steps of a test. Steps can be nested with Run.
+type Driver interface {
+	// Run runs the named step. Steps reported by f are nested. A fatal error
+	// reported by f stops f, but not the current step.
+	Run(name string, f func(Driver))
+
+	// Info reports details of the current step.
+	Info(field, value string)
+
+	// Warn reports a problem, not failing the test.
+	Warn(field, reason string)
+
+	// Error reports the result of the field, failing the test if err is set.
+	Error(field string, err error)
+
+	// Fatal reports the result of the field, like Error. If err is set, the
+	// current step is stopped.
+	Fatal(field string, err error)
+
+	// Result reports data returned by the tested system.
+	Result(data string)
+}
+
+// Testable is implemented by components that can test their configuration.
+type Testable interface {
+	Test(d Driver)
+}
@@ -0,0 +1,223 @@
+package autodiscover
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var debugf = logp.MakeDebug("autodiscover")
+
+// Config is the configuration of the autodiscover subsystem.
+type Config struct {
+	Providers []*common.Config `config:"providers" validate:"required"`
+}
+
+// Event types sent by the providers.
+const (
+	EventStart = "start"
+	EventStop  = "stop"
+)
+
+// Event notifies about a started or stopped container. A start event for a
+// known container updates its data. The data contains one entry per
+// container and port. The ID must be unique within the provider.
+type Event struct {
+	Type string
+	ID   string
+	Data []common.MapStr
+}
+
+// Provider watches for containers. Run sends an Event whenever a container
+// starts or stops, until the done channel is closed. Sending an event must be
+// aborted once the done channel is closed.
+type Provider interface {
+	Run(events chan<- Event, done <-chan struct{})
+	Templates() []*Template
+}
+
+// ProviderBuilder creates a Provider from its configuration.
+type ProviderBuilder func(c *common.Config) (Provider, error)
+
+var providers = map[string]ProviderBuilder{}
+
+// RegisterProvider registers a provider type.
+func RegisterProvider(name string, builder ProviderBuilder) {
+	if _, exists := providers[name]; exists {
+		panic(fmt.Sprintf("autodiscover provider '%v' is already registered", name))
+	}
+	providers[name] = builder
+}
+
+// Autodiscover starts a module runner for every module config created by the
+// templates of the providers for a container, and stops the runners when the
+// container goes away.
+type Autodiscover struct {
+	factory   cfgfile.RunnerFactory
+	providers []Provider
+
+	// runners are the runners per provider and container ID, by runner ID.
+	mutex   sync.Mutex
+	runners map[string]map[uint64]cfgfile.Runner
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAutodiscover creates the providers of the configuration. The runners are
+// created with the given factory.
+func NewAutodiscover(factory cfgfile.RunnerFactory, c *common.Config) (*Autodiscover, error) {
+	config := Config{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	a := &Autodiscover{
+		factory: factory,
+		runners: map[string]map[uint64]cfgfile.Runner{},
+		done:    make(chan struct{}),
+	}
+
+	for _, pc := range config.Providers {
+		providerType := struct {
+			Type string `config:"type" validate:"required"`
+		}{}
+		if err := pc.Unpack(&providerType); err != nil {
+			return nil, err
+		}
+
+		builder, found := providers[providerType.Type]
+		if !found {
+			return nil, fmt.Errorf("unknown autodiscover provider '%v'", providerType.Type)
+		}
+		provider, err := builder(pc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create autodiscover provider '%v': %v", providerType.Type, err)
+		}
+		a.providers = append(a.providers, provider)
+	}
+
+	return a, nil
+}
+
+// Start starts the providers and handles their events.
+func (a *Autodiscover) Start() {
+	for i, p := range a.providers {
+		events := make(chan Event)
+		a.wg.Add(2)
+		go func(p Provider) {
+			defer a.wg.Done()
+			p.Run(events, a.done)
+		}(p)
+		go func(i int, p Provider) {
+			defer a.wg.Done()
+			for {
+				select {
+				case <-a.done:
+					return
+				case e := <-events:
+					a.handle(p, fmt.Sprintf("%d/%s", i, e.ID), e)
+				}
+			}
+		}(i, p)
+	}
+}
+
+// Stop stops the providers and all runners started by them.
+func (a *Autodiscover) Stop() {
+	close(a.done)
+	a.wg.Wait()
+	a.stopAll()
+}
+
+// handle updates the runners of a container. Runners whose config is still
+// created by the templates keep running.
+func (a *Autodiscover) handle(p Provider, key string, e Event) {
+	debugf("Autodiscover event %v for %v: %v", e.Type, key, e.Data)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	current := a.runners[key]
+	next := map[uint64]cfgfile.Runner{}
+
+	if e.Type == EventStart {
+		for _, data := range e.Data {
+			for _, t := range p.Templates() {
+				configs, err := t.Apply(data)
+				if err != nil {
+					logp.Err("Error applying autodiscover template to %v: %v", data, err)
+					continue
+				}
+
+				for _, c := range configs {
+					if !c.Enabled() {
+						continue
+					}
+
+					runner, err := a.factory.Create(c)
+					if err != nil {
+						logp.Err("Error creating module from autodiscover template: %v", err)
+						continue
+					}
+
+					// A runner already running or created for another
+					// config is kept, the new one is stopped to release the
+					// resources it holds, like its publisher client.
+					if running, found := current[runner.ID()]; found {
+						next[runner.ID()] = running
+						runner.Stop()
+					} else if _, found := next[runner.ID()]; found {
+						runner.Stop()
+					} else {
+						next[runner.ID()] = runner
+					}
+				}
+			}
+		}
+	}
+
+	for id, runner := range current {
+		if _, found := next[id]; !found {
+			logp.Info("Autodiscover stopping module %v of %v", id, key)
+			runner.Stop()
+		}
+	}
+	for id, runner := range next {
+		if _, found := current[id]; !found {
+			logp.Info("Autodiscover starting module %v of %v", id, key)
+			runner.Start()
+		}
+	}
+
+	if len(next) > 0 {
+		a.runners[key] = next
+	} else {
+		delete(a.runners, key)
+	}
+}
+
+func (a *Autodiscover) stopAll() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for key, runners := range a.runners {
+		for _, runner := range runners {
+			runner.Stop()
+		}
+		delete(a.runners, key)
+	}
+}
+
+// sendEvent sends the event unless the done channel is closed first.
+func sendEvent(events chan<- Event, done <-chan struct{}, e Event) bool {
+	select {
+	case <-done:
+		return false
+	case events <- e:
+		return true
+	}
+}
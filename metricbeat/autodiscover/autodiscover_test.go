@@ -0,0 +1,168 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"hash/fnv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type testProvider struct {
+	templates []*Template
+}
+
+func (p *testProvider) Run(events chan<- Event, done <-chan struct{}) { <-done }
+func (p *testProvider) Templates() []*Template                        { return p.templates }
+
+// testFactory creates runners identified by their hosts.
+type testFactory struct {
+	mutex   sync.Mutex
+	runners []*testRunner
+}
+
+func (f *testFactory) Create(c *common.Config) (cfgfile.Runner, error) {
+	var config struct {
+		Hosts []string `config:"hosts"`
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	h := fnv.New64()
+	for _, host := range config.Hosts {
+		h.Write([]byte(host))
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	r := &testRunner{id: h.Sum64(), hosts: config.Hosts}
+	f.runners = append(f.runners, r)
+	return r, nil
+}
+
+// started returns the hosts of the running runners.
+func (f *testFactory) started() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var hosts []string
+	for _, r := range f.runners {
+		if r.started && !r.stopped {
+			hosts = append(hosts, r.hosts...)
+		}
+	}
+	return hosts
+}
+
+type testRunner struct {
+	id               uint64
+	hosts            []string
+	started, stopped bool
+}
+
+func (r *testRunner) Start()     { r.started = true }
+func (r *testRunner) Stop()      { r.stopped = true }
+func (r *testRunner) ID() uint64 { return r.id }
+
+func TestAutodiscoverHandle(t *testing.T) {
+	provider := &testProvider{
+		templates: newTestTemplates(t, map[string]interface{}{
+			"templates": []map[string]interface{}{
+				{
+					"condition": map[string]interface{}{
+						"equals": map[string]interface{}{"image": "redis"},
+					},
+					"config": []map[string]interface{}{
+						{"module": "redis", "hosts": []string{"${data.host}:${data.port}"}},
+					},
+				},
+			},
+		}),
+	}
+	factory := &testFactory{}
+	a := &Autodiscover{
+		factory:   factory,
+		providers: []Provider{provider},
+		runners:   map[string]map[uint64]cfgfile.Runner{},
+		done:      make(chan struct{}),
+	}
+
+	// A container not matching any template.
+	a.handle(provider, "0/a", Event{Type: EventStart, ID: "a", Data: []common.MapStr{
+		{"image": "nginx", "host": "10.0.0.1", "port": 80},
+	}})
+	assert.Empty(t, factory.started())
+
+	a.handle(provider, "0/b", Event{Type: EventStart, ID: "b", Data: []common.MapStr{
+		{"image": "redis", "host": "10.0.0.2", "port": 6379},
+		{"image": "redis", "host": "10.0.0.2", "port": 6380},
+	}})
+	assert.Equal(t, []string{"10.0.0.2:6379", "10.0.0.2:6380"}, factory.started())
+
+	// An update keeps the runners with unchanged config running.
+	a.handle(provider, "0/b", Event{Type: EventStart, ID: "b", Data: []common.MapStr{
+		{"image": "redis", "host": "10.0.0.2", "port": 6379},
+	}})
+	assert.Equal(t, []string{"10.0.0.2:6379"}, factory.started())
+	assert.Len(t, factory.runners, 3)
+	assert.True(t, factory.runners[0].started)
+	// The runner created for the running config is stopped without starting.
+	assert.False(t, factory.runners[2].started)
+	assert.True(t, factory.runners[2].stopped)
+
+	a.handle(provider, "0/b", Event{Type: EventStop, ID: "b"})
+	assert.Empty(t, factory.started())
+	assert.Empty(t, a.runners)
+
+	// Runners of configs created twice are only started once.
+	a.handle(provider, "0/c", Event{Type: EventStart, ID: "c", Data: []common.MapStr{
+		{"image": "redis", "host": "10.0.0.3", "port": 6379},
+		{"image": "redis", "host": "10.0.0.3", "port": 6379},
+	}})
+	assert.Equal(t, []string{"10.0.0.3:6379"}, factory.started())
+	assert.Len(t, factory.runners, 5)
+	assert.False(t, factory.runners[4].started)
+	assert.True(t, factory.runners[4].stopped)
+}
+
+func TestAutodiscoverStop(t *testing.T) {
+	factory := &testFactory{}
+	a := &Autodiscover{
+		factory: factory,
+		runners: map[string]map[uint64]cfgfile.Runner{},
+		done:    make(chan struct{}),
+	}
+	provider := &testProvider{
+		templates: newTestTemplates(t, map[string]interface{}{
+			"templates": []map[string]interface{}{
+				{"config": []map[string]interface{}{{"hosts": []string{"${data.host}"}}}},
+			},
+		}),
+	}
+	a.providers = []Provider{provider}
+
+	a.Start()
+	a.handle(provider, "0/a", Event{Type: EventStart, ID: "a", Data: []common.MapStr{{"host": "10.0.0.1"}}})
+	assert.Equal(t, []string{"10.0.0.1"}, factory.started())
+
+	a.Stop()
+	assert.Empty(t, factory.started())
+}
+
+func TestNewAutodiscoverUnknownProvider(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"providers": []map[string]interface{}{{"type": "unknown"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewAutodiscover(&testFactory{}, c)
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package autodiscover
+
+import (
+	"errors"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/module/docker"
+)
+
+func init() {
+	RegisterProvider("docker", newDockerProvider)
+}
+
+type dockerConfig struct {
+	Host      string            `config:"host"`
+	TLS       *docker.TLSConfig `config:"ssl"`
+	Templates []TemplateConfig  `config:"templates"`
+}
+
+// dockerProvider watches the Docker events API for started and stopped
+// containers. A container is reported once per exposed port.
+type dockerProvider struct {
+	watcher   *docker.Watcher
+	templates []*Template
+
+	// running are the IDs of the containers reported as started.
+	running map[string]struct{}
+}
+
+func newDockerProvider(c *common.Config) (Provider, error) {
+	config := dockerConfig{
+		Host: "unix:///var/run/docker.sock",
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	watcher, err := docker.NewWatcher(config.Host, docker.Config{TLS: config.TLS})
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := NewTemplates(config.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dockerProvider{
+		watcher:   watcher,
+		templates: templates,
+		running:   map[string]struct{}{},
+	}, nil
+}
+
+func (p *dockerProvider) Templates() []*Template {
+	return p.templates
+}
+
+// Run watches the containers. If the connection to Docker fails, it is
+// retried after 3 seconds.
+func (p *dockerProvider) Run(events chan<- Event, done <-chan struct{}) {
+	for {
+		if err := p.watch(events, done); err != nil {
+			logp.Err("Error watching Docker events: %v", err)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// watch reports the running containers and then the containers started or
+// stopped, until the done channel is closed or the connection fails.
+func (p *dockerProvider) watch(events chan<- Event, done <-chan struct{}) error {
+	// Subscribe before listing the containers, so no container starting in
+	// between is missed.
+	containerEvents, stop, err := p.watcher.Watch()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if err := p.sync(events, done); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case e, ok := <-containerEvents:
+			if !ok {
+				return errors.New("connection to the Docker events API closed")
+			}
+
+			switch e.Action {
+			case "start":
+				if err := p.start(events, done, e.ID); err != nil {
+					logp.Err("Error inspecting Docker container %v: %v", e.ID, err)
+				}
+			case "die":
+				p.stop(events, done, e.ID)
+			}
+		}
+	}
+}
+
+// sync reports the running containers not known yet and the known containers
+// not running anymore.
+func (p *dockerProvider) sync(events chan<- Event, done <-chan struct{}) error {
+	ids, err := p.watcher.RunningContainers()
+	if err != nil {
+		return err
+	}
+
+	running := map[string]struct{}{}
+	for _, id := range ids {
+		running[id] = struct{}{}
+		if _, found := p.running[id]; found {
+			continue
+		}
+		if err := p.start(events, done, id); err != nil {
+			logp.Err("Error inspecting Docker container %v: %v", id, err)
+		}
+	}
+
+	for id := range p.running {
+		if _, found := running[id]; !found {
+			p.stop(events, done, id)
+		}
+	}
+	return nil
+}
+
+func (p *dockerProvider) start(events chan<- Event, done <-chan struct{}, id string) error {
+	c, err := p.watcher.Inspect(id)
+	if err != nil {
+		return err
+	}
+	if !c.Running {
+		return nil
+	}
+
+	if sendEvent(events, done, Event{Type: EventStart, ID: c.ID, Data: containerData(c)}) {
+		p.running[c.ID] = struct{}{}
+	}
+	return nil
+}
+
+func (p *dockerProvider) stop(events chan<- Event, done <-chan struct{}, id string) {
+	if _, found := p.running[id]; !found {
+		return
+	}
+	if sendEvent(events, done, Event{Type: EventStop, ID: id}) {
+		delete(p.running, id)
+	}
+}
+
+// containerData returns the container data, once per exposed TCP or UDP port.
+// The dots in label names are replaced by underscores.
+func containerData(c *docker.ContainerInfo) []common.MapStr {
+	base := common.MapStr{
+		"id":    c.ID,
+		"name":  c.Name,
+		"image": c.Image,
+	}
+	if len(c.Labels) > 0 {
+		base["labels"] = docker.DeDotLabels(c.Labels)
+	}
+	if c.IP != "" {
+		base["host"] = c.IP
+	}
+
+	if len(c.Ports) == 0 {
+		return []common.MapStr{base}
+	}
+
+	data := make([]common.MapStr, 0, len(c.Ports))
+	for _, port := range c.Ports {
+		d := base.Clone()
+		d["port"] = port
+		data = append(data, d)
+	}
+	return data
+}
@@ -0,0 +1,135 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/module/docker"
+)
+
+func TestDockerContainerData(t *testing.T) {
+	c := &docker.ContainerInfo{
+		ID:      "abc",
+		Name:    "redis",
+		Image:   "redis:3.2",
+		Labels:  map[string]string{"com.example.team": "ops"},
+		Running: true,
+		IP:      "172.17.0.2",
+		Ports:   []int{6379, 6380},
+	}
+
+	base := common.MapStr{
+		"id":     "abc",
+		"name":   "redis",
+		"image":  "redis:3.2",
+		"labels": common.MapStr{"com_example_team": "ops"},
+		"host":   "172.17.0.2",
+	}
+	first, second := base.Clone(), base.Clone()
+	first["port"] = 6379
+	second["port"] = 6380
+	assert.Equal(t, []common.MapStr{first, second}, containerData(c))
+
+	c.Ports = nil
+	assert.Equal(t, []common.MapStr{base}, containerData(c))
+}
+
+func TestDockerProvider(t *testing.T) {
+	eventsStream := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case e := <-eventsStream:
+				w.Write([]byte(e))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id": "abc"}]`))
+	})
+	mux.HandleFunc("/containers/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/containers/"), "/")[0]
+		w.Write([]byte(`{"Id": "` + id + `", "Name": "/` + id + `", "State": {"Running": true},
+			"Config": {"Image": "redis"}, "NetworkSettings": {"IPAddress": "172.17.0.2"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer func() {
+		// The client only notices the removal of its last listener on the
+		// next event, so the events connection is still open here.
+		server.CloseClientConnections()
+		server.Close()
+	}()
+
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"host": "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := newDockerProvider(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan Event)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		provider.Run(events, done)
+		close(stopped)
+	}()
+
+	e := receiveEvent(t, events)
+	assert.Equal(t, EventStart, e.Type)
+	assert.Equal(t, "abc", e.ID)
+	assert.Equal(t, []common.MapStr{{"id": "abc", "name": "abc", "image": "redis", "host": "172.17.0.2"}}, e.Data)
+
+	eventsStream <- `{"Type": "container", "Action": "start", "Actor": {"ID": "def"}, "time": 1}`
+	e = receiveEvent(t, events)
+	assert.Equal(t, EventStart, e.Type)
+	assert.Equal(t, "def", e.ID)
+
+	eventsStream <- `{"Type": "container", "Action": "die", "Actor": {"ID": "abc"}, "time": 2}`
+	e = receiveEvent(t, events)
+	assert.Equal(t, Event{Type: EventStop, ID: "abc"}, e)
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("provider didn't stop")
+	}
+}
+
+func TestDockerProviderInvalidHost(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{"host": "ftp://localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newDockerProvider(c)
+	assert.Error(t, err)
+}
+
+func receiveEvent(t *testing.T, events <-chan Event) Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+		return Event{}
+	}
+}
@@ -0,0 +1,108 @@
+package autodiscover
+
+import (
+	"reflect"
+
+	"github.com/laincloud/lainlet/watcher/container"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/lainlet"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+func init() {
+	RegisterProvider("lainlet", newLainletProvider)
+}
+
+type lainletConfig struct {
+	LainletAddress string           `config:"lainlet_address" validate:"required"`
+	Templates      []TemplateConfig `config:"templates"`
+}
+
+// lainletProvider watches the LAIN containers of this node with lainlet.
+type lainletProvider struct {
+	address   string
+	templates []*Template
+
+	// containers are the containers reported as started, by container ID.
+	containers map[string]container.Info
+}
+
+func newLainletProvider(c *common.Config) (Provider, error) {
+	config := lainletConfig{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	templates, err := NewTemplates(config.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lainletProvider{
+		address:    config.LainletAddress,
+		templates:  templates,
+		containers: map[string]container.Info{},
+	}, nil
+}
+
+func (p *lainletProvider) Templates() []*Template {
+	return p.templates
+}
+
+// Run watches the containers until done is closed.
+func (p *lainletProvider) Run(events chan<- Event, done <-chan struct{}) {
+	watcher, err := lainlet.NewWatcher(p.address, func(containers map[string]container.Info) {
+		p.update(events, done, containers)
+	})
+	if err != nil {
+		logp.Err("Error creating lainlet watcher: %v", err)
+		return
+	}
+
+	watcher.Start()
+	<-done
+	watcher.Stop()
+}
+
+// update reports the containers added or changed and the containers removed.
+func (p *lainletProvider) update(events chan<- Event, done <-chan struct{}, current map[string]container.Info) {
+	for id, info := range current {
+		if previous, found := p.containers[id]; found && reflect.DeepEqual(previous, info) {
+			continue
+		}
+		e := Event{Type: EventStart, ID: id, Data: []common.MapStr{lainletData(id, info)}}
+		if !sendEvent(events, done, e) {
+			return
+		}
+		p.containers[id] = info
+	}
+
+	for id := range p.containers {
+		if _, found := current[id]; found {
+			continue
+		}
+		if !sendEvent(events, done, Event{Type: EventStop, ID: id}) {
+			return
+		}
+		delete(p.containers, id)
+	}
+}
+
+func lainletData(id string, info container.Info) common.MapStr {
+	data := common.MapStr{
+		"id":          id,
+		"app_name":    info.AppName,
+		"proc_name":   info.ProcName,
+		"instance_no": info.InstanceNo,
+		"app_version": info.AppVersion,
+		"node_name":   info.NodeName,
+	}
+	if info.IP != "" {
+		data["host"] = info.IP
+	}
+	if info.Port > 0 {
+		data["port"] = info.Port
+	}
+	return data
+}
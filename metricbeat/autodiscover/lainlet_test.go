@@ -0,0 +1,57 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"testing"
+
+	"github.com/laincloud/lainlet/watcher/container"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestLainletProviderUpdate(t *testing.T) {
+	p := &lainletProvider{containers: map[string]container.Info{}}
+	events := make(chan Event, 10)
+	done := make(chan struct{})
+
+	web := container.Info{AppName: "hello", ProcName: "hello.web.web", NodeName: "node1",
+		IP: "172.20.0.5", Port: 8080, InstanceNo: 1, AppVersion: "1.0"}
+	worker := container.Info{AppName: "hello", ProcName: "hello.worker.worker", NodeName: "node1",
+		IP: "172.20.0.6", InstanceNo: 1, AppVersion: "1.0"}
+
+	p.update(events, done, map[string]container.Info{"abc": web, "def": worker})
+	assert.Len(t, events, 2)
+	for i := 0; i < 2; i++ {
+		e := <-events
+		assert.Equal(t, EventStart, e.Type)
+		if e.ID == "abc" {
+			assert.Equal(t, []common.MapStr{{
+				"id":          "abc",
+				"app_name":    "hello",
+				"proc_name":   "hello.web.web",
+				"instance_no": 1,
+				"app_version": "1.0",
+				"node_name":   "node1",
+				"host":        "172.20.0.5",
+				"port":        8080,
+			}}, e.Data)
+		} else {
+			assert.Equal(t, "def", e.ID)
+			assert.NotContains(t, e.Data[0], "port")
+		}
+	}
+
+	// Unchanged containers aren't reported again.
+	p.update(events, done, map[string]container.Info{"abc": web, "def": worker})
+	assert.Len(t, events, 0)
+
+	web.AppVersion = "1.1"
+	p.update(events, done, map[string]container.Info{"abc": web})
+	assert.Len(t, events, 2)
+	e := <-events
+	assert.Equal(t, EventStart, e.Type)
+	assert.Equal(t, "1.1", e.Data[0]["app_version"])
+	assert.Equal(t, Event{Type: EventStop, ID: "def"}, <-events)
+}
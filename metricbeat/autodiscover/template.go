@@ -0,0 +1,69 @@
+package autodiscover
+
+import (
+	"github.com/elastic/go-ucfg"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+// TemplateConfig is the configuration of a template. The module configs are
+// used for every container matching the condition.
+type TemplateConfig struct {
+	Condition *processors.ConditionConfig `config:"condition"`
+	Configs   []*common.Config            `config:"config"    validate:"required"`
+}
+
+// Template creates module configs for the containers matching its condition.
+type Template struct {
+	condition *processors.Condition
+	configs   []*common.Config
+}
+
+// NewTemplates creates the templates of a provider.
+func NewTemplates(configs []TemplateConfig) ([]*Template, error) {
+	templates := make([]*Template, 0, len(configs))
+	for _, config := range configs {
+		condition, err := processors.NewCondition(config.Condition)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, &Template{
+			condition: condition,
+			configs:   config.Configs,
+		})
+	}
+	return templates, nil
+}
+
+// Apply returns the module configs for the container data, if the data matches
+// the condition of the template. Variables of the form `${data.<field>}` in the
+// configs are replaced with the fields of the data.
+func (t *Template) Apply(data common.MapStr) ([]*common.Config, error) {
+	if t.condition != nil && !t.condition.Check(data) {
+		return nil, nil
+	}
+
+	vars, err := ucfg.NewFrom(map[string]interface{}{"data": map[string]interface{}(data)},
+		ucfg.PathSep("."))
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]*common.Config, 0, len(t.configs))
+	for _, c := range t.configs {
+		var resolved map[string]interface{}
+		err := (*ucfg.Config)(c).Unpack(&resolved,
+			ucfg.PathSep("."), ucfg.Env(vars), ucfg.ResolveEnv, ucfg.VarExp)
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := common.NewConfigFrom(resolved)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
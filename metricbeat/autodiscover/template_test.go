@@ -0,0 +1,95 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestTemplates(t *testing.T, config map[string]interface{}) []*Template {
+	c, err := common.NewConfigFrom(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var configs struct {
+		Templates []TemplateConfig `config:"templates"`
+	}
+	if err := c.Unpack(&configs); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := NewTemplates(configs.Templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return templates
+}
+
+func TestTemplateApply(t *testing.T) {
+	templates := newTestTemplates(t, map[string]interface{}{
+		"templates": []map[string]interface{}{
+			{
+				"condition": map[string]interface{}{
+					"contains": map[string]interface{}{"image": "redis"},
+				},
+				"config": []map[string]interface{}{
+					{
+						"module":     "redis",
+						"metricsets": []string{"info"},
+						"hosts":      []string{"${data.host}:${data.port}"},
+					},
+				},
+			},
+		},
+	})
+	if !assert.Len(t, templates, 1) {
+		return
+	}
+
+	configs, err := templates[0].Apply(common.MapStr{
+		"image": "redis:3.2",
+		"host":  "172.17.0.2",
+		"port":  6379,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, configs, 1) {
+		return
+	}
+
+	var module struct {
+		Module string   `config:"module"`
+		Hosts  []string `config:"hosts"`
+	}
+	if err := configs[0].Unpack(&module); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "redis", module.Module)
+	assert.Equal(t, []string{"172.17.0.2:6379"}, module.Hosts)
+
+	// The condition doesn't match.
+	configs, err = templates[0].Apply(common.MapStr{"image": "nginx", "host": "172.17.0.3"})
+	assert.NoError(t, err)
+	assert.Empty(t, configs)
+}
+
+func TestTemplateApplyMissingField(t *testing.T) {
+	templates := newTestTemplates(t, map[string]interface{}{
+		"templates": []map[string]interface{}{
+			{
+				"config": []map[string]interface{}{
+					{"module": "redis", "hosts": []string{"${data.host}:${data.port}"}},
+				},
+			},
+		},
+	})
+
+	_, err := templates[0].Apply(common.MapStr{"host": "172.17.0.2"})
+	assert.Error(t, err)
+}
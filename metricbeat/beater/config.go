@@ -7,4 +7,5 @@ type Config struct {
 	// Modules is a list of module specific configuration data.
 	Modules       []*common.Config `config:"modules"`
 	ReloadModules *common.Config   `config:"config.modules"`
+	Autodiscover  *common.Config   `config:"autodiscover"`
 }
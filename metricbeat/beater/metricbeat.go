@@ -1,12 +1,15 @@
 package beater
 
 import (
+	"fmt"
 	"sync"
 
+	"github.com/elastic/beats/libbeat/api"
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/metricbeat/autodiscover"
 	"github.com/elastic/beats/metricbeat/mb"
 	"github.com/elastic/beats/metricbeat/mb/module"
 
@@ -16,10 +19,12 @@ import (
 
 // Metricbeat implements the Beater interface for metricbeat.
 type Metricbeat struct {
-	done    chan struct{}     // Channel used to initiate shutdown.
-	modules []*module.Wrapper // Active list of modules.
-	client  publisher.Client  // Publisher client.
-	config  Config
+	done         chan struct{}              // Channel used to initiate shutdown.
+	modules      []*module.Wrapper          // Active list of modules.
+	client       publisher.Client           // Publisher client.
+	publisher    publisher.Publisher        // Publisher the discovered modules are connected to.
+	autodiscover *autodiscover.Autodiscover // Modules started for discovered containers.
+	config       Config
 }
 
 // New creates and returns a new Metricbeat instance.
@@ -34,10 +39,19 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 		return nil, errors.Wrap(err, "error reading configuration file")
 	}
 
+	if b.ConfigCheck {
+		// modules and autodiscover providers are validated by CheckConfig
+		return &Metricbeat{
+			done:      make(chan struct{}),
+			publisher: b.Publisher,
+			config:    config,
+		}, nil
+	}
+
 	modules, err := module.NewWrappers(config.Modules, mb.Registry)
 	if err != nil {
-		// Empty config is fine if dynamic config is enabled
-		if !config.ReloadModules.Enabled() {
+		// Empty config is fine if dynamic config or autodiscover is enabled
+		if !config.ReloadModules.Enabled() && !config.Autodiscover.Enabled() {
 			return nil, err
 		} else if err != mb.ErrEmptyConfig && err != mb.ErrAllModulesDisabled {
 			return nil, err
@@ -45,13 +59,73 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 	}
 
 	mb := &Metricbeat{
-		done:    make(chan struct{}),
-		modules: modules,
-		config:  config,
+		done:      make(chan struct{}),
+		modules:   modules,
+		publisher: b.Publisher,
+		config:    config,
+	}
+
+	if config.Autodiscover.Enabled() {
+		mb.autodiscover, err = autodiscover.NewAutodiscover(module.NewFactory(mb.publisher), config.Autodiscover)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating autodiscover")
+		}
 	}
+	api.RegisterState("modules", mb.modulesState)
 	return mb, nil
 }
 
+// modulesState reports the statically configured modules and their
+// metricsets for the HTTP endpoint.
+func (bt *Metricbeat) modulesState() interface{} {
+	state := []common.MapStr{}
+	for _, m := range bt.modules {
+		metricSets := map[string][]string{}
+		for _, ms := range m.MetricSets() {
+			metricSets[ms.Name()] = append(metricSets[ms.Name()], ms.Host())
+		}
+		state = append(state, common.MapStr{
+			"module":     m.Name(),
+			"metricsets": metricSets,
+		})
+	}
+	return state
+}
+
+// CheckConfig creates each of the modules and the autodiscover providers
+// without starting them, reporting the errors of all invalid modules.
+func (bt *Metricbeat) CheckConfig() []error {
+	var errs []error
+	enabled := 0
+	for i, c := range bt.config.Modules {
+		if !c.Enabled() {
+			continue
+		}
+		enabled++
+
+		if _, err := module.NewWrapper(c, mb.Registry); err != nil {
+			errs = append(errs, fmt.Errorf("metricbeat.modules.%d: %v", i, err))
+		}
+	}
+
+	dynamic := bt.config.ReloadModules.Enabled() || bt.config.Autodiscover.Enabled()
+	if enabled == 0 && !dynamic {
+		if len(bt.config.Modules) == 0 {
+			errs = append(errs, fmt.Errorf("metricbeat.modules: %v", mb.ErrEmptyConfig))
+		} else {
+			errs = append(errs, fmt.Errorf("metricbeat.modules: %v", mb.ErrAllModulesDisabled))
+		}
+	}
+
+	if bt.config.Autodiscover.Enabled() {
+		_, err := autodiscover.NewAutodiscover(module.NewFactory(bt.publisher), bt.config.Autodiscover)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metricbeat.autodiscover: %v", err))
+		}
+	}
+	return errs
+}
+
 // Run starts the workers for Metricbeat and blocks until Stop is called
 // and the workers complete. Each host associated with a MetricSet is given its
 // own goroutine for fetching data. The ensures that each host is isolated so
@@ -86,6 +160,17 @@ func (bt *Metricbeat) Run(b *beat.Beat) error {
 		}()
 	}
 
+	if bt.autodiscover != nil {
+		logp.Warn("BETA: feature autodiscover is enabled.")
+		bt.autodiscover.Start()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-bt.done
+			bt.autodiscover.Stop()
+		}()
+	}
+
 	wg.Wait()
 	return nil
 }
@@ -0,0 +1,48 @@
+// +build !integration
+
+package beater
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestCheckConfig(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"modules": []map[string]interface{}{
+			{"module": "unknown1", "metricsets": []string{"a"}},
+			{"module": "unknown2", "metricsets": []string{"b"}, "enabled": false},
+			{"module": "unknown3", "metricsets": []string{"c"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &beat.Beat{Name: "metricbeat", ConfigCheck: true}
+	bt, err := New(b, cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	errs := bt.(beat.ConfigChecker).CheckConfig()
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), "metricbeat.modules.0:")
+		assert.Contains(t, errs[1].Error(), "metricbeat.modules.2:")
+	}
+}
+
+func TestCheckConfigNoModules(t *testing.T) {
+	b := &beat.Beat{Name: "metricbeat", ConfigCheck: true}
+	bt, err := New(b, common.NewConfig())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	errs := bt.(beat.ConfigChecker).CheckConfig()
+	assert.Len(t, errs, 1)
+}
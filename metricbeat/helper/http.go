@@ -23,15 +23,16 @@ type HTTP struct {
 	body    []byte
 }
 
-// NewHTTP creates new http helper
-func NewHTTP(base mb.BaseMetricSet) *HTTP {
+// NewHTTP creates new http helper. It returns an error if the ssl settings
+// are invalid.
+func NewHTTP(base mb.BaseMetricSet) (*HTTP, error) {
 	config := struct {
 		TLS     *outputs.TLSConfig `config:"ssl"`
 		Timeout time.Duration      `config:"timeout"`
 		Headers map[string]string  `config:"headers"`
 	}{}
 	if err := base.Module().UnpackConfig(&config); err != nil {
-		return nil
+		return nil, err
 	}
 
 	if config.Headers == nil {
@@ -40,7 +41,7 @@ func NewHTTP(base mb.BaseMetricSet) *HTTP {
 
 	tlsConfig, err := outputs.LoadTLSConfig(config.TLS)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	var dialer, tlsDialer transport.Dialer
@@ -48,7 +49,7 @@ func NewHTTP(base mb.BaseMetricSet) *HTTP {
 	dialer = transport.NetDialer(config.Timeout)
 	tlsDialer, err = transport.TLSDialer(dialer, tlsConfig, config.Timeout)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	return &HTTP{
@@ -63,7 +64,7 @@ func NewHTTP(base mb.BaseMetricSet) *HTTP {
 		headers: config.Headers,
 		method:  "GET",
 		body:    nil,
-	}
+	}, nil
 }
 
 // FetchResponse fetches a response for the http metricset.
@@ -0,0 +1,242 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+// maxPacketSize is the largest payload of an UDP packet.
+const maxPacketSize = 65507
+
+// LineHandler processes a single line received by a Server. The line is only
+// valid until the handler returns. The handler is called concurrently for
+// every TCP connection.
+type LineHandler func(line []byte)
+
+// Server receives line based messages, like the StatsD or Graphite plaintext
+// protocol, over UDP or TCP.
+type Server struct {
+	protocol string
+	address  string
+
+	udpConn  *net.UDPConn
+	listener net.Listener
+
+	mutex sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer creates a server for the host, port and protocol given in the
+// module configuration. The socket is only opened by Run, so MetricSets that
+// are created but never run, like by the config check, don't hold the port.
+func NewServer(base mb.BaseMetricSet, defaultProtocol string, defaultPort int) (*Server, error) {
+	config := struct {
+		Host     string `config:"host"`
+		Port     int    `config:"port"     validate:"min=0,max=65535"`
+		Protocol string `config:"protocol"`
+	}{
+		Host:     "localhost",
+		Port:     defaultPort,
+		Protocol: defaultProtocol,
+	}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	switch config.Protocol {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported protocol '%v', expected udp or tcp", config.Protocol)
+	}
+
+	return &Server{
+		protocol: config.Protocol,
+		address:  net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		conns:    map[net.Conn]struct{}{},
+	}, nil
+}
+
+// listen opens the socket.
+func (s *Server) listen() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.protocol == "udp" {
+		addr, err := net.ResolveUDPAddr("udp", s.address)
+		if err != nil {
+			return err
+		}
+		s.udpConn, err = net.ListenUDP("udp", addr)
+		return err
+	}
+
+	var err error
+	s.listener, err = net.Listen("tcp", s.address)
+	return err
+}
+
+// Addr returns the address the server is listening on, or nil if Run has not
+// opened the socket yet.
+func (s *Server) Addr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case s.udpConn != nil:
+		return s.udpConn.LocalAddr()
+	case s.listener != nil:
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+// Run opens the socket and passes every non-empty line received to the
+// handler. It blocks until the done channel is closed or the socket fails.
+// The server is closed afterwards. An error is returned if the socket can't
+// be opened.
+func (s *Server) Run(handler LineHandler, done <-chan struct{}) error {
+	if err := s.listen(); err != nil {
+		return fmt.Errorf("error listening on %v/%v: %v", s.protocol, s.address, err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-stopped:
+		}
+		s.close()
+	}()
+
+	if s.udpConn != nil {
+		s.readPackets(handler, done)
+	} else {
+		s.acceptConnections(handler, done)
+	}
+	close(stopped)
+	s.wg.Wait()
+	return nil
+}
+
+// readPackets reads UDP packets. A packet can contain multiple lines.
+func (s *Server) readPackets(handler LineHandler, done <-chan struct{}) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := s.udpConn.ReadFrom(buf)
+		if err != nil {
+			if isDone(done) {
+				return
+			}
+			logp.Err("Error reading from %v: %v", s.Addr(), err)
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+			return
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				handler(line)
+			}
+		}
+	}
+}
+
+func (s *Server) acceptConnections(handler LineHandler, done <-chan struct{}) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if isDone(done) {
+				return
+			}
+			logp.Err("Error accepting connection on %v: %v", s.Addr(), err)
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+			return
+		}
+
+		if !s.addConn(conn) {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.removeConn(conn)
+			s.readLines(conn, handler)
+		}()
+	}
+}
+
+// readLines reads newline separated lines from a TCP connection until the
+// connection is closed.
+func (s *Server) readLines(conn net.Conn, handler LineHandler) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxPacketSize)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			handler(line)
+		}
+	}
+	if err := scanner.Err(); err != nil && !s.isClosed() {
+		logp.Err("Error reading from %v: %v", conn.RemoteAddr(), err)
+	}
+}
+
+func (s *Server) addConn(conn net.Conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conns == nil {
+		conn.Close()
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) removeConn(conn net.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	conn.Close()
+	delete(s.conns, conn)
+}
+
+func (s *Server) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.conns == nil
+}
+
+// close closes the socket and all open TCP connections.
+func (s *Server) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.udpConn != nil {
+		s.udpConn.Close()
+	}
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
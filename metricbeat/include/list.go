@@ -28,9 +28,21 @@ import (
 	_ "github.com/elastic/beats/metricbeat/module/docker/info"
 	_ "github.com/elastic/beats/metricbeat/module/docker/memory"
 	_ "github.com/elastic/beats/metricbeat/module/docker/network"
+	_ "github.com/elastic/beats/metricbeat/module/etcd"
+	_ "github.com/elastic/beats/metricbeat/module/etcd/leader"
+	_ "github.com/elastic/beats/metricbeat/module/etcd/metrics"
+	_ "github.com/elastic/beats/metricbeat/module/etcd/self"
+	_ "github.com/elastic/beats/metricbeat/module/etcd/store"
+	_ "github.com/elastic/beats/metricbeat/module/golang"
+	_ "github.com/elastic/beats/metricbeat/module/golang/expvar"
+	_ "github.com/elastic/beats/metricbeat/module/golang/heap"
+	_ "github.com/elastic/beats/metricbeat/module/graphite"
+	_ "github.com/elastic/beats/metricbeat/module/graphite/server"
 	_ "github.com/elastic/beats/metricbeat/module/haproxy"
 	_ "github.com/elastic/beats/metricbeat/module/haproxy/info"
 	_ "github.com/elastic/beats/metricbeat/module/haproxy/stat"
+	_ "github.com/elastic/beats/metricbeat/module/http"
+	_ "github.com/elastic/beats/metricbeat/module/http/json"
 	_ "github.com/elastic/beats/metricbeat/module/jolokia"
 	_ "github.com/elastic/beats/metricbeat/module/jolokia/jmx"
 	_ "github.com/elastic/beats/metricbeat/module/kafka"
@@ -55,6 +67,10 @@ import (
 	_ "github.com/elastic/beats/metricbeat/module/redis"
 	_ "github.com/elastic/beats/metricbeat/module/redis/info"
 	_ "github.com/elastic/beats/metricbeat/module/redis/keyspace"
+	_ "github.com/elastic/beats/metricbeat/module/sql"
+	_ "github.com/elastic/beats/metricbeat/module/sql/query"
+	_ "github.com/elastic/beats/metricbeat/module/statsd"
+	_ "github.com/elastic/beats/metricbeat/module/statsd/server"
 	_ "github.com/elastic/beats/metricbeat/module/system"
 	_ "github.com/elastic/beats/metricbeat/module/system/core"
 	_ "github.com/elastic/beats/metricbeat/module/system/cpu"
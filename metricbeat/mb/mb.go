@@ -60,8 +60,8 @@ func (m *BaseModule) UnpackConfig(to interface{}) error {
 // MetricSet interfaces
 
 // MetricSet is the common interface for all MetricSet implementations. In
-// addition to this interface, all MetricSets must implement either
-// EventFetcher or EventsFetcher (but not both).
+// addition to this interface, all MetricSets must implement one of
+// EventFetcher, EventsFetcher or PushMetricSet.
 type MetricSet interface {
 	Name() string   // Name returns the name of the MetricSet.
 	Module() Module // Module returns the parent Module for the MetricSet.
@@ -84,6 +84,31 @@ type EventsFetcher interface {
 	Fetch() ([]common.MapStr, error)
 }
 
+// EntityIdentifier is an optional interface for EventsFetchers reporting one
+// event per entity, like a network interface of a container. The fields
+// returned, named by their dotted path in the event, tell the events of a
+// fetch apart, in addition to the fields named like name, id or interface.
+// They are used to compute the configured rates per entity.
+type EntityIdentifier interface {
+	IdentifyingFields() []string
+}
+
+// PushMetricSet is a MetricSet that receives events pushed to it, instead of
+// fetching them when polled. Run is started once and must block until the done
+// channel is closed. Events and errors are sent using the PushReporter.
+type PushMetricSet interface {
+	MetricSet
+	Run(r PushReporter, done <-chan struct{})
+}
+
+// PushReporter is used by a PushMetricSet to report events and errors. Both
+// methods return false when the MetricSet is being stopped and the value was
+// not published.
+type PushReporter interface {
+	Event(event common.MapStr) bool // Event reports a single event.
+	Error(err error) bool           // Error reports a failure, like an unparsable message.
+}
+
 // HostData contains values parsed from the 'host' configuration. Other
 // configuration data like protocols, usernames, and passwords may also be
 // used to construct this HostData data.
@@ -156,6 +181,9 @@ func (b *BaseMetricSet) HostData() HostData {
 // The Raw config option is used to enable raw fields in a metricset. This means
 // the metricset fetches not only the predefined fields but add alls raw data under
 // the raw namespace to the event.
+//
+// The Rates config option lists the counter fields, or glob patterns of them,
+// for which a per second rate is added to the events.
 type ModuleConfig struct {
 	Hosts      []string                `config:"hosts"`
 	Period     time.Duration           `config:"period"     validate:"positive"`
@@ -165,16 +193,17 @@ type ModuleConfig struct {
 	Enabled    bool                    `config:"enabled"`
 	Filters    processors.PluginConfig `config:"filters"`
 	Raw        bool                    `config:"raw"`
+	Rates      []string                `config:"rates"`
 
 	common.EventMetadata `config:",inline"` // Fields and tags to add to events.
 }
 
 func (c ModuleConfig) String() string {
 	return fmt.Sprintf(`{Module:"%v", MetricSets:%v, Enabled:%v, `+
-		`Hosts:[%v hosts], Period:"%v", Timeout:"%v", Raw:%v, Fields:%v, `+
-		`FieldsUnderRoot:%v, Tags:%v}`,
+		`Hosts:[%v hosts], Period:"%v", Timeout:"%v", Raw:%v, Rates:%v, `+
+		`Fields:%v, FieldsUnderRoot:%v, Tags:%v}`,
 		c.Module, c.MetricSets, c.Enabled, len(c.Hosts), c.Period, c.Timeout,
-		c.Raw, c.Fields, c.FieldsUnderRoot, c.Tags)
+		c.Raw, c.Rates, c.Fields, c.FieldsUnderRoot, c.Tags)
 }
 
 func (c ModuleConfig) GoString() string { return c.String() }
@@ -28,6 +28,18 @@ func (m *testMetricSet) Fetch() (common.MapStr, error) {
 	return nil, nil
 }
 
+type testPushMetricSet struct {
+	BaseMetricSet
+}
+
+func (m *testPushMetricSet) Run(r PushReporter, done <-chan struct{}) {}
+
+type testPushAndFetchMetricSet struct {
+	testMetricSet
+}
+
+func (m *testPushAndFetchMetricSet) Run(r PushReporter, done <-chan struct{}) {}
+
 func TestModuleConfig(t *testing.T) {
 	tests := []struct {
 		in  interface{}
@@ -80,6 +92,20 @@ func TestModuleConfig(t *testing.T) {
 			},
 			err: "negative value accessing 'timeout'",
 		},
+		{
+			in: map[string]interface{}{
+				"module":     "example",
+				"metricsets": []string{"test"},
+				"rates":      []string{"read.bytes", "write.*"},
+			},
+			out: ModuleConfig{
+				Module:     "example",
+				MetricSets: []string{"test"},
+				Enabled:    true,
+				Period:     time.Second * 10,
+				Rates:      []string{"read.bytes", "write.*"},
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -234,6 +260,55 @@ func TestNewModules(t *testing.T) {
 	})
 }
 
+func TestNewModulesPushMetricSet(t *testing.T) {
+	r := newTestRegistry(t)
+
+	pushFactory := func(base BaseMetricSet) (MetricSet, error) {
+		return &testPushMetricSet{base}, nil
+	}
+	if err := r.AddMetricSet(moduleName, "push", pushFactory); err != nil {
+		t.Fatal(err)
+	}
+
+	bothFactory := func(base BaseMetricSet) (MetricSet, error) {
+		return &testPushAndFetchMetricSet{testMetricSet{base}}, nil
+	}
+	if err := r.AddMetricSet(moduleName, "both", bothFactory); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("PushMetricSet", func(t *testing.T) {
+		c := newConfig(t, map[string]interface{}{
+			"module":     moduleName,
+			"metricsets": []string{"push"},
+		})
+
+		modules, err := NewModules(c, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, metricSets := range modules {
+			assert.Len(t, metricSets, 1)
+			assert.Implements(t, (*PushMetricSet)(nil), metricSets[0])
+			return
+		}
+		assert.FailNow(t, "no modules found")
+	})
+
+	t.Run("PushMetricSet and EventFetcher", func(t *testing.T) {
+		c := newConfig(t, map[string]interface{}{
+			"module":     moduleName,
+			"metricsets": []string{"both"},
+		})
+
+		_, err := NewModules(c, r)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "can only implement a single Fetcher interface")
+		}
+	})
+}
+
 // TestNewBaseModuleFromModuleConfigStruct tests the creation a new BaseModule.
 func TestNewBaseModuleFromModuleConfigStruct(t *testing.T) {
 	moduleConf := DefaultModuleConfig()
@@ -0,0 +1,254 @@
+package module
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// rateSuffix is appended to the name of a counter field to name its rate.
+const rateSuffix = "_per_sec"
+
+// rateCalculator adds the per second rates of counter fields to the events of
+// a MetricSet. It keeps the counters of the previous fetch to compute the
+// rates, so it must only be used for a single MetricSet and host.
+//
+// MetricSets returning multiple events per fetch report one event per entity,
+// like a disk or a network interface. The events are told apart by the values
+// of their identifying fields, see isIdentifying.
+type rateCalculator struct {
+	patterns    []string
+	identifying map[string]bool // fields declared by mb.EntityIdentifier
+	samples     map[string]rateSample
+}
+
+// rateSample contains the counters of an event.
+type rateSample struct {
+	time     time.Time
+	counters map[string]interface{}
+}
+
+// newRateCalculator returns a rateCalculator for the fields matching the given
+// glob patterns. The identifying fields are used in addition to the default
+// ones to tell the events of a fetch apart. It returns nil if no patterns are
+// given.
+func newRateCalculator(patterns []string, identifying []string) (*rateCalculator, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid rates pattern '%v': %v", pattern, err)
+		}
+	}
+
+	c := &rateCalculator{
+		patterns:    patterns,
+		identifying: map[string]bool{},
+		samples:     map[string]rateSample{},
+	}
+	for _, field := range identifying {
+		c.identifying[field] = true
+	}
+	return c, nil
+}
+
+// applyEvent adds the rates to the event of a single event fetch started at
+// the given time. No rates are added to the first event.
+func (c *rateCalculator) applyEvent(event common.MapStr, now time.Time) {
+	fields := map[string]interface{}{}
+	flatten("", event, fields)
+
+	current := rateSample{time: now, counters: c.counters(fields)}
+	c.addRates(event, c.samples[""], current)
+	c.samples = map[string]rateSample{"": current}
+}
+
+// apply adds the rates to the events of a multiple events fetch started at
+// the given time. No rates are added for events without a previous sample.
+// Samples of events missing in the fetch are forgotten. Events that can't be
+// told apart, because their identifying fields are equal, get no rates.
+func (c *rateCalculator) apply(events []common.MapStr, now time.Time) {
+	keys := make([]string, len(events))
+	samples := make(map[string]rateSample, len(events))
+	duplicates := map[string]bool{}
+
+	for i, event := range events {
+		if event == nil {
+			continue
+		}
+
+		fields := map[string]interface{}{}
+		flatten("", event, fields)
+
+		key := c.eventKey(fields)
+		if _, found := samples[key]; found {
+			duplicates[key] = true
+		}
+		keys[i] = key
+		samples[key] = rateSample{time: now, counters: c.counters(fields)}
+	}
+
+	for key := range duplicates {
+		delete(samples, key)
+	}
+
+	for i, event := range events {
+		if current, found := samples[keys[i]]; found && event != nil {
+			c.addRates(event, c.samples[keys[i]], current)
+		}
+	}
+
+	c.samples = samples
+}
+
+// addRates adds the rates of the counters of the current sample to the event.
+// Nothing is added if there is no previous sample.
+func (c *rateCalculator) addRates(event common.MapStr, previous, current rateSample) {
+	seconds := current.time.Sub(previous.time).Seconds()
+	if previous.counters == nil || seconds <= 0 {
+		return
+	}
+
+	for name, value := range current.counters {
+		if last, found := previous.counters[name]; found {
+			event.Put(name+rateSuffix, counterDelta(last, value)/seconds)
+		}
+	}
+}
+
+// counters returns the numeric fields matching one of the patterns.
+func (c *rateCalculator) counters(fields map[string]interface{}) map[string]interface{} {
+	counters := map[string]interface{}{}
+	for name, value := range fields {
+		if !c.matches(name) {
+			continue
+		}
+		if _, ok := toFloat(value); ok {
+			counters[name] = value
+		}
+	}
+	return counters
+}
+
+func (c *rateCalculator) matches(name string) bool {
+	for _, pattern := range c.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// counterDelta returns the increase of a counter. A uint32 or uint64 counter
+// in the upper half of its range dropping to a lower value wrapped around at
+// the width of its type. Any other decrease is a reset of the counter to 0, so
+// the current value is the increase since the reset.
+func counterDelta(previous, current interface{}) float64 {
+	switch now := current.(type) {
+	case uint32:
+		if last, ok := previous.(uint32); ok {
+			if now >= last || last > math.MaxUint32/2 {
+				return float64(now - last)
+			}
+			return float64(now)
+		}
+	case uint64:
+		if last, ok := previous.(uint64); ok {
+			if now >= last || last > math.MaxUint64/2 {
+				return float64(now - last)
+			}
+			return float64(now)
+		}
+	}
+
+	last, _ := toFloat(previous)
+	now, _ := toFloat(current)
+	if now >= last {
+		return now - last
+	}
+	return now
+}
+
+// eventKey joins the identifying fields of an event.
+func (c *rateCalculator) eventKey(fields map[string]interface{}) string {
+	var parts []string
+	for name, value := range fields {
+		if c.identifying[name] || isIdentifying(name) {
+			parts = append(parts, fmt.Sprintf("%v=%v", name, value))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x00")
+}
+
+// isIdentifying returns true for the fields identifying the entity of an event,
+// like system.diskio.name, system.filesystem.device_name, docker.container.id
+// or docker.network.interface. Those are the fields named name, id, pid,
+// interface or device, or ending in _name or _id.
+func isIdentifying(name string) bool {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	switch name {
+	case "name", "id", "pid", "interface", "device":
+		return true
+	}
+	return strings.HasSuffix(name, "_name") || strings.HasSuffix(name, "_id")
+}
+
+// flatten stores the leaf values of the event under their dotted names.
+func flatten(prefix string, event map[string]interface{}, out map[string]interface{}) {
+	for k, v := range event {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+
+		switch nested := v.(type) {
+		case common.MapStr:
+			flatten(name, nested, out)
+		case map[string]interface{}:
+			flatten(name, nested, out)
+		default:
+			out[name] = v
+		}
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,212 @@
+// +build !integration
+
+package module
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateCalculator(t *testing.T) {
+	c, err := newRateCalculator([]string{"read.bytes", "write.*"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	sample := func(read, write uint64) common.MapStr {
+		return common.MapStr{
+			"name":  "sda",
+			"read":  common.MapStr{"bytes": read, "count": uint64(1)},
+			"write": common.MapStr{"bytes": write},
+		}
+	}
+
+	// The first sample has no rates.
+	first := sample(100, 1000)
+	c.applyEvent(first, start)
+	assert.Equal(t, sample(100, 1000), first)
+
+	second := sample(300, 1500)
+	c.applyEvent(second, start.Add(10*time.Second))
+	assert.Equal(t, common.MapStr{
+		"name":  "sda",
+		"read":  common.MapStr{"bytes": uint64(300), "count": uint64(1), "bytes_per_sec": 20.0},
+		"write": common.MapStr{"bytes": uint64(1500), "bytes_per_sec": 50.0},
+	}, second)
+
+	// The event of a single event fetch is not matched by its fields.
+	third := sample(400, 1500)
+	third["name"] = "sdb"
+	c.applyEvent(third, start.Add(20*time.Second))
+	assert.Equal(t, 10.0, third["read"].(common.MapStr)["bytes_per_sec"])
+}
+
+func TestRateCalculatorMultipleEvents(t *testing.T) {
+	c, err := newRateCalculator([]string{"bytes"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	c.apply([]common.MapStr{
+		{"name": "eth0", "bytes": 10},
+		{"name": "eth1", "bytes": 100},
+	}, start)
+
+	events := []common.MapStr{
+		{"name": "eth1", "bytes": 200},
+		{"name": "eth2", "bytes": 1000},
+	}
+	c.apply(events, start.Add(time.Second))
+	assert.Equal(t, 100.0, events[0]["bytes_per_sec"])
+	assert.NotContains(t, events[1], "bytes_per_sec")
+
+	// eth0 was missing in the last fetch, so its sample is forgotten.
+	events = []common.MapStr{{"name": "eth0", "bytes": 20}}
+	c.apply(events, start.Add(2*time.Second))
+	assert.NotContains(t, events[0], "bytes_per_sec")
+}
+
+func TestRateCalculatorIdentifyingFields(t *testing.T) {
+	c, err := newRateCalculator([]string{"bytes"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	c.apply([]common.MapStr{
+		{"device_name": "sda1", "state": "ok", "bytes": 10},
+		{"pid": 42, "bytes": 10},
+		{"pid": 43, "bytes": 10},
+	}, start)
+
+	// Only the identifying fields are used to match the events.
+	events := []common.MapStr{
+		{"device_name": "sda1", "state": "degraded", "bytes": 20},
+		{"pid": 43, "bytes": 30},
+		{"pid": 42, "bytes": 40},
+	}
+	c.apply(events, start.Add(time.Second))
+	assert.Equal(t, 10.0, events[0]["bytes_per_sec"])
+	assert.Equal(t, 20.0, events[1]["bytes_per_sec"])
+	assert.Equal(t, 30.0, events[2]["bytes_per_sec"])
+
+	// Events that can't be told apart get no rates.
+	events = []common.MapStr{
+		{"state": "ok", "bytes": 50},
+		{"state": "degraded", "bytes": 60},
+	}
+	c.apply(events, start.Add(2*time.Second))
+	c.apply(events, start.Add(3*time.Second))
+	assert.NotContains(t, events[0], "bytes_per_sec")
+	assert.NotContains(t, events[1], "bytes_per_sec")
+}
+
+// dockerNetworkEvent returns an event like the ones of the docker/network
+// MetricSet, reporting one event per container and network interface.
+func dockerNetworkEvent(container, iface string, inBytes uint64) common.MapStr {
+	return common.MapStr{
+		mb.ModuleData: common.MapStr{
+			"container": common.MapStr{
+				"id":   container,
+				"name": container + "-name",
+			},
+		},
+		"interface": iface,
+		"in": common.MapStr{
+			"bytes":   inBytes,
+			"dropped": uint64(0),
+			"errors":  uint64(0),
+			"packets": uint64(1),
+		},
+	}
+}
+
+func TestRateCalculatorDockerNetwork(t *testing.T) {
+	c, err := newRateCalculator([]string{"in.bytes"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	c.apply([]common.MapStr{
+		dockerNetworkEvent("a", "eth0", 100),
+		dockerNetworkEvent("a", "eth1", 1000),
+		dockerNetworkEvent("b", "eth0", 10),
+	}, start)
+
+	// The interfaces of a container are told apart by their name.
+	events := []common.MapStr{
+		dockerNetworkEvent("b", "eth0", 20),
+		dockerNetworkEvent("a", "eth1", 3000),
+		dockerNetworkEvent("a", "eth0", 200),
+	}
+	c.apply(events, start.Add(time.Second))
+	for i, expected := range []float64{10, 2000, 100} {
+		rate, err := events[i].GetValue("in.bytes_per_sec")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, rate)
+	}
+}
+
+func TestRateCalculatorDeclaredIdentifyingFields(t *testing.T) {
+	c, err := newRateCalculator([]string{"bytes"}, []string{"disk.serial"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	c.apply([]common.MapStr{
+		{"disk": common.MapStr{"serial": "S1"}, "bytes": 10},
+		{"disk": common.MapStr{"serial": "S2"}, "bytes": 10},
+	}, start)
+
+	events := []common.MapStr{
+		{"disk": common.MapStr{"serial": "S2"}, "bytes": 30},
+		{"disk": common.MapStr{"serial": "S1"}, "bytes": 20},
+	}
+	c.apply(events, start.Add(time.Second))
+	assert.Equal(t, 20.0, events[0]["bytes_per_sec"])
+	assert.Equal(t, 10.0, events[1]["bytes_per_sec"])
+}
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		previous, current interface{}
+		delta             float64
+	}{
+		{previous: 10, current: 15, delta: 5},
+		{previous: 10, current: 10, delta: 0},
+		{previous: 1.5, current: 2, delta: 0.5},
+		// Counter reset.
+		{previous: 1000, current: 7, delta: 7},
+		{previous: uint32(1000), current: uint32(7), delta: 7},
+		// Wraparound at the width of the type.
+		{previous: uint32(math.MaxUint32 - 9), current: uint32(5), delta: 15},
+		{previous: uint64(math.MaxUint64 - 9), current: uint64(5), delta: 15},
+		// A uint64 counter doesn't wrap around at 32 bit.
+		{previous: uint64(math.MaxUint32 - 9), current: uint64(5), delta: 5},
+		// Other types only reset.
+		{previous: int64(math.MaxUint32 - 9), current: int64(5), delta: 5},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.delta, counterDelta(test.previous, test.current),
+			"previous=%v current=%v", test.previous, test.current)
+	}
+}
+
+func TestInvalidRatePattern(t *testing.T) {
+	_, err := newRateCalculator([]string{"[bytes"}, nil)
+	assert.Error(t, err)
+
+	c, err := newRateCalculator(nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
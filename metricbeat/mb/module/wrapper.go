@@ -44,8 +44,9 @@ type Wrapper struct {
 // running the MetricSet. It contains a pointer to the parent Module.
 type metricSetWrapper struct {
 	mb.MetricSet
-	module *Wrapper    // Parent Module.
-	stats  *expvar.Map // expvar stats for this MetricSet.
+	module *Wrapper        // Parent Module.
+	stats  *expvar.Map     // expvar stats for this MetricSet.
+	rates  *rateCalculator // Adds the configured rates, nil if none are configured.
 }
 
 // NewWrapper create a new Module and its associated MetricSets based
@@ -100,10 +101,20 @@ func NewWrappers(modulesConfig []*common.Config, r *mb.Register) ([]*Wrapper, er
 				return nil, err
 			}
 
+			var identifying []string
+			if ei, ok := ms.(mb.EntityIdentifier); ok {
+				identifying = ei.IdentifyingFields()
+			}
+			rates, err := newRateCalculator(k.Config().Rates, identifying)
+			if err != nil {
+				return nil, errors.Wrapf(err, "module %s", k.Name())
+			}
+
 			msw := &metricSetWrapper{
 				MetricSet: ms,
 				module:    mw,
 				stats:     expMap,
+				rates:     rates,
 			}
 			msws = append(msws, msw)
 		}
@@ -117,7 +128,8 @@ func NewWrappers(modulesConfig []*common.Config, r *mb.Register) ([]*Wrapper, er
 
 // Start starts the Module's MetricSet workers which are responsible for
 // fetching metrics. The workers will continue to periodically fetch until the
-// done channel is closed. When the done channel is closed all MetricSet workers
+// done channel is closed. Workers of a PushMetricSet run the MetricSet until
+// the done channel is closed instead. When the done channel is closed all MetricSet workers
 // will stop and the returned output channel will be closed.
 //
 // The returned channel is buffered with a length one one. It must drained to
@@ -136,6 +148,10 @@ func (mw *Wrapper) Start(done <-chan struct{}) <-chan common.MapStr {
 	for _, msw := range mw.metricSets {
 		go func(msw *metricSetWrapper) {
 			defer wg.Done()
+			if ms, ok := msw.MetricSet.(mb.PushMetricSet); ok {
+				msw.startPushing(ms, done, out)
+				return
+			}
 			msw.startFetching(done, out)
 		}(msw)
 	}
@@ -155,6 +171,15 @@ func (mw *Wrapper) String() string {
 		mw.Name(), len(mw.metricSets))
 }
 
+// MetricSets returns the MetricSets of the module.
+func (mw *Wrapper) MetricSets() []mb.MetricSet {
+	metricSets := make([]mb.MetricSet, len(mw.metricSets))
+	for i, msw := range mw.metricSets {
+		metricSets[i] = msw.MetricSet
+	}
+	return metricSets
+}
+
 // Hash returns the hash value of the module wrapper
 // This allows to check if two modules are the same / have the same config
 func (mw *Wrapper) Hash() uint64 {
@@ -208,6 +233,21 @@ func (msw *metricSetWrapper) startFetching(
 	}
 }
 
+// startPushing runs the PushMetricSet until the done channel is closed. This
+// method will recover from panics and log a stack track if one occurs.
+func (msw *metricSetWrapper) startPushing(
+	ms mb.PushMetricSet,
+	done <-chan struct{},
+	out chan<- common.MapStr,
+) {
+	debugf("Starting %s", msw)
+	defer debugf("Stopped %s", msw)
+	defer logp.Recover(fmt.Sprintf("recovered from panic while running "+
+		"'%s/%s' for host '%s'", msw.module.Name(), msw.Name(), msw.Host()))
+
+	ms.Run(&pushReporter{msw: msw, done: done, out: out}, done)
+}
+
 // fetch invokes the appropriate Fetch method for the MetricSet and publishes
 // the result using the publisher client. This method will recover from panics
 // and log a stack track if one occurs.
@@ -251,6 +291,9 @@ func (msw *metricSetWrapper) singleEventFetch(fetcher mb.EventFetcher) (common.M
 
 	if err == nil {
 		msw.stats.Add(successesKey, 1)
+		if msw.rates != nil && event != nil {
+			msw.rates.applyEvent(event, start)
+		}
 	} else {
 		msw.stats.Add(failuresKey, 1)
 	}
@@ -270,6 +313,9 @@ func (msw *metricSetWrapper) multiEventFetch(fetcher mb.EventsFetcher) ([]common
 	var rtnEvents []common.MapStr
 	if err == nil {
 		msw.stats.Add(successesKey, 1)
+		if msw.rates != nil {
+			msw.rates.apply(events, start)
+		}
 
 		for _, event := range events {
 			if event, err = createEvent(msw, event, nil, start, elapsed); err != nil {
@@ -294,6 +340,40 @@ func (msw *metricSetWrapper) multiEventFetch(fetcher mb.EventsFetcher) ([]common
 	return rtnEvents, nil
 }
 
+// pushReporter builds the events of a PushMetricSet and writes them to the
+// output channel.
+type pushReporter struct {
+	msw  *metricSetWrapper
+	done <-chan struct{}
+	out  chan<- common.MapStr
+}
+
+// Event publishes the data reported by the MetricSet as event.
+func (r *pushReporter) Event(data common.MapStr) bool {
+	return r.publish(data, nil)
+}
+
+// Error publishes an event containing the error.
+func (r *pushReporter) Error(err error) bool {
+	r.msw.stats.Add(failuresKey, 1)
+	return r.publish(nil, err)
+}
+
+func (r *pushReporter) publish(data common.MapStr, fetchErr error) bool {
+	event, err := createEvent(r.msw, data, fetchErr, time.Now(), 0)
+	if err != nil {
+		logp.Err("createEvent failed: %v", err)
+		return true
+	}
+	if event == nil {
+		// The event was dropped by the filters.
+		return true
+	}
+
+	r.msw.stats.Add(eventsKey, 1)
+	return writeEvent(r.done, r.out, event)
+}
+
 // String returns a string representation of metricSetWrapper.
 func (msw *metricSetWrapper) String() string {
 	return fmt.Sprintf("metricSetWrapper[module=%s, name=%s, host=%s]",
@@ -303,6 +383,14 @@ func (msw *metricSetWrapper) String() string {
 // other utility functions
 
 func writeEvent(done <-chan struct{}, out chan<- common.MapStr, event common.MapStr) bool {
+	// Prefer writing the event when possible, so events reported while
+	// stopping, like a final flush, are not dropped at random.
+	select {
+	case out <- event:
+		return true
+	default:
+	}
+
 	select {
 	case <-done:
 		return false
@@ -93,3 +93,54 @@ func TestWrapper(t *testing.T) {
 		}
 	}
 }
+
+// fakePushMetricSet
+
+const pushMetricSetName = "push"
+
+type fakePushMetricSet struct {
+	mb.BaseMetricSet
+}
+
+func (ms *fakePushMetricSet) Run(r mb.PushReporter, done <-chan struct{}) {
+	for i := 0; ; i++ {
+		if !r.Event(common.MapStr{"metric": i}) {
+			return
+		}
+	}
+}
+
+func newFakePushMetricSet(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	return &fakePushMetricSet{BaseMetricSet: base}, nil
+}
+
+func TestWrapperPushMetricSet(t *testing.T) {
+	r := mb.NewRegister()
+	if err := r.AddMetricSet(moduleName, pushMetricSetName, newFakePushMetricSet); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newConfig(t, map[string]interface{}{
+		"module":     moduleName,
+		"metricsets": []string{pushMetricSetName},
+	})
+
+	m, err := module.NewWrapper(c, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	output := m.Start(done)
+
+	for i := 0; i < 3; i++ {
+		event := <-output
+		assert.Equal(t, common.MapStr{"metric": i}, event[moduleName].(common.MapStr)[pushMetricSetName])
+		assert.Equal(t, pushMetricSetName, event["metricset"].(common.MapStr)["name"])
+	}
+	close(done)
+
+	// Drain the output until the channel is closed once Run returned.
+	for range output {
+	}
+}
@@ -27,8 +27,8 @@ type ModuleFactory func(base BaseModule) (Module, error)
 
 // MetricSetFactory accepts a BaseMetricSet and returns a MetricSet. If there
 // was an error creating the MetricSet then an error will be returned. The
-// returned MetricSet must also implement either EventFetcher or EventsFetcher
-// (but not both).
+// returned MetricSet must also implement exactly one of EventFetcher,
+// EventsFetcher or PushMetricSet.
 type MetricSetFactory func(base BaseMetricSet) (MetricSet, error)
 
 // HostParser is a function that parses a host value from the configuration
@@ -37,7 +37,9 @@ that Metricbeat does it and with the same validations.
 package testing
 
 import (
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/metricbeat/mb"
@@ -123,3 +125,60 @@ func NewEventsFetcher(t testing.TB, config interface{}) mb.EventsFetcher {
 
 	return fetcher
 }
+
+// NewPushMetricSet instantiates a new PushMetricSet using the given
+// configuration. The ModuleFactory and MetricSetFactory are obtained from the
+// global Registry.
+func NewPushMetricSet(t testing.TB, config interface{}) mb.PushMetricSet {
+	metricSet := newMetricSet(t, config)
+
+	pushMetricSet, ok := metricSet.(mb.PushMetricSet)
+	if !ok {
+		t.Fatal("MetricSet does not implement PushMetricSet")
+	}
+
+	return pushMetricSet
+}
+
+// capturingPushReporter stores all the events and errors reported by a
+// PushMetricSet.
+type capturingPushReporter struct {
+	mutex  sync.Mutex
+	events []common.MapStr
+	errs   []error
+}
+
+func (r *capturingPushReporter) Event(event common.MapStr) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+	return true
+}
+
+func (r *capturingPushReporter) Error(err error) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.errs = append(r.errs, err)
+	return true
+}
+
+// RunPushMetricSet runs the PushMetricSet for the given duration and returns
+// all the events and errors reported during that time.
+func RunPushMetricSet(duration time.Duration, metricSet mb.PushMetricSet) ([]common.MapStr, []error) {
+	r := &capturingPushReporter{}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		metricSet.Run(r, done)
+	}()
+
+	time.Sleep(duration)
+	close(done)
+	<-stopped
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.events, r.errs
+}
@@ -1,7 +1,8 @@
 package docker
 
 type Config struct {
-	TLS *TLSConfig `config:"ssl"`
+	TLS  *TLSConfig  `config:"ssl"`
+	Lain *LainConfig `config:"lain"`
 }
 
 type TLSConfig struct {
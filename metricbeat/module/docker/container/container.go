@@ -18,6 +18,7 @@ func init() {
 type MetricSet struct {
 	mb.BaseMetricSet
 	dockerClient *dc.Client
+	lain         *docker.LainTagger
 }
 
 // New creates a new instance of the docker container MetricSet.
@@ -34,9 +35,15 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
+	lain, err := docker.NewLainTagger(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
 		dockerClient:  client,
+		lain:          lain,
 	}, nil
 }
 
@@ -48,5 +55,5 @@ func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	if err != nil {
 		return nil, err
 	}
-	return eventsMapping(containers), nil
+	return m.lain.Tag(eventsMapping(containers)), nil
 }
@@ -19,6 +19,7 @@ type MetricSet struct {
 	mb.BaseMetricSet
 	cpuService   *CPUService
 	dockerClient *dc.Client
+	lain         *docker.LainTagger
 }
 
 // New creates a new instance of the docker cpu MetricSet.
@@ -35,9 +36,15 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
+	lain, err := docker.NewLainTagger(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
 		dockerClient:  client,
+		lain:          lain,
 		cpuService:    &CPUService{},
 	}, nil
 }
@@ -50,5 +57,5 @@ func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	}
 
 	formattedStats := m.cpuService.getCPUStatsList(stats)
-	return eventsMapping(formattedStats), nil
+	return m.lain.Tag(eventsMapping(formattedStats)), nil
 }
@@ -19,6 +19,7 @@ type MetricSet struct {
 	mb.BaseMetricSet
 	blkioService *BLkioService
 	dockerClient *dc.Client
+	lain         *docker.LainTagger
 }
 
 // New create a new instance of the docker diskio MetricSet.
@@ -35,9 +36,15 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
+	lain, err := docker.NewLainTagger(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
 		dockerClient:  client,
+		lain:          lain,
 		blkioService: &BLkioService{
 			BlkioSTatsPerContainer: make(map[string]BlkioRaw),
 		},
@@ -52,5 +59,5 @@ func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	}
 
 	formattedStats := m.blkioService.getBlkioStatsList(stats)
-	return eventsMapping(formattedStats), nil
+	return m.lain.Tag(eventsMapping(formattedStats)), nil
 }
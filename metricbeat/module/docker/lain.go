@@ -0,0 +1,252 @@
+package docker
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/laincloud/lainlet/watcher/container"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/lainlet"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+// LainConfig configures the tagging of the events of LAIN containers.
+type LainConfig struct {
+	LainletAddress string `config:"lainlet_address" validate:"required"`
+	// Aggregate sums the events of the instances of a proc into one event.
+	Aggregate bool `config:"aggregate"`
+}
+
+// LainTagger adds the LAIN app, proc, instance and version of a container to
+// its events, as reported by lainlet.
+type LainTagger struct {
+	lookup    func(id string) (container.Info, bool)
+	aggregate bool
+}
+
+// NewLainTagger creates the tagger of the module config. It returns nil if
+// LAIN tagging is not configured.
+func NewLainTagger(config Config) (*LainTagger, error) {
+	if config.Lain == nil {
+		return nil, nil
+	}
+
+	watcher, err := getLainWatcher(config.Lain.LainletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &LainTagger{
+		lookup:    watcher.Lookup,
+		aggregate: config.Lain.Aggregate,
+	}, nil
+}
+
+// Tag adds the LAIN fields to the events of LAIN containers, under
+// container.lain. In aggregate mode, the events of the instances of a proc
+// are summed up. Events of other containers are left unchanged. Tag can be
+// called on a nil tagger.
+func (t *LainTagger) Tag(events []common.MapStr) []common.MapStr {
+	if t == nil {
+		return events
+	}
+
+	var groups map[string][]common.MapStr
+	var keys []string
+	if t.aggregate {
+		groups = map[string][]common.MapStr{}
+	}
+
+	tagged := make([]common.MapStr, 0, len(events))
+	for _, event := range events {
+		cont := containerFields(event)
+		id, _ := cont["id"].(string)
+		info, found := t.lookup(id)
+		if cont == nil || !found {
+			tagged = append(tagged, event)
+			continue
+		}
+
+		if !t.aggregate {
+			cont["lain"] = common.MapStr{
+				"app_name":    info.AppName,
+				"proc_name":   info.ProcName,
+				"instance_no": info.InstanceNo,
+				"app_version": info.AppVersion,
+			}
+			tagged = append(tagged, event)
+			continue
+		}
+
+		cont["lain"] = info
+		key := groupKey(info, event)
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], event)
+	}
+
+	for _, key := range keys {
+		tagged = append(tagged, aggregateProc(groups[key]))
+	}
+	return tagged
+}
+
+// containerFields returns the container of an event. The container metricset
+// reports the container as the event itself.
+func containerFields(event common.MapStr) common.MapStr {
+	if module, ok := event[mb.ModuleData].(common.MapStr); ok {
+		cont, _ := module["container"].(common.MapStr)
+		return cont
+	}
+	return event
+}
+
+// groupKey identifies the events summed up in aggregate mode. Events are
+// grouped by proc and by the string fields outside the container, like the
+// network interface.
+func groupKey(info container.Info, event common.MapStr) string {
+	parts := []string{info.AppName, info.ProcName}
+	if _, found := event[mb.ModuleData]; !found {
+		return strings.Join(parts, "\x00")
+	}
+	for k, v := range event {
+		if k == mb.ModuleData {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			parts = append(parts, k+"="+s)
+		}
+	}
+	sort.Strings(parts[2:])
+	return strings.Join(parts, "\x00")
+}
+
+// aggregateProc sums up the events of the instances of a proc. Numbers are
+// summed, other values are kept if they are equal in all events.
+func aggregateProc(events []common.MapStr) common.MapStr {
+	infos := make([]container.Info, 0, len(events))
+	for _, event := range events {
+		cont := containerFields(event)
+		infos = append(infos, cont["lain"].(container.Info))
+		delete(cont, "lain")
+	}
+
+	lain := common.MapStr{
+		"app_name":  infos[0].AppName,
+		"proc_name": infos[0].ProcName,
+		"instances": len(events),
+	}
+	lain["app_version"] = infos[0].AppVersion
+	for _, info := range infos[1:] {
+		// The instances differ while the proc is being upgraded.
+		if info.AppVersion != infos[0].AppVersion {
+			delete(lain, "app_version")
+			break
+		}
+	}
+
+	sum := sumMaps(events)
+	if cont := containerFields(sum); cont != nil {
+		cont["lain"] = lain
+	}
+	return sum
+}
+
+// sumMaps sums up the values of the maps.
+func sumMaps(maps []common.MapStr) common.MapStr {
+	result := common.MapStr{}
+	for k, v := range maps[0] {
+		values := make([]interface{}, 0, len(maps))
+		for _, m := range maps {
+			value, found := m[k]
+			if !found {
+				break
+			}
+			values = append(values, value)
+		}
+		if len(values) < len(maps) {
+			continue
+		}
+		if sum, ok := sumValues(v, values); ok {
+			result[k] = sum
+		}
+	}
+	return result
+}
+
+func sumValues(first interface{}, values []interface{}) (interface{}, bool) {
+	if _, ok := first.(common.MapStr); ok {
+		maps := make([]common.MapStr, 0, len(values))
+		for _, v := range values {
+			m, ok := v.(common.MapStr)
+			if !ok {
+				return nil, false
+			}
+			maps = append(maps, m)
+		}
+		return sumMaps(maps), true
+	}
+
+	sum := first
+	for _, v := range values[1:] {
+		var ok bool
+		if sum, ok = add(sum, v); !ok {
+			return nil, false
+		}
+	}
+	return sum, true
+}
+
+// add sums up numbers of the same type. Other values are only kept if they
+// are equal.
+func add(a, b interface{}) (interface{}, bool) {
+	switch a := a.(type) {
+	case int:
+		if b, ok := b.(int); ok {
+			return a + b, true
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			return a + b, true
+		}
+	case uint64:
+		if b, ok := b.(uint64); ok {
+			return a + b, true
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a + b, true
+		}
+	default:
+		if reflect.DeepEqual(a, b) {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// lainWatchers are the lainlet watchers by address. They are shared by all
+// metricsets and run as long as the process.
+var (
+	lainWatchersMutex sync.Mutex
+	lainWatchers      = map[string]*lainlet.Watcher{}
+)
+
+func getLainWatcher(address string) (*lainlet.Watcher, error) {
+	lainWatchersMutex.Lock()
+	defer lainWatchersMutex.Unlock()
+
+	w, found := lainWatchers[address]
+	if !found {
+		var err error
+		if w, err = lainlet.NewWatcher(address, nil); err != nil {
+			return nil, err
+		}
+		w.Start()
+		lainWatchers[address] = w
+	}
+	return w, nil
+}
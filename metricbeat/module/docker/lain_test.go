@@ -0,0 +1,137 @@
+// +build !integration
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/laincloud/lainlet/watcher/container"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+var testLainContainers = map[string]container.Info{
+	"web1": {AppName: "hello", ProcName: "hello.web.web", InstanceNo: 1, AppVersion: "1.0"},
+	"web2": {AppName: "hello", ProcName: "hello.web.web", InstanceNo: 2, AppVersion: "1.0"},
+	"job1": {AppName: "hello", ProcName: "hello.worker.job", InstanceNo: 1, AppVersion: "1.0"},
+}
+
+func newTestLainTagger(aggregate bool) *LainTagger {
+	return &LainTagger{
+		lookup: func(id string) (container.Info, bool) {
+			info, found := testLainContainers[id]
+			return info, found
+		},
+		aggregate: aggregate,
+	}
+}
+
+func memoryEvent(id string, usage uint64, pct float64) common.MapStr {
+	return common.MapStr{
+		mb.ModuleData: common.MapStr{
+			"container": common.MapStr{"id": id, "name": id},
+		},
+		"usage": common.MapStr{"total": usage, "pct": pct},
+	}
+}
+
+func TestLainTaggerTag(t *testing.T) {
+	events := newTestLainTagger(false).Tag([]common.MapStr{
+		memoryEvent("web1", 100, 0.1),
+		memoryEvent("other", 10, 0.01),
+	})
+
+	assert.Equal(t, []common.MapStr{
+		{
+			mb.ModuleData: common.MapStr{
+				"container": common.MapStr{
+					"id":   "web1",
+					"name": "web1",
+					"lain": common.MapStr{
+						"app_name":    "hello",
+						"proc_name":   "hello.web.web",
+						"instance_no": 1,
+						"app_version": "1.0",
+					},
+				},
+			},
+			"usage": common.MapStr{"total": uint64(100), "pct": 0.1},
+		},
+		memoryEvent("other", 10, 0.01),
+	}, events)
+}
+
+func TestLainTaggerAggregate(t *testing.T) {
+	events := newTestLainTagger(true).Tag([]common.MapStr{
+		memoryEvent("web1", 100, 0.25),
+		memoryEvent("job1", 50, 0.05),
+		memoryEvent("web2", 200, 0.5),
+		memoryEvent("other", 10, 0.01),
+	})
+
+	assert.Equal(t, []common.MapStr{
+		memoryEvent("other", 10, 0.01),
+		{
+			mb.ModuleData: common.MapStr{
+				"container": common.MapStr{
+					"lain": common.MapStr{
+						"app_name":    "hello",
+						"proc_name":   "hello.web.web",
+						"app_version": "1.0",
+						"instances":   2,
+					},
+				},
+			},
+			"usage": common.MapStr{"total": uint64(300), "pct": 0.75},
+		},
+		{
+			mb.ModuleData: common.MapStr{
+				"container": common.MapStr{
+					"id":   "job1",
+					"name": "job1",
+					"lain": common.MapStr{
+						"app_name":    "hello",
+						"proc_name":   "hello.worker.job",
+						"app_version": "1.0",
+						"instances":   1,
+					},
+				},
+			},
+			"usage": common.MapStr{"total": uint64(50), "pct": 0.05},
+		},
+	}, events)
+}
+
+func TestLainTaggerAggregateByInterface(t *testing.T) {
+	event := func(id, iface string, bytes uint64) common.MapStr {
+		return common.MapStr{
+			mb.ModuleData: common.MapStr{"container": common.MapStr{"id": id}},
+			"interface":   iface,
+			"in":          common.MapStr{"bytes": bytes},
+		}
+	}
+
+	events := newTestLainTagger(true).Tag([]common.MapStr{
+		event("web1", "eth0", 1), event("web1", "eth1", 2),
+		event("web2", "eth0", 10), event("web2", "eth1", 20),
+	})
+	if !assert.Len(t, events, 2) {
+		return
+	}
+	assert.Equal(t, "eth0", events[0]["interface"])
+	assert.Equal(t, common.MapStr{"bytes": uint64(11)}, events[0]["in"])
+	assert.Equal(t, "eth1", events[1]["interface"])
+	assert.Equal(t, common.MapStr{"bytes": uint64(22)}, events[1]["in"])
+}
+
+func TestLainTaggerNil(t *testing.T) {
+	var tagger *LainTagger
+	events := []common.MapStr{memoryEvent("web1", 100, 0.1)}
+	assert.Equal(t, events, tagger.Tag(events))
+
+	tagger, err := NewLainTagger(Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, tagger)
+}
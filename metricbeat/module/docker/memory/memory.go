@@ -19,6 +19,7 @@ type MetricSet struct {
 	mb.BaseMetricSet
 	memoryService *MemoryService
 	dockerClient  *dc.Client
+	lain          *docker.LainTagger
 }
 
 // New creates a new instance of the docker memory MetricSet.
@@ -35,10 +36,16 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
+	lain, err := docker.NewLainTagger(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
 		memoryService: &MemoryService{},
 		dockerClient:  client,
+		lain:          lain,
 	}, nil
 }
 
@@ -50,5 +57,5 @@ func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	}
 
 	memoryStats := m.memoryService.getMemoryStatsList(stats)
-	return eventsMapping(memoryStats), nil
+	return m.lain.Tag(eventsMapping(memoryStats)), nil
 }
@@ -19,6 +19,7 @@ type MetricSet struct {
 	mb.BaseMetricSet
 	netService   *NetService
 	dockerClient *dc.Client
+	lain         *docker.LainTagger
 }
 
 // New creates a new instance of the docker network MetricSet.
@@ -35,15 +36,27 @@ func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 		return nil, err
 	}
 
+	lain, err := docker.NewLainTagger(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
 		dockerClient:  client,
+		lain:          lain,
 		netService: &NetService{
 			NetworkStatPerContainer: make(map[string]map[string]NetRaw),
 		},
 	}, nil
 }
 
+// IdentifyingFields returns the fields telling the events of the network
+// interfaces of the containers apart.
+func (m *MetricSet) IdentifyingFields() []string {
+	return []string{mb.ModuleData + ".container.id", "interface"}
+}
+
 // Fetch methods creates a list of network events for each container.
 func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	stats, err := docker.FetchStats(m.dockerClient, m.Module().Config().Timeout)
@@ -52,5 +65,5 @@ func (m *MetricSet) Fetch() ([]common.MapStr, error) {
 	}
 
 	formattedStats := m.netService.getNetworkStatsPerContainer(stats)
-	return eventsMapping(formattedStats), nil
+	return m.lain.Tag(eventsMapping(formattedStats)), nil
 }
@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/logp"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// ContainerInfo holds the details of a container used to discover it.
+type ContainerInfo struct {
+	ID      string
+	Name    string
+	Image   string
+	Labels  map[string]string
+	Running bool
+	IP      string // IP address in the default bridge network, or else in the first network by name
+	Ports   []int  // exposed TCP and UDP ports, sorted
+}
+
+// ContainerEvent reports a container having been started or having died.
+type ContainerEvent struct {
+	Action string // start or die
+	ID     string
+}
+
+// Watcher lists, inspects and watches the containers of a Docker host. It uses
+// a client created by NewDockerClient, so the `ssl` settings of the module
+// apply.
+type Watcher struct {
+	client *docker.Client
+}
+
+// NewWatcher creates a watcher for the Docker host given as
+// unix:///path/to/socket or tcp://host:port.
+func NewWatcher(host string, config Config) (*Watcher, error) {
+	client, err := NewDockerClient(host, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{client: client}, nil
+}
+
+// RunningContainers returns the IDs of the running containers.
+func (w *Watcher) RunningContainers() ([]string, error) {
+	containers, err := w.client.ListContainers(docker.ListContainersOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// Inspect returns the details of a container.
+func (w *Watcher) Inspect(id string) (*ContainerInfo, error) {
+	c, err := w.client.InspectContainer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ContainerInfo{
+		ID:      c.ID,
+		Name:    strings.TrimPrefix(c.Name, "/"),
+		Running: c.State.Running,
+	}
+	if c.Config != nil {
+		info.Image = c.Config.Image
+		info.Labels = c.Config.Labels
+		for p := range c.Config.ExposedPorts {
+			port, err := strconv.Atoi(p.Port())
+			if err == nil {
+				info.Ports = append(info.Ports, port)
+			}
+		}
+		sort.Ints(info.Ports)
+	}
+	if c.NetworkSettings != nil {
+		info.IP = containerIP(c.NetworkSettings)
+	}
+	return info, nil
+}
+
+func containerIP(settings *docker.NetworkSettings) string {
+	if settings.IPAddress != "" {
+		return settings.IPAddress
+	}
+
+	names := make([]string, 0, len(settings.Networks))
+	for name := range settings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ip := settings.Networks[name].IPAddress; ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// Watch subscribes to the start and die events of the containers. The events
+// are sent on the returned channel, which is closed if the connection to
+// Docker fails or after the returned stop function has been called.
+func (w *Watcher) Watch() (<-chan ContainerEvent, func(), error) {
+	listener := make(chan *docker.APIEvents, 100)
+	if err := w.client.AddEventListener(listener); err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan ContainerEvent)
+	stop := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-stop:
+				w.removeListener(listener)
+				return
+			case e, ok := <-listener:
+				if !ok {
+					return
+				}
+
+				id, action := e.Actor.ID, e.Action
+				if e.Type == "" {
+					// API versions before 1.22
+					id, action = e.ID, e.Status
+				} else if e.Type != "container" {
+					continue
+				}
+				if action != "start" && action != "die" {
+					continue
+				}
+
+				select {
+				case events <- ContainerEvent{Action: action, ID: id}:
+				case <-stop:
+					w.removeListener(listener)
+					return
+				}
+			}
+		}
+	}()
+
+	return events, func() { once.Do(func() { close(stop) }) }, nil
+}
+
+// removeListener unsubscribes the listener. The listener is drained until
+// removed, as the client blocks on sending events to its listeners.
+func (w *Watcher) removeListener(listener chan *docker.APIEvents) {
+	removed := make(chan struct{})
+	go func() {
+		defer close(removed)
+		if err := w.client.RemoveEventListener(listener); err != nil {
+			logp.Err("Error removing Docker event listener: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-removed:
+			return
+		case <-listener:
+		}
+	}
+}
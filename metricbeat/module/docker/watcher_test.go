@@ -0,0 +1,26 @@
+// +build !integration
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerIP(t *testing.T) {
+	settings := &docker.NetworkSettings{
+		Networks: map[string]docker.ContainerNetwork{
+			"none":    {},
+			"backend": {IPAddress: "10.0.0.3"},
+			"web":     {IPAddress: "10.0.1.3"},
+		},
+	}
+	assert.Equal(t, "10.0.0.3", containerIP(settings))
+
+	settings.IPAddress = "172.17.0.2"
+	assert.Equal(t, "172.17.0.2", containerIP(settings))
+
+	assert.Equal(t, "", containerIP(&docker.NetworkSettings{}))
+}
@@ -0,0 +1,4 @@
+/*
+Package etcd is a Metricbeat module that contains MetricSets.
+*/
+package etcd
@@ -0,0 +1,48 @@
+package leader
+
+import (
+	"sort"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	s "github.com/elastic/beats/metricbeat/schema"
+	c "github.com/elastic/beats/metricbeat/schema/mapstriface"
+)
+
+var debugf = logp.MakeDebug("etcd-leader")
+
+var (
+	// The latencies are reported in milliseconds.
+	schema = s.Schema{
+		"latency": c.Dict("latency", s.Schema{
+			"current":            c.Float("current"),
+			"average":            c.Float("average"),
+			"standard_deviation": c.Float("standardDeviation"),
+			"minimum":            c.Float("minimum"),
+			"maximum":            c.Float("maximum"),
+		}),
+		"counts": c.Dict("counts", s.Schema{
+			"fail":    c.Int("fail"),
+			"success": c.Int("success"),
+		}),
+	}
+)
+
+func eventsMapping(leader string, followers map[string]map[string]interface{}) []common.MapStr {
+	ids := make([]string, 0, len(followers))
+	for id := range followers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	events := make([]common.MapStr, 0, len(ids))
+	for _, id := range ids {
+		follower := schema.Apply(followers[id])
+		follower["id"] = id
+		events = append(events, common.MapStr{
+			"leader":   leader,
+			"follower": follower,
+		})
+	}
+	return events
+}
@@ -0,0 +1,86 @@
+package leader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/mb/parse"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/v2/stats/leader"
+)
+
+var (
+	hostParser = parse.URLHostParserBuilder{
+		DefaultScheme: defaultScheme,
+		DefaultPath:   defaultPath,
+	}.Build()
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("etcd", "leader", New, hostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the statistics of the followers, as seen by the leader.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http *helper.HTTP
+}
+
+// New creates a new instance of the leader MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The etcd leader metricset is beta")
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+	}, nil
+}
+
+// Fetch returns an event per follower. Only the leader reports the followers,
+// so no events are returned if the member is not the leader.
+func (m *MetricSet) Fetch() ([]common.MapStr, error) {
+	resp, err := m.http.FetchResponse()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		debugf("%v is not the leader", m.Host())
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error %d in %s: %s", resp.StatusCode, m.Name(), resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		Leader    string                            `json:"leader"`
+		Followers map[string]map[string]interface{} `json:"followers"`
+	}
+	if err := json.Unmarshal(content, &stats); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+
+	return eventsMapping(stats.Leader, stats.Followers), nil
+}
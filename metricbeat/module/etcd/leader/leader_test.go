@@ -0,0 +1,79 @@
+// +build !integration
+
+package leader
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetchEventContents(t *testing.T) {
+	response, err := ioutil.ReadFile("../_meta/testdata/leader.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/stats/leader", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"leader"},
+		"hosts":      []string{server.URL},
+	})
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, events, 2) {
+		return
+	}
+
+	assert.Equal(t, common.MapStr{
+		"leader": "8e9e05c52164694d",
+		"follower": common.MapStr{
+			"id": "6e3bd23ae5f1eae0",
+			"latency": common.MapStr{
+				"current":            0.001434,
+				"average":            0.0025937424395503854,
+				"standard_deviation": 0.0032549307519232534,
+				"minimum":            0.000514,
+				"maximum":            0.063891,
+			},
+			"counts": common.MapStr{
+				"fail":    int64(0),
+				"success": int64(9694),
+			},
+		},
+	}, events[0])
+	assert.Equal(t, "a8266ecf031671f3", events[1]["follower"].(common.MapStr)["id"])
+}
+
+func TestFetchNotLeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"not current leader"}`))
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"leader"},
+		"hosts":      []string{server.URL},
+	})
+	events, err := f.Fetch()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
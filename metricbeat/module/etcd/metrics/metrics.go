@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/mb/parse"
+	"github.com/elastic/beats/metricbeat/module/prometheus/collector"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/metrics"
+)
+
+var (
+	hostParser = parse.URLHostParserBuilder{
+		DefaultScheme: defaultScheme,
+		DefaultPath:   defaultPath,
+	}.Build()
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("etcd", "metrics", New, hostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the Prometheus metrics of etcd.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http *helper.HTTP
+}
+
+// New creates a new instance of the metrics MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The etcd metrics metricset is beta")
+
+	http, err := collector.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+	}, nil
+}
+
+// Fetch returns an event per label set, like the Prometheus collector
+// metricset.
+func (m *MetricSet) Fetch() ([]common.MapStr, error) {
+	families, err := collector.FetchFamilies(m.http, m.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.GroupFamilies(families), nil
+}
@@ -0,0 +1,85 @@
+// +build !integration
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetchEventContents(t *testing.T) {
+	response, err := ioutil.ReadFile("../_meta/testdata/metrics.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/metrics", r.URL.Path)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"metrics"},
+		"hosts":      []string{server.URL},
+	})
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.MapStr{
+		{
+			"etcd_disk_wal_fsync_duration_seconds": common.MapStr{
+				"count": int64(6),
+				"sum":   0.0065,
+				"buckets": []common.MapStr{
+					{"le": 0.001, "count": int64(2)},
+					{"le": 0.002, "count": int64(5)},
+				},
+			},
+			"etcd_server_has_leader":                int64(1),
+			"etcd_server_leader_changes_seen_total": int64(1),
+			"etcd_server_proposals_committed_total": int64(19400),
+		},
+		{
+			"label":                                  common.MapStr{"From": "6e3bd23ae5f1eae0"},
+			"etcd_network_peer_received_bytes_total": int64(10452),
+		},
+		{
+			"label":                              common.MapStr{"To": "6e3bd23ae5f1eae0"},
+			"etcd_network_peer_sent_bytes_total": int64(1402683),
+		},
+		{
+			"label":                              common.MapStr{"To": "a8266ecf031671f3"},
+			"etcd_network_peer_sent_bytes_total": int64(1398040),
+		},
+	}, events)
+}
+
+func TestNewInvalidSSL(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"metrics"},
+		"hosts":      []string{"localhost"},
+		"ssl": map[string]interface{}{
+			"certificate_authorities": []string{"/nonexistent/ca.pem"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mb.NewModules([]*common.Config{c}, mb.Registry)
+	assert.Error(t, err)
+}
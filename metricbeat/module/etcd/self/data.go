@@ -0,0 +1,35 @@
+package self
+
+import (
+	s "github.com/elastic/beats/metricbeat/schema"
+	c "github.com/elastic/beats/metricbeat/schema/mapstriface"
+)
+
+var (
+	schema = s.Schema{
+		"name":       c.Str("name"),
+		"id":         c.Str("id"),
+		"state":      c.Str("state"),
+		"start_time": c.Str("startTime"),
+		"leader_info": c.Dict("leaderInfo", s.Schema{
+			"leader":     c.Str("leader"),
+			"uptime":     c.Str("uptime"),
+			"start_time": c.Str("startTime"),
+		}),
+		// The rates are only reported while data is received or sent.
+		"recv": s.Object{
+			"append_request": s.Object{
+				"count": c.Int("recvAppendRequestCnt"),
+			},
+			"pkg_rate":       c.Float("recvPkgRate", s.Optional),
+			"bandwidth_rate": c.Float("recvBandwidthRate", s.Optional),
+		},
+		"send": s.Object{
+			"append_request": s.Object{
+				"count": c.Int("sendAppendRequestCnt"),
+			},
+			"pkg_rate":       c.Float("sendPkgRate", s.Optional),
+			"bandwidth_rate": c.Float("sendBandwidthRate", s.Optional),
+		},
+	}
+)
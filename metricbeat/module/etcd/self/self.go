@@ -0,0 +1,66 @@
+package self
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/mb/parse"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/v2/stats/self"
+)
+
+var (
+	hostParser = parse.URLHostParserBuilder{
+		DefaultScheme: defaultScheme,
+		DefaultPath:   defaultPath,
+	}.Build()
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("etcd", "self", New, hostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the statistics of an etcd member.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http *helper.HTTP
+}
+
+// New creates a new instance of the self MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The etcd self metricset is beta")
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+	}, nil
+}
+
+// Fetch fetches the statistics of the member.
+func (m *MetricSet) Fetch() (common.MapStr, error) {
+	content, err := m.http.FetchContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(content, &stats); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+
+	return schema.Apply(stats), nil
+}
@@ -0,0 +1,59 @@
+// +build !integration
+
+package self
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetchEventContents(t *testing.T) {
+	response, err := ioutil.ReadFile("../_meta/testdata/self.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/stats/self", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventFetcher(t, map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"self"},
+		"hosts":      []string{server.URL},
+	})
+	event, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, common.MapStr{
+		"name":       "etcd1",
+		"id":         "8e9e05c52164694d",
+		"state":      "StateLeader",
+		"start_time": "2017-05-10T08:40:19.536465283Z",
+		"leader_info": common.MapStr{
+			"leader":     "8e9e05c52164694d",
+			"uptime":     "2h3m4.051356812s",
+			"start_time": "2017-05-10T08:40:20.237565301Z",
+		},
+		"recv": common.MapStr{
+			"append_request": common.MapStr{"count": int64(0)},
+		},
+		"send": common.MapStr{
+			"append_request": common.MapStr{"count": int64(19381)},
+			"pkg_rate":       9.968236358491537,
+			"bandwidth_rate": 6906.28411352216,
+		},
+	}, event)
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	s "github.com/elastic/beats/metricbeat/schema"
+	c "github.com/elastic/beats/metricbeat/schema/mapstriface"
+)
+
+var (
+	schema = s.Schema{
+		"gets": s.Object{
+			"success": c.Int("getsSuccess"),
+			"fail":    c.Int("getsFail"),
+		},
+		"sets": s.Object{
+			"success": c.Int("setsSuccess"),
+			"fail":    c.Int("setsFail"),
+		},
+		"delete": s.Object{
+			"success": c.Int("deleteSuccess"),
+			"fail":    c.Int("deleteFail"),
+		},
+		"update": s.Object{
+			"success": c.Int("updateSuccess"),
+			"fail":    c.Int("updateFail"),
+		},
+		"create": s.Object{
+			"success": c.Int("createSuccess"),
+			"fail":    c.Int("createFail"),
+		},
+		"compare_and_swap": s.Object{
+			"success": c.Int("compareAndSwapSuccess"),
+			"fail":    c.Int("compareAndSwapFail"),
+		},
+		"compare_and_delete": s.Object{
+			"success": c.Int("compareAndDeleteSuccess"),
+			"fail":    c.Int("compareAndDeleteFail"),
+		},
+		"expire": s.Object{
+			"count": c.Int("expireCount"),
+		},
+		"watchers": c.Int("watchers"),
+	}
+)
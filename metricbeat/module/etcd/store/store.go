@@ -0,0 +1,66 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/mb/parse"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/v2/stats/store"
+)
+
+var (
+	hostParser = parse.URLHostParserBuilder{
+		DefaultScheme: defaultScheme,
+		DefaultPath:   defaultPath,
+	}.Build()
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("etcd", "store", New, hostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the statistics of the etcd v2 store.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http *helper.HTTP
+}
+
+// New creates a new instance of the store MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The etcd store metricset is beta")
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+	}, nil
+}
+
+// Fetch fetches the statistics of the store operations.
+func (m *MetricSet) Fetch() (common.MapStr, error) {
+	content, err := m.http.FetchContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(content, &stats); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+
+	return schema.Apply(stats), nil
+}
@@ -0,0 +1,44 @@
+// +build !integration
+
+package store
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetchEventContents(t *testing.T) {
+	response, err := ioutil.ReadFile("../_meta/testdata/store.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/stats/store", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventFetcher(t, map[string]interface{}{
+		"module":     "etcd",
+		"metricsets": []string{"store"},
+		"hosts":      []string{server.URL},
+	})
+	event, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, common.MapStr{"success": int64(212), "fail": int64(31)}, event["gets"])
+	assert.Equal(t, common.MapStr{"success": int64(4017), "fail": int64(3)}, event["compare_and_swap"])
+	assert.Equal(t, common.MapStr{"count": int64(17)}, event["expire"])
+	assert.Equal(t, int64(8), event["watchers"])
+}
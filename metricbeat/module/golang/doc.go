@@ -0,0 +1,5 @@
+/*
+Package golang is a Metricbeat module that fetches the expvar variables and
+runtime memory statistics of Go applications.
+*/
+package golang
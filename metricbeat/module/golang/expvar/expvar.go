@@ -0,0 +1,119 @@
+package expvar
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/module/golang"
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("golang", "expvar", New, golang.HostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the expvar variables of a Go application.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http      *helper.HTTP
+	namespace string
+	include   []string
+	exclude   []string
+}
+
+// New creates a new instance of the expvar MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The golang expvar metricset is beta")
+
+	config := struct {
+		Expvar struct {
+			Namespace string   `config:"namespace"`
+			Include   []string `config:"include"`
+			Exclude   []string `config:"exclude"`
+		} `config:"expvar"`
+	}{}
+	config.Expvar.Exclude = []string{"memstats", "cmdline"}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range append(config.Expvar.Include, config.Expvar.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid expvar pattern '%v': %v", pattern, err)
+		}
+	}
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+		namespace:     config.Expvar.Namespace,
+		include:       config.Expvar.Include,
+		exclude:       config.Expvar.Exclude,
+	}, nil
+}
+
+// Fetch fetches the variables. They are reported under the namespace, if one
+// is configured.
+func (m *MetricSet) Fetch() (common.MapStr, error) {
+	content, err := m.http.FetchContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var vars map[string]interface{}
+	if err := json.Unmarshal(content, &vars); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+
+	event := m.filter(vars, "", len(m.include) == 0)
+	if m.namespace == "" {
+		return event, nil
+	}
+	return common.MapStr{m.namespace: event}, nil
+}
+
+// filter returns the variables not excluded and, if include patterns are
+// given, included. Nested variables are named with dots. A variable is
+// included if it or one of its parents matches an include pattern. Objects
+// without any variable left are dropped.
+func (m *MetricSet) filter(vars map[string]interface{}, prefix string, included bool) common.MapStr {
+	result := common.MapStr{}
+	for k, v := range vars {
+		name := prefix + k
+		if matchAny(m.exclude, name) {
+			continue
+		}
+		inc := included || matchAny(m.include, name)
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			if filtered := m.filter(nested, name+".", inc); len(filtered) > 0 {
+				result[k] = filtered
+			}
+			continue
+		}
+		if inc {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// +build !integration
+
+package expvar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+const testVars = `{
+	"cmdline": ["/usr/bin/app", "-debug"],
+	"memstats": {"Alloc": 1024, "HeapSys": 4096},
+	"requests": 42,
+	"http": {"requests": {"get": 10, "post": 2}, "errors": 1},
+	"version": "1.2.3"
+}`
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/debug/vars" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(testVars))
+	}))
+}
+
+func fetch(t *testing.T, config map[string]interface{}) common.MapStr {
+	server := newTestServer()
+	defer server.Close()
+
+	config["module"] = "golang"
+	config["metricsets"] = []string{"expvar"}
+	config["hosts"] = []string{server.URL}
+
+	f := mbtest.NewEventFetcher(t, config)
+	event, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestFetch(t *testing.T) {
+	event := fetch(t, map[string]interface{}{})
+
+	assert.Equal(t, common.MapStr{
+		"requests": float64(42),
+		"http": common.MapStr{
+			"requests": common.MapStr{"get": float64(10), "post": float64(2)},
+			"errors":   float64(1),
+		},
+		"version": "1.2.3",
+	}, event)
+}
+
+func TestFetchNamespaceAndFilters(t *testing.T) {
+	event := fetch(t, map[string]interface{}{
+		"expvar.namespace": "myapp",
+		"expvar.include":   []string{"http", "requests"},
+		"expvar.exclude":   []string{"http.requests.p*"},
+	})
+
+	assert.Equal(t, common.MapStr{
+		"myapp": common.MapStr{
+			"requests": float64(42),
+			"http": common.MapStr{
+				"requests": common.MapStr{"get": float64(10)},
+				"errors":   float64(1),
+			},
+		},
+	}, event)
+}
+
+func TestFetchCustomPath(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	f := mbtest.NewEventFetcher(t, map[string]interface{}{
+		"module":      "golang",
+		"metricsets":  []string{"expvar"},
+		"hosts":       []string{server.URL},
+		"expvar_path": "/other",
+	})
+	_, err := f.Fetch()
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package golang
+
+import (
+	"github.com/elastic/beats/metricbeat/mb/parse"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/debug/vars"
+)
+
+// HostParser is used for parsing the configured Go application hosts. The
+// path of the expvar handler can be set with `expvar_path`.
+var HostParser = parse.URLHostParserBuilder{
+	DefaultScheme: defaultScheme,
+	DefaultPath:   defaultPath,
+	PathConfigKey: "expvar_path",
+}.Build()
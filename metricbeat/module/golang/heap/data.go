@@ -0,0 +1,84 @@
+package heap
+
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func eventMapping(cmdline []string, stats *runtime.MemStats) common.MapStr {
+	return common.MapStr{
+		"cmdline": strings.Join(cmdline, " "),
+		"gc": common.MapStr{
+			"total_pause": common.MapStr{
+				"ns": stats.PauseTotalNs,
+			},
+			"total_count":   stats.NumGC,
+			"next_gc_limit": stats.NextGC,
+			"cpu_fraction":  stats.GCCPUFraction,
+		},
+		"system": common.MapStr{
+			"total":    stats.Sys,
+			"obtained": stats.HeapSys,
+			"stack":    stats.StackSys,
+			"released": stats.HeapReleased,
+		},
+		"allocations": common.MapStr{
+			"mallocs":   stats.Mallocs,
+			"frees":     stats.Frees,
+			"objects":   stats.HeapObjects,
+			"total":     stats.TotalAlloc,
+			"allocated": stats.HeapAlloc,
+			"idle":      stats.HeapIdle,
+			"active":    stats.HeapInuse,
+		},
+	}
+}
+
+// addDerived adds the GC pauses and the allocation rates since the previous
+// fetch. Nothing is added if the application was restarted in between.
+func addDerived(event common.MapStr, last, current *runtime.MemStats, elapsed time.Duration) {
+	if current.NumGC < last.NumGC || current.TotalAlloc < last.TotalAlloc || elapsed <= 0 {
+		return
+	}
+	seconds := elapsed.Seconds()
+
+	count := current.NumGC - last.NumGC
+	sum := current.PauseTotalNs - last.PauseTotalNs
+	pause := common.MapStr{
+		"count": count,
+		"sum":   common.MapStr{"ns": sum},
+		"pct":   float64(sum) / float64(elapsed.Nanoseconds()),
+	}
+	if count > 0 {
+		pause["avg"] = common.MapStr{"ns": sum / uint64(count)}
+		pause["max"] = common.MapStr{"ns": maxPause(current, count)}
+	}
+	event.Put("gc.pause", pause)
+
+	event.Put("allocations.rate", common.MapStr{
+		"bytes":   float64(current.TotalAlloc-last.TotalAlloc) / seconds,
+		"mallocs": float64(current.Mallocs-last.Mallocs) / seconds,
+	})
+}
+
+// maxPause returns the longest of the last count GC pauses. The pauses are
+// kept in a circular buffer, so only the most recent ones are known.
+func maxPause(stats *runtime.MemStats, count uint32) uint64 {
+	size := uint32(len(stats.PauseNs))
+	if count > size {
+		count = size
+	}
+
+	var max uint64
+	for i := uint32(0); i < count; i++ {
+		// The most recent pause is at (NumGC+255)%256.
+		pause := stats.PauseNs[(stats.NumGC-i+size-1)%size]
+		if pause > max {
+			max = pause
+		}
+	}
+	return max
+}
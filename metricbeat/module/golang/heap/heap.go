@@ -0,0 +1,74 @@
+package heap
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/module/golang"
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("golang", "heap", New, golang.HostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches the runtime memory statistics of a Go application.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http *helper.HTTP
+
+	// last are the statistics of the previous fetch, used to compute the GC
+	// pauses and allocation rates in between.
+	last     *runtime.MemStats
+	lastTime time.Time
+}
+
+// New creates a new instance of the heap MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The golang heap metricset is beta")
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+	}, nil
+}
+
+// Fetch fetches the memory statistics found in the memstats variable.
+func (m *MetricSet) Fetch() (common.MapStr, error) {
+	content, err := m.http.FetchContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var vars struct {
+		Cmdline  []string          `json:"cmdline"`
+		MemStats *runtime.MemStats `json:"memstats"`
+	}
+	if err := json.Unmarshal(content, &vars); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+	if vars.MemStats == nil {
+		return nil, fmt.Errorf("memstats variable not found")
+	}
+
+	now := time.Now()
+	event := eventMapping(vars.Cmdline, vars.MemStats)
+	if m.last != nil {
+		addDerived(event, m.last, vars.MemStats, now.Sub(m.lastTime))
+	}
+	m.last, m.lastTime = vars.MemStats, now
+
+	return event, nil
+}
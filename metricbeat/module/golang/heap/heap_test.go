@@ -0,0 +1,122 @@
+// +build !integration
+
+package heap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{
+			"cmdline": ["/usr/bin/app", "-debug"],
+			"memstats": {"Alloc": 1024, "TotalAlloc": 4096, "Sys": 8192, "NumGC": 3, "PauseTotalNs": 300}
+		}`))
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventFetcher(t, map[string]interface{}{
+		"module":     "golang",
+		"metricsets": []string{"heap"},
+		"hosts":      []string{server.URL},
+	})
+
+	event, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/usr/bin/app -debug", event["cmdline"])
+	assert.Equal(t, uint64(8192), event["system"].(common.MapStr)["total"])
+	assert.Equal(t, uint32(3), event["gc"].(common.MapStr)["total_count"])
+	assert.NotContains(t, event["gc"], "pause")
+
+	// The second fetch has derived values.
+	event, err = f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pause := event["gc"].(common.MapStr)["pause"].(common.MapStr)
+	assert.Equal(t, uint32(0), pause["count"])
+	assert.Equal(t, 0.0, event["allocations"].(common.MapStr)["rate"].(common.MapStr)["bytes"])
+}
+
+func TestFetchMissingMemStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cmdline": ["/usr/bin/app"]}`))
+	}))
+	defer server.Close()
+
+	f := mbtest.NewEventFetcher(t, map[string]interface{}{
+		"module":     "golang",
+		"metricsets": []string{"heap"},
+		"hosts":      []string{server.URL},
+	})
+	_, err := f.Fetch()
+	assert.Error(t, err)
+}
+
+func TestAddDerived(t *testing.T) {
+	last := &runtime.MemStats{NumGC: 254, PauseTotalNs: 1000, TotalAlloc: 1000, Mallocs: 10}
+	current := &runtime.MemStats{NumGC: 258, PauseTotalNs: 1800, TotalAlloc: 3000, Mallocs: 30}
+	// The pauses of GC number 255 to 258 wrap around the buffer.
+	current.PauseNs[254] = 100
+	current.PauseNs[255] = 400
+	current.PauseNs[0] = 200
+	current.PauseNs[1] = 100
+	current.PauseNs[2] = 9999
+
+	event := eventMapping(nil, current)
+	addDerived(event, last, current, 2*time.Second)
+
+	gc := event["gc"].(common.MapStr)
+	assert.Equal(t, common.MapStr{
+		"count": uint32(4),
+		"sum":   common.MapStr{"ns": uint64(800)},
+		"avg":   common.MapStr{"ns": uint64(200)},
+		"max":   common.MapStr{"ns": uint64(400)},
+		"pct":   800.0 / 2e9,
+	}, gc["pause"])
+
+	assert.Equal(t, common.MapStr{
+		"bytes":   1000.0,
+		"mallocs": 10.0,
+	}, event["allocations"].(common.MapStr)["rate"])
+}
+
+func TestAddDerivedRestart(t *testing.T) {
+	last := &runtime.MemStats{NumGC: 10, TotalAlloc: 1000}
+	current := &runtime.MemStats{NumGC: 1, TotalAlloc: 10}
+
+	event := eventMapping(nil, current)
+	addDerived(event, last, current, time.Second)
+	assert.NotContains(t, event["gc"], "pause")
+	assert.NotContains(t, event["allocations"], "rate")
+}
+
+func TestMemStatsJSON(t *testing.T) {
+	// The memstats variable is runtime.MemStats encoded as JSON.
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	content, err := json.Marshal(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded runtime.MemStats
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stats.NumGC, decoded.NumGC)
+	assert.Equal(t, stats.Sys, decoded.Sys)
+}
@@ -0,0 +1,5 @@
+/*
+Package graphite is a Metricbeat module that receives metrics sent with the
+Graphite plaintext protocol.
+*/
+package graphite
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// parser turns lines of the Graphite plaintext protocol into events.
+type parser struct {
+	templates       []*template
+	defaultTemplate *template
+}
+
+// parse parses a line of the form `<metric path> <value> [<timestamp>]`. The
+// timestamp is given in seconds since the epoch. If it is missing or -1, the
+// current time is used.
+func (p *parser) parse(line string) (common.MapStr, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid line '%v': expected <metric path> <value> [<timestamp>]", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("invalid line '%v': invalid value '%v'", line, fields[1])
+	}
+
+	timestamp := time.Now()
+	if len(fields) == 3 && fields[2] != "-1" {
+		seconds, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid line '%v': invalid timestamp '%v'", line, fields[2])
+		}
+		timestamp = time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+
+	name := strings.Split(fields[0], ".")
+	metric, tags := p.template(name).apply(name)
+	if metric == "" {
+		return nil, fmt.Errorf("invalid line '%v': template yields an empty metric", line)
+	}
+
+	event := common.MapStr{
+		"@timestamp": common.Time(timestamp),
+		"metric":     metric,
+		"value":      value,
+	}
+	if len(tags) > 0 {
+		t := common.MapStr{}
+		for k, v := range tags {
+			t[k] = v
+		}
+		event["tags"] = t
+	}
+	return event, nil
+}
+
+// template returns the first template whose filter matches the name, or the
+// default template.
+func (p *parser) template(name []string) *template {
+	for _, t := range p.templates {
+		if t.matches(name) {
+			return t
+		}
+	}
+	return p.defaultTemplate
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+const defaultPort = 2003
+
+func init() {
+	if err := mb.Registry.AddMetricSet("graphite", "server", New); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet receives metrics sent with the Graphite plaintext protocol and
+// reports every metric as an event.
+type MetricSet struct {
+	mb.BaseMetricSet
+	server *helper.Server
+	parser *parser
+}
+
+// New creates a new instance of the MetricSet. The listening socket is only
+// opened by Run.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The graphite server metricset is beta")
+
+	config := struct {
+		Templates       []templateConfig `config:"templates"`
+		DefaultTemplate templateConfig   `config:"default_template"`
+	}{
+		DefaultTemplate: templateConfig{
+			Template:  "metric*",
+			Delimiter: ".",
+		},
+	}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	p := &parser{}
+	for _, c := range config.Templates {
+		t, err := newTemplate(c)
+		if err != nil {
+			return nil, err
+		}
+		p.templates = append(p.templates, t)
+	}
+
+	var err error
+	if p.defaultTemplate, err = newTemplate(config.DefaultTemplate); err != nil {
+		return nil, err
+	}
+
+	server, err := helper.NewServer(base, "tcp", defaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		server:        server,
+		parser:        p,
+	}, nil
+}
+
+// Run receives metrics until the done channel is closed.
+func (m *MetricSet) Run(r mb.PushReporter, done <-chan struct{}) {
+	err := m.server.Run(func(line []byte) {
+		event, err := m.parser.parse(string(line))
+		if err != nil {
+			r.Error(err)
+			return
+		}
+		r.Event(event)
+	}, done)
+	if err != nil {
+		logp.Err("graphite server failed: %v", err)
+		r.Error(err)
+	}
+}
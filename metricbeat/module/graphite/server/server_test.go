@@ -0,0 +1,166 @@
+// +build !integration
+
+package server
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateApply(t *testing.T) {
+	tests := []struct {
+		config templateConfig
+		name   string
+		metric string
+		tags   map[string]string
+	}{
+		{
+			config: templateConfig{Template: "metric*"},
+			name:   "servers.web01.cpu",
+			metric: "servers.web01.cpu",
+			tags:   map[string]string{},
+		},
+		{
+			config: templateConfig{Template: ".host.metric*", Delimiter: "_"},
+			name:   "servers.web01.cpu.load",
+			metric: "cpu_load",
+			tags:   map[string]string{"host": "web01"},
+		},
+		{
+			config: templateConfig{
+				Template: "region.host.metric",
+				Tags:     map[string]string{"env": "prod"},
+			},
+			name:   "eu.web01.cpu.ignored",
+			metric: "cpu",
+			tags:   map[string]string{"region": "eu", "host": "web01", "env": "prod"},
+		},
+		{
+			config: templateConfig{Template: "metric.host.metric"},
+			name:   "cpu.web01.load",
+			metric: "cpu.load",
+			tags:   map[string]string{"host": "web01"},
+		},
+	}
+
+	for _, test := range tests {
+		tmpl, err := newTemplate(test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		metric, tags := tmpl.apply(strings.Split(test.name, "."))
+		assert.Equal(t, test.metric, metric, test.name)
+		assert.Equal(t, test.tags, tags, test.name)
+	}
+}
+
+func TestTemplateMatches(t *testing.T) {
+	tmpl, err := newTemplate(templateConfig{Filter: "servers.web*", Template: "metric*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, tmpl.matches(strings.Split("servers.web01.cpu", ".")))
+	assert.False(t, tmpl.matches(strings.Split("servers.db01.cpu", ".")))
+	assert.False(t, tmpl.matches([]string{"servers"}))
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	for _, config := range []templateConfig{
+		{Template: "host"},
+		{Template: "metric*.host"},
+		{Template: "metric*", Filter: "servers.[web"},
+	} {
+		_, err := newTemplate(config)
+		assert.Error(t, err, config.Template)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tmpl, err := newTemplate(templateConfig{Filter: "servers", Template: ".host.metric*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultTmpl, err := newTemplate(templateConfig{Template: "metric*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &parser{templates: []*template{tmpl}, defaultTemplate: defaultTmpl}
+
+	event, err := p.parse("servers.web01.cpu.load 1.5 1500000000")
+	if assert.NoError(t, err) {
+		assert.Equal(t, common.MapStr{
+			"@timestamp": common.Time(time.Unix(1500000000, 0)),
+			"metric":     "cpu.load",
+			"value":      1.5,
+			"tags":       common.MapStr{"host": "web01"},
+		}, event)
+	}
+
+	event, err = p.parse("jobs.queued 3")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "jobs.queued", event["metric"])
+		assert.Equal(t, 3.0, event["value"])
+		assert.NotContains(t, event, "tags")
+	}
+
+	for _, line := range []string{
+		"jobs.queued",
+		"jobs.queued three",
+		"jobs.queued NaN",
+		"jobs.queued 3 yesterday",
+		"jobs.queued 3 1500000000 extra",
+	} {
+		_, err := p.parse(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestRunTCP(t *testing.T) {
+	ms := mbtest.NewPushMetricSet(t, map[string]interface{}{
+		"module":     "graphite",
+		"metricsets": []string{"server"},
+		"port":       0,
+		"templates": []map[string]interface{}{
+			{"filter": "servers", "template": ".host.metric*"},
+		},
+	})
+
+	// The socket is only opened by Run, so write once it is listening.
+	go func() {
+		var addr net.Addr
+		for i := 0; addr == nil; i++ {
+			if i == 100 {
+				t.Error("timeout waiting for the server to listen")
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			addr = ms.(*MetricSet).server.Addr()
+		}
+
+		conn, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("servers.web01.cpu 1 1500000000\nbroken\n")); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	events, errs := mbtest.RunPushMetricSet(200*time.Millisecond, ms)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "cpu", events[0]["metric"])
+		assert.Equal(t, common.MapStr{"host": "web01"}, events[0]["tags"])
+	}
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const metricPart = "metric"
+
+// templateConfig is the configuration of a template. The filter selects the
+// metric names the template is applied to.
+type templateConfig struct {
+	Filter    string            `config:"filter"`
+	Template  string            `config:"template"  validate:"required"`
+	Delimiter string            `config:"delimiter"`
+	Tags      map[string]string `config:"tags"`
+}
+
+// template splits a dot separated Graphite metric name into the metric and
+// tags. Every part of the template names the meaning of the part of the metric
+// name at the same position:
+//
+//	metric   the part is added to the metric
+//	metric*  the part and all following parts are added to the metric
+//	<name>   the part is the value of the tag <name>
+//	(empty)  the part is ignored
+//
+// For example the template `.host.metric*` parses `servers.web01.cpu.load`
+// into the metric `cpu.load` and the tag `host: web01`.
+type template struct {
+	filter    []string
+	parts     []string
+	delimiter string
+	tags      map[string]string
+}
+
+func newTemplate(config templateConfig) (*template, error) {
+	t := &template{
+		parts:     strings.Split(config.Template, "."),
+		delimiter: config.Delimiter,
+		tags:      config.Tags,
+	}
+	if t.delimiter == "" {
+		t.delimiter = "."
+	}
+	if config.Filter != "" {
+		t.filter = strings.Split(config.Filter, ".")
+	}
+
+	hasMetric := false
+	for i, part := range t.parts {
+		if part == metricPart+"*" && i != len(t.parts)-1 {
+			return nil, fmt.Errorf("invalid template '%v': %v* must be the last part",
+				config.Template, metricPart)
+		}
+		if part == metricPart || part == metricPart+"*" {
+			hasMetric = true
+		}
+	}
+	if !hasMetric {
+		return nil, fmt.Errorf("invalid template '%v': no %v part", config.Template, metricPart)
+	}
+
+	for _, pattern := range t.filter {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter '%v': %v", config.Filter, err)
+		}
+	}
+	return t, nil
+}
+
+// matches checks if the leading parts of the name match the filter. A template
+// without filter matches all names.
+func (t *template) matches(name []string) bool {
+	if len(name) < len(t.filter) {
+		return false
+	}
+	for i, pattern := range t.filter {
+		if matched, _ := path.Match(pattern, name[i]); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the metric and the tags of the name. Parts of the name
+// without a matching template part are ignored.
+func (t *template) apply(name []string) (string, map[string]string) {
+	var metric []string
+	tags := map[string]string{}
+	for k, v := range t.tags {
+		tags[k] = v
+	}
+
+	for i, part := range t.parts {
+		if i >= len(name) {
+			break
+		}
+
+		switch part {
+		case "":
+		case metricPart:
+			metric = append(metric, name[i])
+		case metricPart + "*":
+			metric = append(metric, name[i:]...)
+		default:
+			tags[part] = name[i]
+		}
+	}
+
+	return strings.Join(metric, t.delimiter), tags
+}
@@ -0,0 +1,5 @@
+/*
+Package http is a Metricbeat module that fetches JSON documents from HTTP
+endpoints.
+*/
+package http
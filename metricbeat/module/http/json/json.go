@@ -0,0 +1,198 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/mb/parse"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPath   = "/"
+)
+
+var hostParser = parse.URLHostParserBuilder{
+	DefaultScheme: defaultScheme,
+	DefaultPath:   defaultPath,
+	PathConfigKey: "path",
+}.Build()
+
+func init() {
+	if err := mb.Registry.AddMetricSet("http", "json", New, hostParser); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet fetches a JSON document from an HTTP endpoint.
+type MetricSet struct {
+	mb.BaseMetricSet
+	http          *helper.HTTP
+	namespace     string
+	path          []string
+	coerceNumbers bool
+}
+
+// New creates a new instance of the json MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The http json metricset is beta")
+
+	config := struct {
+		Namespace string `config:"namespace" validate:"required"`
+		Method    string `config:"method"`
+		Body      string `config:"body"`
+		JSON      struct {
+			Path          string `config:"path"`
+			CoerceNumbers bool   `config:"coerce_numbers"`
+		} `config:"json"`
+	}{
+		Method: "GET",
+	}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	http, err := helper.NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+	http.SetMethod(strings.ToUpper(config.Method))
+	if config.Body != "" {
+		http.SetBody([]byte(config.Body))
+	}
+
+	var path []string
+	if config.JSON.Path != "" {
+		path = strings.Split(config.JSON.Path, ".")
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		http:          http,
+		namespace:     config.Namespace,
+		path:          path,
+		coerceNumbers: config.JSON.CoerceNumbers,
+	}, nil
+}
+
+// Fetch requests the document and returns the sub-document at the configured
+// path. An array is split up into one event per element.
+func (m *MetricSet) Fetch() ([]common.MapStr, error) {
+	content, err := m.http.FetchContent()
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing json: %v", err)
+	}
+
+	doc, err = extract(doc, m.path)
+	if err != nil {
+		return nil, err
+	}
+	doc = m.convert(doc)
+
+	var docs []interface{}
+	if array, ok := doc.([]interface{}); ok {
+		docs = array
+	} else {
+		docs = []interface{}{doc}
+	}
+
+	events := make([]common.MapStr, 0, len(docs))
+	for _, d := range docs {
+		object, ok := d.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a json object, found %T", d)
+		}
+		events = append(events, common.MapStr{m.namespace: toMapStr(object)})
+	}
+	return events, nil
+}
+
+// extract returns the sub-document at the path. Array elements are selected
+// by their index.
+func extract(doc interface{}, path []string) (interface{}, error) {
+	for i, key := range path {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			value, found := d[key]
+			if !found {
+				return nil, fmt.Errorf("json path '%v' not found", strings.Join(path[:i+1], "."))
+			}
+			doc = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(d) {
+				return nil, fmt.Errorf("json path '%v' not found", strings.Join(path[:i+1], "."))
+			}
+			doc = d[index]
+		default:
+			return nil, fmt.Errorf("json path '%v' not found", strings.Join(path[:i+1], "."))
+		}
+	}
+	return doc, nil
+}
+
+// convert converts the numbers of the document to int64, or to float64 if
+// they are not integers. If numbers are coerced, strings containing numbers
+// are converted the same way.
+func (m *MetricSet) convert(doc interface{}) interface{} {
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		for k, v := range d {
+			d[k] = m.convert(v)
+		}
+	case []interface{}:
+		for i, v := range d {
+			d[i] = m.convert(v)
+		}
+	case json.Number:
+		if n := toNumber(string(d)); n != nil {
+			return n
+		}
+		return string(d)
+	case string:
+		if m.coerceNumbers {
+			if n := toNumber(strings.TrimSpace(d)); n != nil {
+				return n
+			}
+		}
+	}
+	return doc
+}
+
+// toNumber parses a number. It returns nil if the string is not a finite
+// number.
+func toNumber(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	return nil
+}
+
+func toMapStr(object map[string]interface{}) common.MapStr {
+	m := common.MapStr{}
+	for k, v := range object {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = toMapStr(nested)
+		}
+		m[k] = v
+	}
+	return m
+}
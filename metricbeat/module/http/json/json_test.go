@@ -0,0 +1,154 @@
+// +build !integration
+
+package json
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+const testDoc = `{
+	"status": "ok",
+	"queues": [
+		{"name": "default", "pending": 12, "workers": "4", "load": "0.5"},
+		{"name": "mail", "pending": 0, "workers": "1", "load": "n/a"}
+	],
+	"uptime": 1234.5
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testDoc))
+		case "/query":
+			body, _ := ioutil.ReadAll(r.Body)
+			if r.Method != "POST" || string(body) != `{"query": "stats"}` || r.Header.Get("X-Token") != "secret" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"count": 3}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func fetch(t *testing.T, server *httptest.Server, config map[string]interface{}) ([]common.MapStr, error) {
+	config["module"] = "http"
+	config["metricsets"] = []string{"json"}
+	config["hosts"] = []string{server.URL}
+	config["namespace"] = "test"
+
+	f := mbtest.NewEventsFetcher(t, config)
+	return f.Fetch()
+}
+
+func TestFetch(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	events, err := fetch(t, server, map[string]interface{}{"path": "/status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, events, 1) {
+		return
+	}
+
+	doc := events[0]["test"].(common.MapStr)
+	assert.Equal(t, "ok", doc["status"])
+	assert.Equal(t, 1234.5, doc["uptime"])
+	assert.Len(t, doc["queues"], 2)
+}
+
+func TestFetchPathSplitCoerce(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	events, err := fetch(t, server, map[string]interface{}{
+		"path":                "/status",
+		"json.path":           "queues",
+		"json.coerce_numbers": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.MapStr{
+		{"test": common.MapStr{"name": "default", "pending": int64(12), "workers": int64(4), "load": 0.5}},
+		{"test": common.MapStr{"name": "mail", "pending": int64(0), "workers": int64(1), "load": "n/a"}},
+	}, events)
+
+	events, err = fetch(t, server, map[string]interface{}{
+		"path":      "/status",
+		"json.path": "queues.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []common.MapStr{
+		{"test": common.MapStr{"name": "mail", "pending": int64(0), "workers": "1", "load": "n/a"}},
+	}, events)
+}
+
+func TestFetchPathNotFound(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	for _, path := range []string{"missing", "queues.2", "status.name"} {
+		_, err := fetch(t, server, map[string]interface{}{"path": "/status", "json.path": path})
+		assert.Error(t, err, path)
+	}
+}
+
+func TestFetchRequest(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	events, err := fetch(t, server, map[string]interface{}{
+		"path":    "/query",
+		"method":  "post",
+		"body":    `{"query": "stats"}`,
+		"headers": map[string]string{"X-Token": "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []common.MapStr{{"test": common.MapStr{"count": int64(3)}}}, events)
+}
+
+func TestNewInvalidSSL(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"module":     "http",
+		"metricsets": []string{"json"},
+		"hosts":      []string{"localhost"},
+		"namespace":  "test",
+		"ssl": map[string]interface{}{
+			"certificate_authorities": []string{"/nonexistent/ca.pem"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mb.NewModules([]*common.Config{c}, mb.Registry)
+	assert.Error(t, err)
+}
+
+func TestToNumber(t *testing.T) {
+	assert.Equal(t, int64(-7), toNumber("-7"))
+	assert.Equal(t, 1.5e3, toNumber("1.5e3"))
+	assert.Nil(t, toNumber("NaN"))
+	assert.Nil(t, toNumber("1e500"))
+	assert.Nil(t, toNumber("12abc"))
+}
@@ -161,6 +161,139 @@ func (b *Broker) PartitionOffset(
 	return block.Offsets[0], nil
 }
 
+// FetchHighWaterMarks fetches the newest offsets of the given topic
+// partitions from the partition leaders. If the leader of a partition changes
+// during the fetch, the partition is queried again with refreshed metadata.
+// Partitions whose offset can't be fetched are missing in the result.
+func (b *Broker) FetchHighWaterMarks(
+	topics map[string][]int32,
+) (map[string]map[int32]int64, error) {
+	offsets := map[string]map[int32]int64{}
+
+	pending := topics
+	for retries := 0; len(pending) > 0; retries++ {
+		if retries > 0 {
+			if retries > b.cfg.Metadata.Retry.Max {
+				debugf("failed to fetch high water marks of %v", pending)
+				break
+			}
+			time.Sleep(b.cfg.Metadata.Retry.Backoff)
+		}
+
+		var err error
+		pending, err = b.fetchHighWaterMarks(pending, offsets)
+		if err != nil {
+			return offsets, err
+		}
+	}
+
+	return offsets, nil
+}
+
+// fetchHighWaterMarks queries the current leaders of the partitions for their
+// newest offsets, which are added to offsets. It returns the partitions to
+// retry, because their leader is unknown or has changed.
+func (b *Broker) fetchHighWaterMarks(
+	topics map[string][]int32,
+	offsets map[string]map[int32]int64,
+) (map[string][]int32, error) {
+	names := make([]string, 0, len(topics))
+	for topic := range topics {
+		names = append(names, topic)
+	}
+
+	meta, err := b.GetMetadata(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	brokers := map[int32]*sarama.Broker{}
+	for _, broker := range meta.Brokers {
+		brokers[broker.ID()] = broker
+	}
+
+	retry := map[string][]int32{}
+	requests := map[int32]*sarama.OffsetRequest{}
+	requested := map[int32]map[string][]int32{}
+	for _, topic := range meta.Topics {
+		leaders := map[int32]int32{}
+		for _, partition := range topic.Partitions {
+			if partition.Err == sarama.ErrNoError {
+				leaders[partition.ID] = partition.Leader
+			}
+		}
+
+		for _, partition := range topics[topic.Name] {
+			leader, found := leaders[partition]
+			if !found || brokers[leader] == nil {
+				retry[topic.Name] = append(retry[topic.Name], partition)
+				continue
+			}
+
+			requ := requests[leader]
+			if requ == nil {
+				requ = &sarama.OffsetRequest{}
+				requests[leader] = requ
+				requested[leader] = map[string][]int32{}
+			}
+			requ.AddBlock(topic.Name, partition, sarama.OffsetNewest, 1)
+			requested[leader][topic.Name] = append(requested[leader][topic.Name], partition)
+		}
+	}
+
+	for leader, requ := range requests {
+		resp, err := b.leaderOffsets(brokers[leader], requ)
+		if err != nil {
+			debugf("failed to query offsets from leader %v: %v", leader, err)
+			for topic, partitions := range requested[leader] {
+				retry[topic] = append(retry[topic], partitions...)
+			}
+			continue
+		}
+
+		for topic, partitions := range requested[leader] {
+			for _, partition := range partitions {
+				block := resp.GetBlock(topic, partition)
+				switch {
+				case block == nil:
+					retry[topic] = append(retry[topic], partition)
+				case block.Err == sarama.ErrNotLeaderForPartition,
+					block.Err == sarama.ErrLeaderNotAvailable,
+					block.Err == sarama.ErrUnknownTopicOrPartition:
+					// leader changed since the metadata has been fetched
+					retry[topic] = append(retry[topic], partition)
+				case block.Err != sarama.ErrNoError || len(block.Offsets) == 0:
+					debugf("failed to query offset of %v:%v: %v", topic, partition, block.Err)
+				default:
+					if offsets[topic] == nil {
+						offsets[topic] = map[int32]int64{}
+					}
+					offsets[topic][partition] = block.Offsets[0]
+				}
+			}
+		}
+	}
+
+	return retry, nil
+}
+
+// leaderOffsets sends the offset request to a partition leader. The
+// connection of the broker is reused if it is the leader.
+func (b *Broker) leaderOffsets(
+	leader *sarama.Broker,
+	requ *sarama.OffsetRequest,
+) (*sarama.OffsetResponse, error) {
+	if leader.ID() == b.ID() {
+		return b.broker.GetAvailableOffsets(requ)
+	}
+
+	if err := leader.Open(b.cfg); err != nil {
+		return nil, err
+	}
+	defer closeBroker(leader)
+	return leader.GetAvailableOffsets(requ)
+}
+
 // ListGroups lists all groups managed by the broker. Other consumer
 // groups might be managed by other brokers.
 func (b *Broker) ListGroups() ([]string, error) {
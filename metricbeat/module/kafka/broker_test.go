@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchHighWaterMarks(t *testing.T) {
+	leader1 := sarama.NewMockBroker(t, 1)
+	defer leader1.Close()
+	leader2 := sarama.NewMockBroker(t, 2)
+	defer leader2.Close()
+
+	metadata := func(leaderOf1 int32) *sarama.MockMetadataResponse {
+		return sarama.NewMockMetadataResponse(t).
+			SetBroker(leader1.Addr(), leader1.BrokerID()).
+			SetBroker(leader2.Addr(), leader2.BrokerID()).
+			SetLeader("topic", 0, 1).
+			SetLeader("topic", 1, leaderOf1)
+	}
+
+	// partition 1 moves to broker 2 after the first metadata request
+	notLeader := &sarama.OffsetResponse{}
+	notLeader.AddTopicPartition("topic", 0, 10)
+	notLeader.AddTopicPartition("topic", 1, -1)
+	notLeader.GetBlock("topic", 1).Err = sarama.ErrNotLeaderForPartition
+
+	leader1.SetHandlerByMap(map[string]sarama.MockResponse{
+		// the first request is sent on connect to find the broker ID
+		"MetadataRequest": sarama.NewMockSequence(metadata(1), metadata(1), metadata(2)),
+		"OffsetRequest": sarama.NewMockSequence(
+			sarama.NewMockWrapper(notLeader),
+		),
+	})
+	leader2.SetHandlerByMap(map[string]sarama.MockResponse{
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("topic", 1, sarama.OffsetNewest, 20),
+	})
+
+	b := NewBroker(leader1.Addr(), BrokerSettings{
+		MatchID:     true,
+		ClientID:    "test",
+		DialTimeout: time.Second,
+		ReadTimeout: time.Second,
+		Retries:     3,
+		Backoff:     time.Millisecond,
+	})
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	offsets, err := b.FetchHighWaterMarks(map[string][]int32{
+		"topic": {0, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]map[int32]int64{
+		"topic": {0: 10, 1: 20},
+	}, offsets)
+}
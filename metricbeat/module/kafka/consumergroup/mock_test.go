@@ -10,9 +10,10 @@ import (
 )
 
 type mockClient struct {
-	listGroups        func() ([]string, error)
-	describeGroups    func(group []string) (map[string]kafka.GroupDescription, error)
-	fetchGroupOffsets func(group string) (*sarama.OffsetFetchResponse, error)
+	listGroups          func() ([]string, error)
+	describeGroups      func(group []string) (map[string]kafka.GroupDescription, error)
+	fetchGroupOffsets   func(group string) (*sarama.OffsetFetchResponse, error)
+	fetchHighWaterMarks func(topics map[string][]int32) (map[string]map[int32]int64, error)
 }
 
 type mockState struct {
@@ -21,13 +22,17 @@ type mockState struct {
 
 	// groups->client->topic->partitions ids
 	groups map[string][]map[string][]int32 // group/client assignments to topics and partition IDs
+
+	// topics -> partitions -> high water mark
+	highWaterMarks map[string][]int64
 }
 
 func defaultMockClient(state mockState) *mockClient {
 	return &mockClient{
-		listGroups:        makeListGroups(state),
-		describeGroups:    makeDescribeGroups(state),
-		fetchGroupOffsets: makeFetchGroupOffsets(state),
+		listGroups:          makeListGroups(state),
+		describeGroups:      makeDescribeGroups(state),
+		fetchGroupOffsets:   makeFetchGroupOffsets(state),
+		fetchHighWaterMarks: makeFetchHighWaterMarks(state),
 	}
 }
 
@@ -121,6 +126,35 @@ func makeFetchGroupOffsetsFail(
 	}
 }
 
+func makeFetchHighWaterMarks(
+	state mockState,
+) func(map[string][]int32) (map[string]map[int32]int64, error) {
+	return func(topics map[string][]int32) (map[string]map[int32]int64, error) {
+		ret := map[string]map[int32]int64{}
+		for topic, partitions := range topics {
+			hwms := state.highWaterMarks[topic]
+			for _, partition := range partitions {
+				if int(partition) >= len(hwms) {
+					continue
+				}
+				if ret[topic] == nil {
+					ret[topic] = map[int32]int64{}
+				}
+				ret[topic][partition] = hwms[partition]
+			}
+		}
+		return ret, nil
+	}
+}
+
+func makeFetchHighWaterMarksFail(
+	err error,
+) func(map[string][]int32) (map[string]map[int32]int64, error) {
+	return func(_ map[string][]int32) (map[string]map[int32]int64, error) {
+		return nil, err
+	}
+}
+
 func (c *mockClient) ListGroups() ([]string, error) { return c.listGroups() }
 func (c *mockClient) DescribeGroups(groups []string) (map[string]kafka.GroupDescription, error) {
 	return c.describeGroups(groups)
@@ -128,3 +162,6 @@ func (c *mockClient) DescribeGroups(groups []string) (map[string]kafka.GroupDesc
 func (c *mockClient) FetchGroupOffsets(group string) (*sarama.OffsetFetchResponse, error) {
 	return c.fetchGroupOffsets(group)
 }
+func (c *mockClient) FetchHighWaterMarks(topics map[string][]int32) (map[string]map[int32]int64, error) {
+	return c.fetchHighWaterMarks(topics)
+}
@@ -13,6 +13,7 @@ type client interface {
 	ListGroups() ([]string, error)
 	DescribeGroups(group []string) (map[string]kafka.GroupDescription, error)
 	FetchGroupOffsets(group string) (*sarama.OffsetFetchResponse, error)
+	FetchHighWaterMarks(topics map[string][]int32) (map[string]map[int32]int64, error)
 }
 
 func fetchGroupInfo(
@@ -67,6 +68,7 @@ func fetchGroupInfo(
 		return err
 	}
 
+	var offsets []result
 	for ret := range results {
 		if err := ret.err; err != nil {
 			// wait for workers to stop and drop results
@@ -74,6 +76,18 @@ func fetchGroupInfo(
 			}
 			return err
 		}
+		offsets = append(offsets, ret)
+	}
+
+	responses := make([]*sarama.OffsetFetchResponse, 0, len(offsets))
+	for _, ret := range offsets {
+		responses = append(responses, ret.off)
+	}
+	highWaterMarks := fetchHighWaterMarks(b, responses)
+
+	for _, ret := range offsets {
+		var groupLag int64
+		groupPartitions := 0
 
 		asgnGroup := assignments[ret.group]
 		for topic, partitions := range ret.off.Blocks {
@@ -82,6 +96,9 @@ func fetchGroupInfo(
 				asgnTopic = asgnGroup[topic]
 			}
 
+			var topicLag int64
+			lagPartitions := 0
+
 			for partition, info := range partitions {
 				event := common.MapStr{
 					"id":        ret.group,
@@ -104,14 +121,94 @@ func fetchGroupInfo(
 					}
 				}
 
+				if lag, ok := partitionLag(highWaterMarks, topic, partition, info.Offset); ok {
+					event["lag"] = lag
+					topicLag += lag
+					lagPartitions++
+				}
+
 				emit(event)
 			}
+
+			// report the lag of the group per topic
+			if lagPartitions > 0 {
+				emit(common.MapStr{
+					"id":         ret.group,
+					"topic":      topic,
+					"topic_lag":  topicLag,
+					"partitions": lagPartitions,
+				})
+				groupLag += topicLag
+				groupPartitions += lagPartitions
+			}
+		}
+
+		// report the lag of the group over all topics
+		if groupPartitions > 0 {
+			emit(common.MapStr{
+				"id":         ret.group,
+				"group_lag":  groupLag,
+				"partitions": groupPartitions,
+			})
 		}
 	}
 
 	return nil
 }
 
+// fetchHighWaterMarks fetches the newest offsets of all partitions the groups
+// have committed offsets for. If fetching fails, the offsets found so far are
+// returned, so the lag of the other partitions is not reported.
+func fetchHighWaterMarks(
+	b client,
+	responses []*sarama.OffsetFetchResponse,
+) map[string]map[int32]int64 {
+	partitions := map[string][]int32{}
+	known := map[string]map[int32]bool{}
+	for _, resp := range responses {
+		for topic, blocks := range resp.Blocks {
+			if known[topic] == nil {
+				known[topic] = map[int32]bool{}
+			}
+			for partition := range blocks {
+				if !known[topic][partition] {
+					known[topic][partition] = true
+					partitions[topic] = append(partitions[topic], partition)
+				}
+			}
+		}
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	highWaterMarks, err := b.FetchHighWaterMarks(partitions)
+	if err != nil {
+		logp.Err("failed to fetch kafka partition offsets: %v", err)
+	}
+	return highWaterMarks
+}
+
+// partitionLag computes the number of messages not consumed by a group yet.
+// No lag is reported if the group has not committed an offset yet.
+func partitionLag(
+	highWaterMarks map[string]map[int32]int64,
+	topic string,
+	partition int32,
+	offset int64,
+) (int64, bool) {
+	hwm, found := highWaterMarks[topic][partition]
+	if !found || offset < 0 {
+		return 0, false
+	}
+
+	// offsets committed after the high water mark has been fetched
+	if offset > hwm {
+		return 0, true
+	}
+	return hwm - offset, true
+}
+
 func listGroups(b client, filter func(string) bool) ([]string, error) {
 	groups, err := b.ListGroups()
 	if err != nil {
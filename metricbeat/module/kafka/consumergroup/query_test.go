@@ -119,6 +119,124 @@ func TestFetchGroupInfo(t *testing.T) {
 			},
 		},
 
+		{
+			name: "report lag",
+			client: defaultMockClient(mockState{
+				partitions: map[string]map[string][]int64{
+					"group1": {
+						"topic1": {10, 11, -1},
+						"topic2": {5},
+					},
+				},
+				groups: map[string][]map[string][]int32{
+					"group1": {
+						{"topic1": {0, 1, 2}, "topic2": {0}},
+					},
+				},
+				highWaterMarks: map[string][]int64{
+					"topic1": {15, 11, 20},
+				},
+			}),
+			expected: []common.MapStr{
+				testEvent("group1", "topic1", 0, common.MapStr{
+					"offset": int64(10),
+					"lag":    int64(5),
+				}),
+				testEvent("group1", "topic1", 1, common.MapStr{
+					"offset": int64(11),
+					"lag":    int64(0),
+				}),
+				testEvent("group1", "topic1", 2, common.MapStr{
+					"offset": int64(-1),
+				}),
+				testEvent("group1", "topic2", 0, common.MapStr{
+					"offset": int64(5),
+				}),
+				{
+					"id":         "group1",
+					"topic":      "topic1",
+					"topic_lag":  int64(5),
+					"partitions": 2,
+				},
+				{
+					"id":         "group1",
+					"group_lag":  int64(5),
+					"partitions": 2,
+				},
+			},
+			validate: func(events []common.MapStr) {
+				// 4 partitions, the lag of topic1 and the lag of group1, topic2
+				// has no high water marks
+				assert.Len(t, events, 6)
+				for _, event := range events {
+					if event["partition"] == int32(2) || event["topic"] == "topic2" || event["partition"] == nil {
+						assert.NotContains(t, event, "lag")
+					}
+					if event["partition"] != nil {
+						assert.NotContains(t, event, "topic_lag")
+						assert.NotContains(t, event, "group_lag")
+					}
+				}
+			},
+		},
+
+		{
+			name: "report lag per topic and group",
+			client: defaultMockClient(mockState{
+				partitions: map[string]map[string][]int64{
+					"group1": {
+						"topic1": {10, 11},
+						"topic2": {5},
+					},
+					"group2": {
+						"topic1": {15, 1},
+					},
+				},
+				groups: map[string][]map[string][]int32{
+					"group1": {{"topic1": {0, 1}, "topic2": {0}}},
+					"group2": {{"topic1": {0, 1}}},
+				},
+				highWaterMarks: map[string][]int64{
+					"topic1": {15, 21},
+					"topic2": {8},
+				},
+			}),
+			expected: []common.MapStr{
+				{"id": "group1", "topic": "topic1", "topic_lag": int64(15), "partitions": 2},
+				{"id": "group1", "topic": "topic2", "topic_lag": int64(3), "partitions": 1},
+				{"id": "group1", "group_lag": int64(18), "partitions": 3},
+				{"id": "group2", "topic": "topic1", "topic_lag": int64(20), "partitions": 2},
+				{"id": "group2", "group_lag": int64(20), "partitions": 2},
+			},
+			validate: func(events []common.MapStr) {
+				// 5 partitions, 3 topic and 2 group lags
+				assert.Len(t, events, 10)
+			},
+		},
+
+		{
+			name: "report offsets if high water marks are not available",
+			client: defaultMockClient(mockState{
+				partitions: map[string]map[string][]int64{
+					"group1": {"topic1": {1}},
+				},
+				groups: map[string][]map[string][]int32{
+					"group1": {{"topic1": {0}}},
+				},
+			}).with(func(c *mockClient) {
+				c.fetchHighWaterMarks = makeFetchHighWaterMarksFail(io.EOF)
+			}),
+			expected: []common.MapStr{
+				testEvent("group1", "topic1", 0, common.MapStr{
+					"offset": int64(1),
+				}),
+			},
+			validate: func(events []common.MapStr) {
+				assert.Len(t, events, 1)
+				assert.NotContains(t, events[0], "lag")
+			},
+		},
+
 		{
 			name:     "no events on empty group",
 			client:   defaultMockClient(mockState{}),
@@ -1,6 +1,13 @@
 package collector
 
 import (
+	"fmt"
+	"io"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/metricbeat/helper"
@@ -11,6 +18,10 @@ import (
 const (
 	defaultScheme = "http"
 	defaultPath   = "/metrics"
+
+	// acceptHeader prefers the protobuf format, falling back to the text
+	// format, like the Prometheus server does.
+	acceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
 )
 
 var (
@@ -31,66 +42,104 @@ type MetricSet struct {
 	mb.BaseMetricSet
 	http      *helper.HTTP
 	namespace string
+	filters   metricsFilters
+	rules     []*relabelRule
 }
 
 func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
 	logp.Warn("BETA: The prometheus collector metricset is beta")
 
-	config := struct {
-		Namespace string `config:"namespace" validate:"required"`
-	}{}
+	config := config{}
 	err := base.Module().UnpackConfig(&config)
 	if err != nil {
 		return nil, err
 	}
 
+	rules, err := newRelabelRules(config.Relabel)
+	if err != nil {
+		return nil, err
+	}
+
+	http, err := NewHTTP(base)
+	if err != nil {
+		return nil, err
+	}
+
 	return &MetricSet{
 		BaseMetricSet: base,
-		http:          helper.NewHTTP(base),
+		http:          http,
 		namespace:     config.Namespace,
+		filters:       config.MetricsFilters,
+		rules:         rules,
 	}, nil
 }
 
 func (m *MetricSet) Fetch() ([]common.MapStr, error) {
+	families, err := FetchFamilies(m.http, m.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	g := familyEvents{filters: m.filters, rules: m.rules}
+	events := g.group(families)
+	for _, e := range events {
+		e["_namespace"] = m.namespace
+	}
+
+	return events, nil
+}
 
-	scanner, err := m.http.FetchScanner()
+// NewHTTP returns the HTTP helper for fetching the metrics of a Prometheus
+// exporter with FetchFamilies.
+func NewHTTP(base mb.BaseMetricSet) (*helper.HTTP, error) {
+	http, err := helper.NewHTTP(base)
 	if err != nil {
 		return nil, err
 	}
-	eventList := map[string]common.MapStr{}
-
-	// Iterate through all events to gather data
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Skip comment lines
-		if line[0] == '#' {
-			continue
-		}
+	http.SetHeader("Accept", acceptHeader)
+	return http, nil
+}
 
-		promEvent := NewPromEvent(line)
-		if promEvent.value == nil {
-			continue
-		}
+// FetchFamilies fetches the metric families in the format chosen by the
+// exporter, the protobuf or the text format. The families are sorted by name.
+// The name of the MetricSet is used in the error messages.
+func FetchFamilies(http *helper.HTTP, name string) ([]*dto.MetricFamily, error) {
+	resp, err := http.FetchResponse()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-		// If MapString for this label group does not exist yet, it is created
-		if _, ok := eventList[promEvent.labelHash]; !ok {
-			eventList[promEvent.labelHash] = common.MapStr{}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP error %d in %s: %s", resp.StatusCode, name, resp.Status)
+	}
 
-			// Add labels
-			if len(promEvent.labels) > 0 {
-				eventList[promEvent.labelHash]["label"] = promEvent.labels
+	decoder := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+	families := []*dto.MetricFamily{}
+	for {
+		family := &dto.MetricFamily{}
+		if err := decoder.Decode(family); err != nil {
+			if err == io.EOF {
+				break
 			}
-
+			return nil, fmt.Errorf("error decoding prometheus metrics: %v", err)
 		}
-		eventList[promEvent.labelHash][promEvent.key] = promEvent.value
+		families = append(families, family)
 	}
 
-	// Converts hash list to slice
-	events := []common.MapStr{}
-	for _, e := range eventList {
-		e["_namespace"] = m.namespace
-		events = append(events, e)
-	}
+	sort.Sort(byName(families))
+	return families, nil
+}
 
-	return events, err
+// GroupFamilies returns one event per label set of the families, like the
+// collector MetricSet does without metrics filters and relabel rules.
+func GroupFamilies(families []*dto.MetricFamily) []common.MapStr {
+	g := familyEvents{}
+	return g.group(families)
 }
+
+type byName []*dto.MetricFamily
+
+func (f byName) Len() int           { return len(f) }
+func (f byName) Less(i, j int) bool { return f[i].GetName() < f[j].GetName() }
+func (f byName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
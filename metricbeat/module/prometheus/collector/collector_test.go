@@ -3,73 +3,205 @@
 package collector
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
 )
 
-func TestDecodeLine(t *testing.T) {
-	tests := []struct {
-		Line  string
-		Event PromEvent
-	}{
+const testMetrics = `# HELP go_goroutines Number of goroutines.
+# TYPE go_goroutines gauge
+go_goroutines 12
+# HELP http_requests_total The number of requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",handler="query"} 10
+http_requests_total{code="500",handler="query"} 2
+# HELP http_request_duration_seconds The request latencies.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{handler="query",le="0.1"} 3
+http_request_duration_seconds_bucket{handler="query",le="0.5"} 8
+http_request_duration_seconds_bucket{handler="query",le="+Inf"} 12
+http_request_duration_seconds_sum{handler="query"} 4.5
+http_request_duration_seconds_count{handler="query"} 12
+# HELP rpc_duration_seconds The RPC latencies.
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.25
+rpc_duration_seconds{quantile="0.99"} NaN
+rpc_duration_seconds_sum 7.5
+rpc_duration_seconds_count 30
+`
+
+func TestFetch(t *testing.T) {
+	expected := []common.MapStr{
 		{
-			Line: `http_request_duration_microseconds{handler="query",quantile="0.99"} 17`,
-			Event: PromEvent{
-				key:       "http_request_duration_microseconds",
-				value:     int64(17),
-				labelHash: `handler="query",quantile="0.99"`,
-				labels: common.MapStr{
-					"handler":  "query",
-					"quantile": 0.99,
+			"_namespace":    "test",
+			"go_goroutines": int64(12),
+			"rpc_duration_seconds": common.MapStr{
+				"count": int64(30),
+				"sum":   7.5,
+				"quantiles": []common.MapStr{
+					{"quantile": 0.5, "value": 0.25},
 				},
 			},
 		},
 		{
-			Line: `http_request_duration_microseconds{handler="query",quantile="0.99"} NaN`,
-			Event: PromEvent{
-				key:       "http_request_duration_microseconds",
-				value:     nil,
-				labelHash: `handler="query",quantile="0.99"`,
-				labels: common.MapStr{
-					"handler":  "query",
-					"quantile": 0.99,
+			"_namespace": "test",
+			"label":      common.MapStr{"handler": "query"},
+			"http_request_duration_seconds": common.MapStr{
+				"count": int64(12),
+				"sum":   4.5,
+				"buckets": []common.MapStr{
+					{"le": 0.1, "count": int64(3)},
+					{"le": 0.5, "count": int64(8)},
 				},
 			},
 		},
 		{
-			Line: `http_request_duration_microseconds{handler="query",quantile="0.99"} 13.2`,
-			Event: PromEvent{
-				key:       "http_request_duration_microseconds",
-				value:     13.2,
-				labelHash: `handler="query",quantile="0.99"`,
-				labels: common.MapStr{
-					"handler":  "query",
-					"quantile": 0.99,
-				},
+			"_namespace":          "test",
+			"label":               common.MapStr{"code": int64(200), "handler": "query"},
+			"http_requests_total": int64(10),
+		},
+		{
+			"_namespace":          "test",
+			"label":               common.MapStr{"code": int64(500), "handler": "query"},
+			"http_requests_total": int64(2),
+		},
+	}
+
+	formats := []expfmt.Format{expfmt.FmtText, expfmt.FmtProtoDelim}
+	for _, format := range formats {
+		server := newTestServer(t, testMetrics, format)
+		f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+			"module":     "prometheus",
+			"metricsets": []string{"collector"},
+			"hosts":      []string{server.URL},
+			"namespace":  "test",
+		})
+
+		events, err := f.Fetch()
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, events, "format: %v", format)
+	}
+}
+
+func TestFetchFilters(t *testing.T) {
+	server := newTestServer(t, testMetrics, expfmt.FmtText)
+	defer server.Close()
+
+	f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+		"module":     "prometheus",
+		"metricsets": []string{"collector"},
+		"hosts":      []string{server.URL},
+		"namespace":  "test",
+		"metrics_filters": map[string]interface{}{
+			"include": []string{"^http_"},
+			"exclude": []string{"_seconds$"},
+		},
+		"relabel": []map[string]interface{}{
+			{
+				"source_labels": []string{"code"},
+				"regex":         "5..",
+				"action":        "drop",
+			},
+			{
+				"source_labels": []string{"handler", "code"},
+				"regex":         "(.*);(.*)",
+				"replacement":   "$1-$2",
+				"target_label":  "route",
+			},
+			{
+				"source_labels": []string{"__name__"},
+				"regex":         "http_(.*)",
+				"target_label":  "__name__",
 			},
 		},
+	})
+
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.MapStr{
 		{
-			Line: `apiserver_request_count{client="Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/56.0.2924.87 Safari/537.36",code="200",contentType="",resource="elasticsearchclusters",verb="LIST"} 1`,
-			Event: PromEvent{
-				key:       "apiserver_request_count",
-				value:     int64(1),
-				labelHash: `client="Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/56.0.2924.87 Safari/537.36",code="200",contentType="",resource="elasticsearchclusters",verb="LIST"`,
-				labels: common.MapStr{
-					"client":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/56.0.2924.87 Safari/537.36",
-					"code":        int64(200),
-					"contentType": "",
-					"resource":    "elasticsearchclusters",
-					"verb":        "LIST",
-				},
+			"_namespace": "test",
+			"label": common.MapStr{
+				"code":    int64(200),
+				"handler": "query",
+				"route":   "query-200",
 			},
+			"requests_total": int64(10),
+		},
+	}, events)
+}
+
+func TestFetchInvalidRelabel(t *testing.T) {
+	configs := []map[string]interface{}{
+		{"action": "replace"},
+		{"action": "unknown"},
+		{"action": "drop", "regex": "("},
+	}
+
+	for _, relabel := range configs {
+		c, err := common.NewConfigFrom(map[string]interface{}{
+			"module":     "prometheus",
+			"metricsets": []string{"collector"},
+			"hosts":      []string{"localhost"},
+			"namespace":  "test",
+			"relabel":    []interface{}{relabel},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = mb.NewModules([]*common.Config{c}, mb.Registry)
+		assert.Error(t, err, "relabel: %v", relabel)
+	}
+}
+
+func TestNewInvalidSSL(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"module":     "prometheus",
+		"metricsets": []string{"collector"},
+		"hosts":      []string{"localhost"},
+		"namespace":  "test",
+		"ssl": map[string]interface{}{
+			"certificate_authorities": []string{"/nonexistent/ca.pem"},
 		},
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	for _, test := range tests {
-		event := NewPromEvent(test.Line)
-		assert.Equal(t, event, test.Event)
+	_, err = mb.NewModules([]*common.Config{c}, mb.Registry)
+	assert.Error(t, err)
+}
+
+func newTestServer(t *testing.T, text string, format expfmt.Format) *httptest.Server {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
 	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/metrics", r.URL.Path)
+		assert.Contains(t, r.Header.Get("Accept"), expfmt.ProtoType)
+
+		w.Header().Set("Content-Type", string(format))
+		encoder := expfmt.NewEncoder(w, format)
+		for _, family := range families {
+			encoder.Encode(family)
+		}
+	}))
 }
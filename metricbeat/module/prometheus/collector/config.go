@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/match"
+)
+
+type config struct {
+	Namespace      string           `config:"namespace" validate:"required"`
+	MetricsFilters metricsFilters   `config:"metrics_filters"`
+	Relabel        []*common.Config `config:"relabel"`
+}
+
+// metricsFilters selects the metric families by name. If include is set, only
+// the families matching one of its patterns are reported. Families matching
+// one of the exclude patterns are dropped.
+type metricsFilters struct {
+	Include []match.Matcher `config:"include"`
+	Exclude []match.Matcher `config:"exclude"`
+}
+
+func (f *metricsFilters) match(name string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, name)
+}
+
+func matchAny(matchers []match.Matcher, name string) bool {
+	for _, m := range matchers {
+		if m.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	relabelReplace = "replace"
+	relabelKeep    = "keep"
+	relabelDrop    = "drop"
+)
+
+// relabelConfig is a relabel rule applied to the labels of every metric, like
+// the relabel_configs of Prometheus. The metric name is available as the
+// __name__ label.
+type relabelConfig struct {
+	SourceLabels []string `config:"source_labels"`
+	Separator    string   `config:"separator"`
+	Regex        string   `config:"regex"`
+	TargetLabel  string   `config:"target_label"`
+	Replacement  string   `config:"replacement"`
+	Action       string   `config:"action"`
+}
+
+var defaultRelabelConfig = relabelConfig{
+	Separator:   ";",
+	Regex:       "(.*)",
+	Replacement: "$1",
+	Action:      relabelReplace,
+}
+
+func (c *relabelConfig) Validate() error {
+	switch c.Action {
+	case relabelReplace:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("relabel action 'replace' requires a target_label")
+		}
+	case relabelKeep, relabelDrop:
+	default:
+		return fmt.Errorf("unknown relabel action '%v'", c.Action)
+	}
+	return nil
+}
+
+// relabelRule is a compiled relabel rule.
+type relabelRule struct {
+	relabelConfig
+	regex *regexp.Regexp
+}
+
+func (c relabelConfig) compile() (*relabelRule, error) {
+	// the regular expression has to match the whole value, like in Prometheus
+	regex, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid relabel regex '%v': %v", c.Regex, err)
+	}
+	return &relabelRule{relabelConfig: c, regex: regex}, nil
+}
+
+func newRelabelRules(configs []*common.Config) ([]*relabelRule, error) {
+	rules := make([]*relabelRule, 0, len(configs))
+	for _, cfg := range configs {
+		c := defaultRelabelConfig
+		if err := cfg.Unpack(&c); err != nil {
+			return nil, err
+		}
+
+		rule, err := c.compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// apply applies the rule to the labels. It returns false if the metric is
+// dropped.
+func (r *relabelRule) apply(labels map[string]string) bool {
+	values := make([]string, 0, len(r.SourceLabels))
+	for _, name := range r.SourceLabels {
+		values = append(values, labels[name])
+	}
+	value := strings.Join(values, r.Separator)
+
+	switch r.Action {
+	case relabelKeep:
+		return r.regex.MatchString(value)
+	case relabelDrop:
+		return !r.regex.MatchString(value)
+	}
+
+	match := r.regex.FindStringSubmatchIndex(value)
+	if match == nil {
+		return true
+	}
+
+	result := r.regex.ExpandString(nil, r.Replacement, value, match)
+	if len(result) == 0 {
+		delete(labels, r.TargetLabel)
+	} else {
+		labels[r.TargetLabel] = string(result)
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+// +build !integration
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelabelRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   relabelConfig
+		labels   map[string]string
+		keep     bool
+		expected map[string]string
+	}{
+		{
+			name: "keep matching",
+			config: relabelConfig{
+				SourceLabels: []string{"job"},
+				Regex:        "api|web",
+				Action:       relabelKeep,
+			},
+			labels:   map[string]string{"job": "api"},
+			keep:     true,
+			expected: map[string]string{"job": "api"},
+		},
+		{
+			name: "keep requires full match",
+			config: relabelConfig{
+				SourceLabels: []string{"job"},
+				Regex:        "api",
+				Action:       relabelKeep,
+			},
+			labels: map[string]string{"job": "api-backup"},
+			keep:   false,
+		},
+		{
+			name: "drop missing label",
+			config: relabelConfig{
+				SourceLabels: []string{"job"},
+				Regex:        "",
+				Action:       relabelDrop,
+			},
+			labels: map[string]string{"instance": "a"},
+			keep:   false,
+		},
+		{
+			name: "replace with default replacement",
+			config: relabelConfig{
+				SourceLabels: []string{"instance"},
+				Separator:    ";",
+				Regex:        "(.*):.*",
+				Replacement:  "$1",
+				TargetLabel:  "host",
+				Action:       relabelReplace,
+			},
+			labels:   map[string]string{"instance": "web:8080"},
+			keep:     true,
+			expected: map[string]string{"instance": "web:8080", "host": "web"},
+		},
+		{
+			name: "replace not matching",
+			config: relabelConfig{
+				SourceLabels: []string{"instance"},
+				Regex:        "(.*):.*",
+				Replacement:  "$1",
+				TargetLabel:  "host",
+				Action:       relabelReplace,
+			},
+			labels:   map[string]string{"instance": "web"},
+			keep:     true,
+			expected: map[string]string{"instance": "web"},
+		},
+		{
+			name: "replace with empty value removes label",
+			config: relabelConfig{
+				SourceLabels: []string{"instance"},
+				Regex:        ".*",
+				Replacement:  "",
+				TargetLabel:  "instance",
+				Action:       relabelReplace,
+			},
+			labels:   map[string]string{"instance": "web", "job": "api"},
+			keep:     true,
+			expected: map[string]string{"job": "api"},
+		},
+	}
+
+	for _, test := range tests {
+		rule, err := test.config.compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keep := rule.apply(test.labels)
+		assert.Equal(t, test.keep, keep, test.name)
+		if keep {
+			assert.Equal(t, test.expected, test.labels, test.name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+const nameLabel = "__name__"
+
+// familyEvents groups the metrics of the families by label set, reporting one
+// event per label set with the labels under `label`. Histograms and summaries
+// are reported as one structured value per label set, containing the count,
+// the sum and the buckets or quantiles. Families not matching the filters and
+// metrics dropped by the relabel rules are skipped.
+type familyEvents struct {
+	filters metricsFilters
+	rules   []*relabelRule
+
+	events []common.MapStr
+	index  map[string]common.MapStr
+}
+
+func (g *familyEvents) group(families []*dto.MetricFamily) []common.MapStr {
+	g.events = []common.MapStr{}
+	g.index = map[string]common.MapStr{}
+
+	for _, family := range families {
+		if !g.filters.match(family.GetName()) {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			value := metricValue(family.GetType(), metric)
+			if value == nil {
+				continue
+			}
+			g.add(family.GetName(), metric.GetLabel(), value)
+		}
+	}
+
+	return g.events
+}
+
+// add adds the value to the event of its label set, after relabeling.
+func (g *familyEvents) add(name string, pairs []*dto.LabelPair, value interface{}) {
+	labels := make(map[string]string, len(pairs)+1)
+	for _, pair := range pairs {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	labels[nameLabel] = name
+
+	for _, rule := range g.rules {
+		if !rule.apply(labels) {
+			return
+		}
+	}
+
+	name = labels[nameLabel]
+	delete(labels, nameLabel)
+	if name == "" {
+		return
+	}
+
+	key := labelsKey(labels)
+	event, found := g.index[key]
+	if !found {
+		event = common.MapStr{}
+		if len(labels) > 0 {
+			label := common.MapStr{}
+			for k, v := range labels {
+				label[k] = convertValue(v)
+			}
+			event["label"] = label
+		}
+		g.index[key] = event
+		g.events = append(g.events, event)
+	}
+	event[name] = value
+}
+
+func labelsKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+// metricValue returns the value of the metric, or nil if it has no value.
+func metricValue(typ dto.MetricType, metric *dto.Metric) interface{} {
+	switch typ {
+	case dto.MetricType_COUNTER:
+		return convertFloat(metric.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		return convertFloat(metric.GetGauge().GetValue())
+	case dto.MetricType_HISTOGRAM:
+		return histogramValue(metric.GetHistogram())
+	case dto.MetricType_SUMMARY:
+		return summaryValue(metric.GetSummary())
+	default:
+		return convertFloat(metric.GetUntyped().GetValue())
+	}
+}
+
+func histogramValue(histogram *dto.Histogram) interface{} {
+	if histogram == nil {
+		return nil
+	}
+
+	buckets := []common.MapStr{}
+	for _, bucket := range histogram.GetBucket() {
+		// the +Inf bucket equals the count
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		buckets = append(buckets, common.MapStr{
+			"le":    bucket.GetUpperBound(),
+			"count": int64(bucket.GetCumulativeCount()),
+		})
+	}
+
+	value := common.MapStr{
+		"count":   int64(histogram.GetSampleCount()),
+		"buckets": buckets,
+	}
+	if sum := histogram.GetSampleSum(); !math.IsNaN(sum) && !math.IsInf(sum, 0) {
+		value["sum"] = sum
+	}
+	return value
+}
+
+func summaryValue(summary *dto.Summary) interface{} {
+	if summary == nil {
+		return nil
+	}
+
+	quantiles := []common.MapStr{}
+	for _, quantile := range summary.GetQuantile() {
+		// quantiles without observations are NaN
+		value := quantile.GetValue()
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		quantiles = append(quantiles, common.MapStr{
+			"quantile": quantile.GetQuantile(),
+			"value":    value,
+		})
+	}
+
+	value := common.MapStr{
+		"count":     int64(summary.GetSampleCount()),
+		"quantiles": quantiles,
+	}
+	if sum := summary.GetSampleSum(); !math.IsNaN(sum) && !math.IsInf(sum, 0) {
+		value["sum"] = sum
+	}
+	return value
+}
+
+// convertFloat converts integral values to int, like convertValue. It returns
+// nil for NaN and infinite values, which can't be encoded as JSON.
+func convertFloat(f float64) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+	return f
+}
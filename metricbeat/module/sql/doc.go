@@ -0,0 +1,5 @@
+/*
+Package sql is a Metricbeat module that runs custom SQL queries against MySQL
+and PostgreSQL servers.
+*/
+package sql
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+)
+
+type config struct {
+	Driver  string        `config:"driver" validate:"required"`
+	Queries []queryConfig `config:"queries" validate:"required"`
+}
+
+func (c *config) Validate() error {
+	switch c.Driver {
+	case driverMySQL, driverPostgres:
+	default:
+		return fmt.Errorf("unsupported sql driver '%v', use '%v' or '%v'",
+			c.Driver, driverMySQL, driverPostgres)
+	}
+
+	names := map[string]bool{}
+	for _, q := range c.Queries {
+		if names[q.Name] {
+			return fmt.Errorf("duplicate sql query name '%v'", q.Name)
+		}
+		names[q.Name] = true
+	}
+	return nil
+}
+
+// queryConfig configures a query. Each row of the result is reported as one
+// event, unless the result is pivoted into one event, using the values of the
+// key column as field names for the values of the value column.
+type queryConfig struct {
+	Name  string `config:"name" validate:"required"`
+	Query string `config:"query" validate:"required"`
+
+	Pivot       bool   `config:"pivot"`
+	KeyColumn   string `config:"key_column"`
+	ValueColumn string `config:"value_column"`
+
+	// Period runs the query less often than the module. It is rounded up to
+	// a multiple of the module period.
+	Period time.Duration `config:"period"`
+	// Timeout defaults to the timeout of the module.
+	Timeout time.Duration `config:"timeout"`
+}
+
+func (c *queryConfig) Validate() error {
+	if !c.Pivot && (c.KeyColumn != "" || c.ValueColumn != "") {
+		return fmt.Errorf("key_column and value_column of sql query '%v' require pivot", c.Name)
+	}
+	if c.Period < 0 || c.Timeout < 0 {
+		return fmt.Errorf("period and timeout of sql query '%v' must be positive", c.Name)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// result is the result set of a query.
+type result struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// runQuery runs the query and returns its result set. If the query doesn't
+// finish within the timeout, an error is returned together with a channel
+// that is closed when the query finishes. The drivers can't cancel queries, so
+// the query keeps running in the background and its result is discarded.
+func runQuery(db *sql.DB, query string, timeout time.Duration) (*result, <-chan struct{}, error) {
+	type response struct {
+		res *result
+		err error
+	}
+
+	done := make(chan response, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		res, err := queryResult(db, query)
+		done <- response{res, err}
+	}()
+
+	select {
+	case resp := <-done:
+		return resp.res, nil, resp.err
+	case <-time.After(timeout):
+		return nil, finished, fmt.Errorf("timeout after %v", timeout)
+	}
+}
+
+func queryResult(db *sql.DB, query string) (*result, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		for i, value := range values {
+			values[i] = convertValue(value)
+		}
+		res.rows = append(res.rows, values)
+	}
+
+	return res, rows.Err()
+}
+
+// convertValue converts the values returned by the drivers. Text values,
+// like all values of the MySQL text protocol, are converted to numbers if
+// possible.
+func convertValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return convertString(string(v))
+	case string:
+		return convertString(v)
+	case time.Time:
+		return common.Time(v)
+	default:
+		return v
+	}
+}
+
+func convertString(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// rowEvents returns one event per row, containing the values of the columns
+// under data. NULL values are skipped.
+func rowEvents(name string, res *result) []common.MapStr {
+	events := make([]common.MapStr, 0, len(res.rows))
+	for _, row := range res.rows {
+		data := common.MapStr{}
+		for i, value := range row {
+			if value != nil {
+				data[res.columns[i]] = value
+			}
+		}
+
+		events = append(events, common.MapStr{
+			"name": name,
+			"data": data,
+		})
+	}
+	return events
+}
+
+// pivotEvent returns one event for the result set, containing the values of
+// the value column under data, by the values of the key column. The columns
+// default to the first and the second column.
+func pivotEvent(name string, res *result, keyColumn, valueColumn string) (common.MapStr, error) {
+	if len(res.columns) < 2 {
+		return nil, fmt.Errorf("pivot requires at least 2 columns, got %v", len(res.columns))
+	}
+
+	key, err := columnIndex(res.columns, keyColumn, 0)
+	if err != nil {
+		return nil, err
+	}
+	value, err := columnIndex(res.columns, valueColumn, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	data := common.MapStr{}
+	for _, row := range res.rows {
+		if row[key] == nil || row[value] == nil {
+			continue
+		}
+		data[fmt.Sprint(row[key])] = row[value]
+	}
+
+	return common.MapStr{
+		"name": name,
+		"data": data,
+	}, nil
+}
+
+func columnIndex(columns []string, name string, defaultIndex int) (int, error) {
+	if name == "" {
+		return defaultIndex, nil
+	}
+	for i, column := range columns {
+		if column == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("column '%v' not found", name)
+}
@@ -0,0 +1,170 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/joeshaw/multierror"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/mb"
+	"github.com/elastic/beats/metricbeat/module/mysql"
+	"github.com/elastic/beats/metricbeat/module/postgresql"
+)
+
+var (
+	debugf = logp.MakeDebug("sql-query")
+)
+
+func init() {
+	if err := mb.Registry.AddMetricSet("sql", "query", New, parseHost); err != nil {
+		panic(err)
+	}
+}
+
+// parseHost parses the host with the host parser of the module of the
+// driver. The mysql and postgresql modules register the drivers.
+func parseHost(mod mb.Module, host string) (mb.HostData, error) {
+	c := struct {
+		Driver string `config:"driver"`
+	}{}
+	if err := mod.UnpackConfig(&c); err != nil {
+		return mb.HostData{}, err
+	}
+
+	switch c.Driver {
+	case driverMySQL:
+		return mysql.ParseDSN(mod, host)
+	case driverPostgres:
+		return postgresql.ParseURL(mod, host)
+	}
+	return mb.HostData{}, fmt.Errorf("unsupported sql driver '%v'", c.Driver)
+}
+
+// MetricSet runs the configured queries.
+type MetricSet struct {
+	mb.BaseMetricSet
+	driver  string
+	queries []*query
+	db      *sql.DB
+}
+
+// query is a configured query, scheduled to run every n-th fetch.
+type query struct {
+	queryConfig
+	every   int
+	fetches int
+
+	// running is closed when the last run, which timed out, finishes. It is
+	// nil if no run is left in the background.
+	running <-chan struct{}
+}
+
+// New creates a new instance of the query MetricSet.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The sql query metricset is beta")
+
+	config := config{}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+
+	period := base.Module().Config().Period
+	queries := make([]*query, 0, len(config.Queries))
+	for _, c := range config.Queries {
+		q := &query{queryConfig: c, every: 1}
+		if c.Period > period {
+			q.every = int((c.Period + period - 1) / period)
+		}
+		if q.Timeout == 0 {
+			q.Timeout = base.Module().Config().Timeout
+		}
+		queries = append(queries, q)
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		driver:        config.Driver,
+		queries:       queries,
+	}, nil
+}
+
+// Fetch runs the queries due. Failing queries are logged, an error is only
+// returned if all queries fail.
+func (m *MetricSet) Fetch() ([]common.MapStr, error) {
+	if m.db == nil {
+		db, err := sql.Open(m.driver, m.HostData().URI)
+		if err != nil {
+			return nil, errors.Wrap(err, "sql open failed")
+		}
+		// Every query runs at most once at a time, so it never needs more
+		// connections than queries.
+		db.SetMaxOpenConns(len(m.queries))
+		m.db = db
+	}
+
+	events := []common.MapStr{}
+	var errs multierror.Errors
+	ran := 0
+	for _, q := range m.queries {
+		if !q.due() {
+			continue
+		}
+		ran++
+
+		start := time.Now()
+		queryEvents, err := q.run(m.db)
+		if err != nil {
+			err = errors.Wrapf(err, "sql query '%v' failed", q.Name)
+			logp.Err("%v", err)
+			errs = append(errs, err)
+			continue
+		}
+		debugf("sql query '%v' returned %v events in %v", q.Name, len(queryEvents), time.Since(start))
+		events = append(events, queryEvents...)
+	}
+
+	if ran > 0 && len(errs) == ran {
+		return nil, errs.Err()
+	}
+	return events, nil
+}
+
+// due reports whether the query runs in this fetch.
+func (q *query) due() bool {
+	due := q.fetches%q.every == 0
+	q.fetches++
+	return due
+}
+
+// run runs the query, unless a previous run that timed out is still running,
+// so that hanging queries don't pile up.
+func (q *query) run(db *sql.DB) ([]common.MapStr, error) {
+	if q.running != nil {
+		select {
+		case <-q.running:
+			q.running = nil
+		default:
+			return nil, fmt.Errorf("skipped, a previous run timed out and is still running")
+		}
+	}
+
+	res, running, err := runQuery(db, q.Query, q.Timeout)
+	if err != nil {
+		q.running = running
+		return nil, err
+	}
+
+	if !q.Pivot {
+		return rowEvents(q.Name, res), nil
+	}
+
+	event, err := pivotEvent(q.Name, res, q.KeyColumn, q.ValueColumn)
+	if err != nil {
+		return nil, err
+	}
+	return []common.MapStr{event}, nil
+}
@@ -0,0 +1,353 @@
+// +build !integration
+
+package query
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+)
+
+func TestFetchRows(t *testing.T) {
+	f := newTestFetcher(t, []map[string]interface{}{
+		{"name": "orders", "query": "SELECT status, count FROM orders"},
+	}, fakeResults{
+		"SELECT status, count FROM orders": {
+			columns: []string{"status", "count", "amount", "updated"},
+			rows: [][]driver.Value{
+				{[]byte("new"), []byte("3"), []byte("12.5"), nil},
+				{"paid", int64(7), 99.5, time.Unix(0, 0).UTC()},
+			},
+		},
+	})
+
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.MapStr{
+		{
+			"name": "orders",
+			"data": common.MapStr{"status": "new", "count": int64(3), "amount": 12.5},
+		},
+		{
+			"name": "orders",
+			"data": common.MapStr{
+				"status":  "paid",
+				"count":   int64(7),
+				"amount":  99.5,
+				"updated": common.Time(time.Unix(0, 0).UTC()),
+			},
+		},
+	}, events)
+}
+
+func TestFetchPivot(t *testing.T) {
+	f := newTestFetcher(t, []map[string]interface{}{
+		{"name": "status", "query": "SHOW STATUS", "pivot": true},
+		{
+			"name":         "settings",
+			"query":        "SELECT * FROM pg_settings",
+			"pivot":        true,
+			"key_column":   "name",
+			"value_column": "setting",
+		},
+	}, fakeResults{
+		"SHOW STATUS": {
+			columns: []string{"Variable_name", "Value"},
+			rows: [][]driver.Value{
+				{[]byte("Threads_connected"), []byte("5")},
+				{[]byte("Uptime"), []byte("3600")},
+				{[]byte("Ssl_cipher"), nil},
+			},
+		},
+		"SELECT * FROM pg_settings": {
+			columns: []string{"unit", "setting", "name"},
+			rows: [][]driver.Value{
+				{nil, []byte("100"), []byte("max_connections")},
+				{[]byte("8kB"), []byte("16384"), []byte("shared_buffers")},
+			},
+		},
+	})
+
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []common.MapStr{
+		{
+			"name": "status",
+			"data": common.MapStr{"Threads_connected": int64(5), "Uptime": int64(3600)},
+		},
+		{
+			"name": "settings",
+			"data": common.MapStr{"max_connections": int64(100), "shared_buffers": int64(16384)},
+		},
+	}, events)
+}
+
+func TestFetchQueryPeriod(t *testing.T) {
+	f := newTestFetcher(t, []map[string]interface{}{
+		{"name": "fast", "query": "SELECT 1 AS one"},
+		{"name": "slow", "query": "SELECT 2 AS two", "period": "25s"},
+	}, fakeResults{
+		"SELECT 1 AS one": {columns: []string{"one"}, rows: [][]driver.Value{{int64(1)}}},
+		"SELECT 2 AS two": {columns: []string{"two"}, rows: [][]driver.Value{{int64(2)}}},
+	})
+
+	// the module period is 10s, the slow query runs every third fetch
+	var names [][]string
+	for i := 0; i < 4; i++ {
+		events, err := f.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var fetched []string
+		for _, event := range events {
+			fetched = append(fetched, event["name"].(string))
+		}
+		names = append(names, fetched)
+	}
+
+	assert.Equal(t, [][]string{
+		{"fast", "slow"},
+		{"fast"},
+		{"fast"},
+		{"fast", "slow"},
+	}, names)
+}
+
+func TestFetchErrors(t *testing.T) {
+	results := fakeResults{
+		"SELECT 1 AS one": {columns: []string{"one"}, rows: [][]driver.Value{{int64(1)}}},
+		"SELECT broken":   {err: fmt.Errorf("syntax error")},
+		"SELECT sleep(1)": {columns: []string{"sleep"}, delay: time.Second},
+	}
+
+	// failing queries don't drop the events of other queries
+	f := newTestFetcher(t, []map[string]interface{}{
+		{"name": "broken", "query": "SELECT broken"},
+		{"name": "slow", "query": "SELECT sleep(1)", "timeout": "10ms"},
+		{"name": "one", "query": "SELECT 1 AS one"},
+	}, results)
+
+	events, err := f.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []common.MapStr{
+		{"name": "one", "data": common.MapStr{"one": int64(1)}},
+	}, events)
+
+	// an error is returned if all queries fail
+	f = newTestFetcher(t, []map[string]interface{}{
+		{"name": "broken", "query": "SELECT broken"},
+		{"name": "slow", "query": "SELECT sleep(1)", "timeout": "10ms"},
+	}, results)
+
+	_, err = f.Fetch()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sql query 'broken' failed: syntax error")
+		assert.Contains(t, err.Error(), "sql query 'slow' failed: timeout after 10ms")
+	}
+}
+
+func TestFetchSkipsRunningQuery(t *testing.T) {
+	results := fakeResults{
+		"SELECT sleep(1)": {columns: []string{"sleep"}, delay: 200 * time.Millisecond},
+	}
+	f := newTestFetcher(t, []map[string]interface{}{
+		{"name": "slow", "query": "SELECT sleep(1)", "timeout": "10ms"},
+	}, results)
+
+	_, err := f.Fetch()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sql query 'slow' failed: timeout after 10ms")
+	}
+
+	// the query is skipped while the timed out run is still running
+	_, err = f.Fetch()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sql query 'slow' failed: skipped")
+	}
+
+	// and runs again once it finished
+	time.Sleep(300 * time.Millisecond)
+	_, err = f.Fetch()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "sql query 'slow' failed: timeout after 10ms")
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		driver string
+		host   string
+		uri    string
+	}{
+		{
+			driver: "mysql",
+			host:   "tcp(127.0.0.1:3306)/shop",
+			uri:    "root:secret@tcp(127.0.0.1:3306)/shop?readTimeout=10s&timeout=10s&writeTimeout=10s",
+		},
+		{
+			driver: "postgres",
+			host:   "postgres://localhost:5432/shop?sslmode=disable",
+			uri:    "connect_timeout=10 dbname=shop host=localhost password=secret port=5432 sslmode=disable user=root",
+		},
+	}
+
+	for _, test := range tests {
+		f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+			"module":     "sql",
+			"metricsets": []string{"query"},
+			"hosts":      []string{test.host},
+			"username":   "root",
+			"password":   "secret",
+			"driver":     test.driver,
+			"queries":    []map[string]interface{}{{"name": "one", "query": "SELECT 1"}},
+		})
+		assert.Equal(t, test.uri, f.HostData().URI, test.driver)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	configs := []map[string]interface{}{
+		{"driver": "oracle"},
+		{"driver": "mysql", "queries": []map[string]interface{}{{"name": "q"}}},
+		{"driver": "mysql", "queries": []map[string]interface{}{
+			{"name": "q", "query": "SELECT 1"},
+			{"name": "q", "query": "SELECT 2"},
+		}},
+		{"driver": "mysql", "queries": []map[string]interface{}{
+			{"name": "q", "query": "SELECT 1", "key_column": "name"},
+		}},
+	}
+
+	for _, config := range configs {
+		config["module"] = "sql"
+		config["metricsets"] = []string{"query"}
+		config["hosts"] = []string{"tcp(127.0.0.1:3306)/"}
+		if _, found := config["queries"]; !found {
+			config["queries"] = []map[string]interface{}{{"name": "q", "query": "SELECT 1"}}
+		}
+
+		c, err := common.NewConfigFrom(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = mb.NewModules([]*common.Config{c}, mb.Registry)
+		assert.Error(t, err, "config: %v", config)
+	}
+}
+
+func newTestFetcher(t *testing.T, queries []map[string]interface{}, results fakeResults) mb.EventsFetcher {
+	f := mbtest.NewEventsFetcher(t, map[string]interface{}{
+		"module":     "sql",
+		"metricsets": []string{"query"},
+		"hosts":      []string{"tcp(127.0.0.1:3306)/"},
+		"period":     "10s",
+		"driver":     "mysql",
+		"queries":    queries,
+	})
+
+	db, err := sql.Open("sqlfake", registerFakeResults(results))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.(*MetricSet).db = db
+	return f
+}
+
+// The fake driver returns the results registered for a DSN, by query.
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	delay   time.Duration
+}
+
+type fakeResults map[string]fakeResult
+
+var fakeDSNs = map[string]fakeResults{}
+
+func registerFakeResults(results fakeResults) string {
+	dsn := fmt.Sprintf("fake-%v", len(fakeDSNs))
+	fakeDSNs[dsn] = results
+	return dsn
+}
+
+func init() {
+	sql.Register("sqlfake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	results, found := fakeDSNs[dsn]
+	if !found {
+		return nil, fmt.Errorf("unknown dsn %v", dsn)
+	}
+	return &fakeConn{results}, nil
+}
+
+type fakeConn struct {
+	results fakeResults
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	result, found := c.results[query]
+	if !found {
+		return nil, fmt.Errorf("unexpected query %v", query)
+	}
+	return &fakeStmt{result}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("not supported") }
+
+type fakeStmt struct {
+	result fakeResult
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	time.Sleep(s.result.delay)
+	if s.result.err != nil {
+		return nil, s.result.err
+	}
+	return &fakeRows{result: s.result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,5 @@
+/*
+Package statsd is a Metricbeat module that receives and aggregates metrics
+sent with the StatsD protocol.
+*/
+package statsd
@@ -0,0 +1,350 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// Metric types of the StatsD protocol.
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+	typeTimer   = "timer"
+	typeSet     = "set"
+)
+
+var metricTypes = map[string]string{
+	"c":  typeCounter,
+	"g":  typeGauge,
+	"ms": typeTimer,
+	"h":  typeTimer,
+	"s":  typeSet,
+}
+
+// metric is a single value parsed from a StatsD line.
+type metric struct {
+	name       string
+	tags       map[string]string
+	metricType string
+	value      string
+	sampleRate float64
+}
+
+// parseMetric parses a line of the form
+// `<name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,...]`. The tags
+// follow the DogStatsD extension of the protocol.
+func parseMetric(line string) (*metric, error) {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return nil, fmt.Errorf("invalid metric '%v': missing name", line)
+	}
+
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid metric '%v': expected <name>:<value>|<type>", line)
+	}
+
+	m := &metric{
+		name:       line[:colon],
+		value:      parts[0],
+		sampleRate: 1,
+	}
+
+	var found bool
+	if m.metricType, found = metricTypes[parts[1]]; !found {
+		return nil, fmt.Errorf("invalid metric '%v': unknown type '%v'", line, parts[1])
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf("invalid metric '%v': invalid sample rate '%v'", line, part[1:])
+			}
+			m.sampleRate = rate
+		case strings.HasPrefix(part, "#"):
+			m.tags = parseTags(part[1:])
+		default:
+			return nil, fmt.Errorf("invalid metric '%v': unknown field '%v'", line, part)
+		}
+	}
+
+	return m, nil
+}
+
+// parseTags parses DogStatsD tags. Tags without a value are set to an empty
+// string.
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		kv := strings.SplitN(tag, ":", 2)
+		if len(kv) == 2 {
+			tags[kv[0]] = kv[1]
+		} else {
+			tags[kv[0]] = ""
+		}
+	}
+	return tags
+}
+
+// series identifies an aggregated metric by its name, type and tags.
+type series struct {
+	name       string
+	metricType string
+	tags       map[string]string
+}
+
+func newSeries(m *metric) series {
+	return series{name: m.name, metricType: m.metricType, tags: m.tags}
+}
+
+// key returns a unique string for the series. The tags are sorted, so their
+// order in the line doesn't matter.
+func (s series) key() string {
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{s.metricType, s.name}
+	for _, k := range keys {
+		parts = append(parts, k+"="+s.tags[k])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (s series) event() common.MapStr {
+	event := common.MapStr{
+		"name": s.name,
+		"type": s.metricType,
+	}
+	if len(s.tags) > 0 {
+		tags := common.MapStr{}
+		for k, v := range s.tags {
+			tags[k] = v
+		}
+		event["tags"] = tags
+	}
+	return event
+}
+
+type counter struct {
+	series
+	value float64
+}
+
+type gauge struct {
+	series
+	value   float64
+	updated bool // received a value since the last flush
+	idle    int  // number of flushes without receiving a value
+}
+
+type timer struct {
+	series
+	count  float64
+	values []float64
+}
+
+type set struct {
+	series
+	values map[string]struct{}
+}
+
+// aggregator collects the metrics received within a flush interval.
+type aggregator struct {
+	percentiles []float64
+	gaugeIdle   int // flushes a gauge is reported without new values, 0 for no limit
+
+	mutex    sync.Mutex
+	counters map[string]*counter
+	gauges   map[string]*gauge
+	timers   map[string]*timer
+	sets     map[string]*set
+}
+
+func newAggregator(percentiles []float64, gaugeIdle int) *aggregator {
+	return &aggregator{
+		percentiles: percentiles,
+		gaugeIdle:   gaugeIdle,
+		counters:    map[string]*counter{},
+		gauges:      map[string]*gauge{},
+		timers:      map[string]*timer{},
+		sets:        map[string]*set{},
+	}
+}
+
+// add parses and aggregates a StatsD line.
+func (a *aggregator) add(line string) error {
+	m, err := parseMetric(line)
+	if err != nil {
+		return err
+	}
+
+	var value float64
+	if m.metricType != typeSet {
+		value, err = strconv.ParseFloat(m.value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid metric '%v': invalid value '%v'", line, m.value)
+		}
+	}
+
+	s := newSeries(m)
+	key := s.key()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	switch m.metricType {
+	case typeCounter:
+		c, found := a.counters[key]
+		if !found {
+			c = &counter{series: s}
+			a.counters[key] = c
+		}
+		c.value += value / m.sampleRate
+	case typeGauge:
+		g, found := a.gauges[key]
+		if !found {
+			g = &gauge{series: s}
+			a.gauges[key] = g
+		}
+		// A sign makes the value relative to the current value of the gauge.
+		if strings.HasPrefix(m.value, "+") || strings.HasPrefix(m.value, "-") {
+			g.value += value
+		} else {
+			g.value = value
+		}
+		g.updated = true
+	case typeTimer:
+		t, found := a.timers[key]
+		if !found {
+			t = &timer{series: s}
+			a.timers[key] = t
+		}
+		t.count += 1 / m.sampleRate
+		t.values = append(t.values, value)
+	case typeSet:
+		st, found := a.sets[key]
+		if !found {
+			st = &set{series: s, values: map[string]struct{}{}}
+			a.sets[key] = st
+		}
+		st.values[m.value] = struct{}{}
+	}
+	return nil
+}
+
+// flush returns an event per metric and resets the counters, timers and sets.
+// Gauges keep their value and are reported on every flush, until they did not
+// receive a value for more than gaugeIdle flushes. The interval is used to
+// compute the per second rates.
+func (a *aggregator) flush(interval time.Duration) []common.MapStr {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	seconds := interval.Seconds()
+	var events []common.MapStr
+
+	for _, c := range a.counters {
+		event := c.event()
+		event["value"] = c.value
+		event["rate"] = c.value / seconds
+		events = append(events, event)
+	}
+
+	for key, g := range a.gauges {
+		if !g.updated {
+			g.idle++
+		}
+		g.updated = false
+		if a.gaugeIdle > 0 && g.idle > a.gaugeIdle {
+			delete(a.gauges, key)
+			continue
+		}
+
+		event := g.event()
+		event["value"] = g.value
+		events = append(events, event)
+	}
+
+	for _, t := range a.timers {
+		event := t.event()
+		event["timer"] = timerStats(t, a.percentiles, seconds)
+		events = append(events, event)
+	}
+
+	for _, s := range a.sets {
+		event := s.event()
+		event["value"] = len(s.values)
+		events = append(events, event)
+	}
+
+	a.counters = map[string]*counter{}
+	a.timers = map[string]*timer{}
+	a.sets = map[string]*set{}
+	return events
+}
+
+func timerStats(t *timer, percentiles []float64, seconds float64) common.MapStr {
+	values := t.values
+	sort.Float64s(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	stats := common.MapStr{
+		"count":  t.count,
+		"rate":   t.count / seconds,
+		"sum":    sum,
+		"min":    values[0],
+		"max":    values[len(values)-1],
+		"mean":   mean,
+		"median": percentile(values, 50),
+		"stddev": math.Sqrt(variance),
+	}
+
+	if len(percentiles) > 0 {
+		p := common.MapStr{}
+		for _, pct := range percentiles {
+			p[percentileName(pct)] = percentile(values, pct)
+		}
+		stats["percentile"] = p
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []float64, pct float64) float64 {
+	rank := int(math.Ceil(pct / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// percentileName returns the field name of a percentile, like p95 or p99_9.
+func percentileName(pct float64) string {
+	return "p" + strings.Replace(strconv.FormatFloat(pct, 'f', -1, 64), ".", "_", -1)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/helper"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+const defaultPort = 8125
+
+func init() {
+	if err := mb.Registry.AddMetricSet("statsd", "server", New); err != nil {
+		panic(err)
+	}
+}
+
+// MetricSet receives metrics sent with the StatsD protocol and reports the
+// aggregated values once per period.
+type MetricSet struct {
+	mb.BaseMetricSet
+	server     *helper.Server
+	aggregator *aggregator
+}
+
+// New creates a new instance of the MetricSet. The listening socket is only
+// opened by Run.
+func New(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	logp.Warn("BETA: The statsd server metricset is beta")
+
+	config := struct {
+		Percentiles      []float64 `config:"percentiles"`
+		GaugeIdlePeriods int       `config:"gauge_idle_periods" validate:"min=0"`
+	}{
+		Percentiles:      []float64{90, 95, 99},
+		GaugeIdlePeriods: 10,
+	}
+	if err := base.Module().UnpackConfig(&config); err != nil {
+		return nil, err
+	}
+	for _, p := range config.Percentiles {
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile must be in (0, 100], but is %v", p)
+		}
+	}
+
+	server, err := helper.NewServer(base, "udp", defaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricSet{
+		BaseMetricSet: base,
+		server:        server,
+		aggregator:    newAggregator(config.Percentiles, config.GaugeIdlePeriods),
+	}, nil
+}
+
+// Run receives metrics until the done channel is closed. The aggregated
+// metrics are reported as one event per metric at the end of every period,
+// and once more when the MetricSet is stopped.
+func (m *MetricSet) Run(r mb.PushReporter, done <-chan struct{}) {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		err := m.server.Run(func(line []byte) {
+			if err := m.aggregator.add(string(line)); err != nil {
+				r.Error(err)
+			}
+		}, done)
+		if err != nil {
+			logp.Err("statsd server failed: %v", err)
+			r.Error(err)
+		}
+	}()
+
+	period := m.Module().Config().Period
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopped:
+			// report the metrics received since the last flush
+			for _, event := range m.aggregator.flush(period) {
+				if !r.Event(event) {
+					return
+				}
+			}
+			return
+		case <-ticker.C:
+			for _, event := range m.aggregator.flush(period) {
+				if !r.Event(event) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// +build !integration
+
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	mbtest "github.com/elastic/beats/metricbeat/mb/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetric(t *testing.T) {
+	m, err := parseMetric("api.requests:2|c|@0.5|#env:prod,canary")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &metric{
+			name:       "api.requests",
+			tags:       map[string]string{"env": "prod", "canary": ""},
+			metricType: typeCounter,
+			value:      "2",
+			sampleRate: 0.5,
+		}, m)
+	}
+
+	for _, line := range []string{
+		"api.requests",
+		":1|c",
+		"api.requests:1",
+		"api.requests:|c",
+		"api.requests:1|x",
+		"api.requests:1|c|@2",
+		"api.requests:1|c|foo",
+	} {
+		_, err := parseMetric(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	a := newAggregator([]float64{50, 99.9}, 0)
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"requests:1|c|#env:prod",
+		"queue:10|g",
+		"queue:-3|g",
+		"latency:30|ms",
+		"latency:10|ms",
+		"latency:20|ms",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	} {
+		if err := a.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Error(t, a.add("requests:one|c"))
+
+	events := indexEvents(a.flush(10 * time.Second))
+	assert.Len(t, events, 5)
+
+	assert.Equal(t, 5.0, events["counter requests"]["value"])
+	assert.Equal(t, 0.5, events["counter requests"]["rate"])
+	assert.Equal(t, 1.0, events["counter requests prod"]["value"])
+	assert.Equal(t, common.MapStr{"env": "prod"}, events["counter requests prod"]["tags"])
+	assert.Equal(t, 7.0, events["gauge queue"]["value"])
+	assert.Equal(t, 2, events["set users"]["value"])
+	assert.Equal(t, common.MapStr{
+		"count":  3.0,
+		"rate":   0.3,
+		"sum":    60.0,
+		"min":    10.0,
+		"max":    30.0,
+		"mean":   20.0,
+		"median": 20.0,
+		"stddev": 8.16496580927726,
+		"percentile": common.MapStr{
+			"p50":   20.0,
+			"p99_9": 30.0,
+		},
+	}, events["timer latency"]["timer"])
+
+	// Only gauges are reported again after a flush.
+	events = indexEvents(a.flush(10 * time.Second))
+	assert.Len(t, events, 1)
+	assert.Equal(t, 7.0, events["gauge queue"]["value"])
+}
+
+func TestAggregatorGaugeIdle(t *testing.T) {
+	a := newAggregator(nil, 2)
+	add := func(line string) {
+		if err := a.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("queue:10|g")
+	add("workers:3|g")
+	assert.Len(t, a.flush(time.Second), 2)
+
+	// gauges are reported for up to 2 periods without new values
+	add("workers:4|g")
+	assert.Len(t, a.flush(time.Second), 2)
+	assert.Len(t, a.flush(time.Second), 2)
+
+	events := indexEvents(a.flush(time.Second))
+	assert.Len(t, events, 1)
+	assert.Equal(t, 4.0, events["gauge workers"]["value"])
+
+	assert.Len(t, a.flush(time.Second), 0)
+
+	// dropped gauges start over when receiving a value again
+	add("queue:+1|g")
+	events = indexEvents(a.flush(time.Second))
+	assert.Equal(t, 1.0, events["gauge queue"]["value"])
+}
+
+func TestRunUDP(t *testing.T) {
+	ms := mbtest.NewPushMetricSet(t, map[string]interface{}{
+		"module":     "statsd",
+		"metricsets": []string{"server"},
+		"period":     "100ms",
+		"port":       0,
+	})
+
+	// The socket is only opened by Run.
+	assert.Nil(t, ms.(*MetricSet).server.Addr())
+
+	go send(t, ms.(*MetricSet), "requests:1|c\nrequests:2|c\ninvalid\n")
+
+	events, errs := mbtest.RunPushMetricSet(250*time.Millisecond, ms)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "requests", events[0]["name"])
+		assert.Equal(t, 3.0, events[0]["value"])
+	}
+	assert.Len(t, errs, 1)
+}
+
+func TestRunFlushesOnStop(t *testing.T) {
+	ms := mbtest.NewPushMetricSet(t, map[string]interface{}{
+		"module":     "statsd",
+		"metricsets": []string{"server"},
+		"period":     "1h",
+		"port":       0,
+	})
+
+	go send(t, ms.(*MetricSet), "requests:1|c\n")
+
+	events, errs := mbtest.RunPushMetricSet(250*time.Millisecond, ms)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "requests", events[0]["name"])
+		assert.Equal(t, 1.0, events[0]["value"])
+	}
+	assert.Len(t, errs, 0)
+}
+
+// send writes the data to the server of the MetricSet once it is listening.
+func send(t *testing.T, m *MetricSet, data string) {
+	var addr net.Addr
+	for i := 0; addr == nil; i++ {
+		if i == 100 {
+			t.Error("timeout waiting for the server to listen")
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		addr = m.server.Addr()
+	}
+
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(data)); err != nil {
+		t.Error(err)
+	}
+}
+
+// indexEvents indexes the events by type, name and tag values.
+func indexEvents(events []common.MapStr) map[string]common.MapStr {
+	index := map[string]common.MapStr{}
+	for _, event := range events {
+		key := event["type"].(string) + " " + event["name"].(string)
+		if tags, ok := event["tags"].(common.MapStr); ok {
+			for _, v := range tags {
+				key += " " + v.(string)
+			}
+		}
+		index[key] = event
+	}
+	return index
+}
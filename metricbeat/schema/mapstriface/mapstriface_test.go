@@ -20,6 +20,8 @@ func TestConversions(t *testing.T) {
 		"testIntFromInt32": int32(32),
 		"testIntFromInt64": int64(42),
 		"testJsonNumber":   json.Number("3910564293633576924"),
+		"testFloat":        4.2,
+		"testFloatFromInt": 42,
 		"testBool":         true,
 		"testObj": map[string]interface{}{
 			"testObjString": "hello, object",
@@ -40,6 +42,8 @@ func TestConversions(t *testing.T) {
 		"test_int_from_float":       Int("testIntFromFloat"),
 		"test_int_from_int64":       Int("testIntFromInt64"),
 		"test_int_from_json":        Int("testJsonNumber"),
+		"test_float":                Float("testFloat"),
+		"test_float_from_int":       Float("testFloatFromInt"),
 		"test_string_from_num":      StrFromNum("testIntFromInt32"),
 		"test_string_from_json_num": StrFromNum("testJsonNumber"),
 		"test_bool":                 Bool("testBool"),
@@ -51,6 +55,7 @@ func TestConversions(t *testing.T) {
 			"test": Str("testObjString"),
 		}),
 		"test_error_int":    Int("testErrorInt", s.Optional),
+		"test_error_float":  Float("testString", s.Optional),
 		"test_error_time":   Time("testErrorTime", s.Optional),
 		"test_error_bool":   Bool("testErrorBool", s.Optional),
 		"test_error_string": Str("testErrorString", s.Optional),
@@ -62,6 +67,8 @@ func TestConversions(t *testing.T) {
 		"test_int_from_float":       int64(42),
 		"test_int_from_int64":       int64(42),
 		"test_int_from_json":        int64(3910564293633576924),
+		"test_float":                4.2,
+		"test_float_from_int":       42.0,
 		"test_string_from_num":      "32",
 		"test_string_from_json_num": "3910564293633576924",
 		"test_bool":                 true,